@@ -0,0 +1,400 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ztkent/parks-explorer/internal/config"
+	"github.com/ztkent/parks-explorer/internal/dashboard"
+	"github.com/ztkent/parks-explorer/internal/database"
+	"github.com/ztkent/parks-explorer/internal/npsfake"
+	"github.com/ztkent/replay"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// Session tokens seeded into every test database
+const (
+	userSession   = "test-user-session"
+	logoutSession = "test-logout-session"
+	adminSession  = "test-admin-session"
+)
+
+// adminEmail is the seeded admin, configured as ADMIN_EMAIL
+const adminEmail = "ztkent@gmail.com"
+
+// eventID is a recorded event in the npsfake fixtures
+const eventID = "4C2C3E30-7D8F-4091-ACBD-2E3F4A5B6C77"
+
+// handlerCase is one request against the test server. Its response is compared to
+// testdata/golden/<name>.golden unless statusOnly is set.
+type handlerCase struct {
+	name       string
+	method     string
+	path       string
+	body       string
+	session    string
+	status     int
+	statusOnly bool
+}
+
+// newTestServer serves every route from a temp SQLite database and the npsfake fixtures.
+// The park list is synced before the dashboard starts, so pages don't race the boot sync.
+func newTestServer(t *testing.T, fake *npsfake.Client) *chi.Mux {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	seedTestDatabase(t, dbPath)
+
+	cfg := config.Default()
+	cfg.DBPath = dbPath
+	cfg.AdminEmail = adminEmail
+	cfg.BaseURL = "http://example.com"
+	cfg.Sync = config.Sync{}
+	dm := dashboard.NewDashboardWithAPI(fake, cfg, slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { dm.Close() })
+
+	r := chi.NewRouter()
+	DefineRoutes(r, dm, replay.NewCache())
+	return r
+}
+
+// seedTestDatabase syncs the fixture parks and adds a user, an admin and a trip
+func seedTestDatabase(t *testing.T, dbPath string) {
+	t.Helper()
+	db, err := database.NewDatabase(dbPath, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := dashboard.NewParkService(npsfake.New(npsfake.Fixtures()), db, slog.New(slog.DiscardHandler)).SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+
+	users := []struct {
+		email    string
+		sessions []string
+	}{
+		{"ranger@example.com", []string{userSession, logoutSession}},
+		{adminEmail, []string{adminSession}},
+	}
+	for i, u := range users {
+		result, err := db.Exec(`INSERT INTO users (email, username, google_id, avatar_url) VALUES (?, ?, ?, '')`,
+			u.email, strings.Split(u.email, "@")[0], fmt.Sprintf("google-%d", i))
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		userID, _ := result.LastInsertId()
+		for _, session := range u.sessions {
+			if _, err := db.Exec(`INSERT INTO sessions (user_id, session_token, expires_at) VALUES (?, ?, datetime('now', '+1 day'))`,
+				userID, session); err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+		}
+	}
+
+	trip := &database.Trip{UserID: 1, Name: "Sierra loop", StartDate: "2026-11-20", Days: 2}
+	if err := db.CreateTrip(t.Context(), trip); err != nil {
+		t.Fatalf("failed to create trip: %v", err)
+	}
+	if err := db.AddTripItem(t.Context(), &database.TripItem{TripID: trip.ID, Day: 1, ItemType: database.EntityPark, ItemID: "yose", ParkCode: "yose", Title: "Yosemite National Park"}); err != nil {
+		t.Fatalf("failed to add trip item: %v", err)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	r := newTestServer(t, npsfake.New(npsfake.Fixtures()))
+
+	cases := []handlerCase{
+		// Pages
+		{name: "home", path: "/", status: 200},
+		{name: "things-to-do-page", path: "/things-to-do", status: 200},
+		{name: "events-page", path: "/events", status: 200},
+		{name: "camping-page", path: "/camping", status: 200},
+		{name: "news-page", path: "/news", status: 200},
+		{name: "park-page", path: "/parks/yosemite", status: 200},
+		{name: "park-page-unknown", path: "/parks/atlantis", status: 404},
+		{name: "my-parks-page", path: "/my-parks", status: 200},
+		{name: "trips-page", path: "/trips", session: userSession, status: 200},
+		{name: "trips-page-signed-out", path: "/trips", status: 200},
+		{name: "trip-page", path: "/trips/1", session: userSession, status: 200},
+		{name: "trip-page-other-user", path: "/trips/1", session: adminSession, status: 404},
+		{name: "trip-page-bad-id", path: "/trips/abc", session: userSession, status: 404},
+		{name: "trip-print", path: "/trips/1/print", session: userSession, status: 200},
+
+		// Feeds
+		{name: "news-rss", path: "/feeds/news.rss", status: 200},
+		{name: "news-atom", path: "/feeds/news.atom", status: 200},
+		{name: "park-news-atom", path: "/parks/yosemite/feed.atom", status: 200},
+		{name: "park-news-atom-unknown", path: "/parks/atlantis/feed.atom", status: 404},
+
+		// Static files
+		{name: "robots", path: "/robots.txt", status: 200},
+		{name: "webmanifest", path: "/site.webmanifest", status: 200, statusOnly: true},
+		{name: "sitemap", path: "/sitemap.xml", status: 200, statusOnly: true},
+		{name: "static-css", path: "/static/styles.css", status: 200, statusOnly: true},
+		{name: "static-missing", path: "/static/missing.css", status: 404},
+		{name: "static-outside-root", path: "/static/../templates/park.html", status: 404, statusOnly: true},
+
+		// Auth
+		{name: "google-login", path: "/api/auth/google", status: 307, statusOnly: true},
+		{name: "google-callback-bad-state", path: "/api/auth/google/callback?state=forged", status: 400},
+		{name: "logout", path: "/api/auth/logout", session: logoutSession, status: 303},
+		{name: "user-info-signed-out", path: "/api/user-info", status: 200},
+		{name: "user-info", path: "/api/user-info", session: userSession, status: 200},
+		{name: "auth-status-signed-out", path: "/api/auth-status", status: 200},
+		{name: "auth-status", path: "/api/auth-status", session: adminSession, status: 200},
+		{name: "avatar-signed-out", path: "/api/avatar", status: 401},
+		{name: "avatar-none", path: "/api/avatar", session: userSession, status: 404},
+		{name: "analytics-config", path: "/api/analytics/config", status: 200},
+
+		// Favorites
+		{name: "favorite-parks-signed-out", path: "/api/favorites/parks", status: 200},
+		{name: "favorites-signed-out", path: "/api/favorites", status: 401},
+		{name: "favorite-add", method: http.MethodPut, path: "/api/favorites/yose", session: userSession, status: 200},
+		{name: "favorite-add-unknown", method: http.MethodPut, path: "/api/favorites/atlantis", session: userSession, status: 404},
+		{name: "favorites", path: "/api/favorites", session: userSession, status: 200},
+		{name: "favorite-parks", path: "/api/favorites/parks", session: userSession, status: 200},
+		{name: "favorite-remove", method: http.MethodDelete, path: "/api/favorites/yose", session: userSession, status: 200},
+
+		// Trips API
+		{name: "trips-signed-out", path: "/api/trips", status: 401},
+		{name: "trips", path: "/api/trips", session: userSession, status: 200},
+		{name: "trip", path: "/api/trips/1", session: userSession, status: 200},
+		{name: "trip-unknown", path: "/api/trips/99", session: userSession, status: 404},
+		{name: "trip-create", method: http.MethodPost, path: "/api/trips", body: `{"name":"Canyon weekend","days":2}`, session: userSession, status: 201},
+		{name: "trip-create-bad-body", method: http.MethodPost, path: "/api/trips", body: `{`, session: userSession, status: 400},
+		{name: "trip-update", method: http.MethodPatch, path: "/api/trips/2", body: `{"notes":"Bring layers"}`, session: userSession, status: 200},
+		{name: "trip-item-add", method: http.MethodPost, path: "/api/trips/2/items", body: `{"day":1,"item_type":"park","item_id":"grca","park_code":"grca","title":"Grand Canyon National Park"}`, session: userSession, status: 201},
+		{name: "trip-item-add-bad-day", method: http.MethodPost, path: "/api/trips/2/items", body: `{"day":99,"item_type":"park","item_id":"grca","park_code":"grca","title":"Grand Canyon National Park"}`, session: userSession, status: 400},
+		{name: "trip-item-update", method: http.MethodPatch, path: "/api/trips/2/items/2", body: `{"notes":"Sunrise at Mather Point"}`, session: userSession, status: 200},
+		{name: "trip-item-delete", method: http.MethodDelete, path: "/api/trips/2/items/2", session: userSession, status: 204},
+		{name: "trip-delete", method: http.MethodDelete, path: "/api/trips/2", session: userSession, status: 204},
+
+		// Admin
+		{name: "admin-sync-runs-forbidden", path: "/api/admin/sync-runs", session: userSession, status: 403},
+		{name: "admin-sync-runs", path: "/api/admin/sync-runs", session: adminSession, status: 200},
+		{name: "admin-schema", path: "/api/admin/schema", session: adminSession, status: 200, statusOnly: true},
+		{name: "admin-status-forbidden", path: "/api/admin/status", session: userSession, status: 403},
+		{name: "admin-status", path: "/api/admin/status", session: adminSession, status: 200, statusOnly: true},
+
+		{name: "image-proxy-missing-url", path: "/api/image-proxy", status: 400},
+
+		// Parks
+		{name: "parks", path: "/api/parks", status: 200},
+		{name: "parks-second-page", path: "/api/parks?offset=2&limit=2", status: 200},
+		{name: "parks-bad-pagination", path: "/api/parks?offset=-5&limit=abc", status: 200},
+		{name: "parks-past-the-end", path: "/api/parks?offset=100", status: 200},
+		{name: "parks-featured", path: "/api/parks/featured", status: 200},
+		{name: "parks-search", path: "/api/parks/search?search=grand", status: 200},
+		{name: "parks-nearby", path: "/api/parks/nearby?lat=37.7&lng=-119.6&radius=100", status: 200},
+		{name: "parks-nearby-bad-coordinates", path: "/api/parks/nearby?lat=north&lng=-119.6", status: 400},
+		{name: "search", path: "/api/search?q=yosemite", status: 200, statusOnly: true},
+
+		// Things to do, events, camping and news
+		{name: "things-to-do-search", path: "/api/things-to-do/search?parkCode=yose", status: 200},
+		{name: "events-search", path: "/api/events/search", status: 200},
+		{name: "events-search-bad-pagination", path: "/api/events/search?limit=abc&start=-1", status: 200},
+		{name: "event-details", path: "/api/events/" + eventID + "/details", status: 200},
+		{name: "event-details-unknown", path: "/api/events/unknown/details", status: 404},
+		{name: "event-ics", path: "/api/events/" + eventID + "/event.ics", status: 200},
+		{name: "event-ics-unknown", path: "/api/events/unknown/event.ics", status: 404},
+		{name: "events-calendar", path: "/api/events/calendar.ics?park=yose", status: 200},
+		{name: "camping-search", path: "/api/camping/search?parkCode=yose", status: 200},
+		{name: "news-search", path: "/api/news/search", status: 200},
+
+		// Park tabs
+		{name: "park-overview", path: "/api/parks/yose/overview", status: 200},
+		{name: "park-activities", path: "/api/parks/yose/activities", status: 200},
+		{name: "park-media", path: "/api/parks/yose/media", status: 200},
+		{name: "park-news", path: "/api/parks/yose/news", status: 200},
+		{name: "park-details", path: "/api/parks/yose/details", status: 200},
+		{name: "park-overview-unknown", path: "/api/parks/atlantis/overview", status: 200},
+		{name: "park-details-unknown", path: "/api/parks/atlantis/details", status: 200},
+
+		// JSON API
+		{name: "v1-parks", path: "/api/v1/parks?state=CA", status: 200},
+		{name: "v1-parks-bad-limit", path: "/api/v1/parks?limit=abc", status: 400},
+		{name: "v1-park", path: "/api/v1/parks/yose", status: 200},
+		{name: "v1-park-unknown", path: "/api/v1/parks/atlantis", status: 404},
+		{name: "v1-park-activities", path: "/api/v1/parks/yose/activities", status: 200},
+		{name: "v1-park-unknown-tab", path: "/api/v1/parks/yose/gift-shops", status: 404},
+		{name: "v1-things-to-do", path: "/api/v1/things-to-do?park=acad", status: 200},
+		{name: "v1-events", path: "/api/v1/events?park=yose", status: 200},
+		{name: "v1-events-second-page", path: "/api/v1/events?park=yose&limit=1&offset=1", status: 200},
+		{name: "v1-event", path: "/api/v1/events/" + eventID, status: 200},
+		{name: "v1-event-unknown", path: "/api/v1/events/unknown", status: 404},
+		{name: "v1-campgrounds", path: "/api/v1/campgrounds?offset=1&limit=1", status: 200},
+		{name: "v1-news", path: "/api/v1/news", status: 200},
+		{name: "v1-not-found", path: "/api/v1/rangers", status: 404},
+		{name: "v1-method-not-allowed", method: http.MethodPost, path: "/api/v1/parks", status: 405},
+
+		// Templates and docs
+		{name: "template-header", path: "/api/templates/header", status: 200},
+		{name: "template-footer", path: "/api/templates/footer", status: 200},
+		{name: "template-unknown", path: "/api/templates/sidebar", status: 404},
+		{name: "openapi", path: "/api/openapi.json", status: 200, statusOnly: true},
+		{name: "metrics-not-public", path: "/metrics", status: 404, statusOnly: true}, // Served on METRICS_ADDR
+		{name: "healthz", path: "/healthz", status: 200},
+		{name: "readyz", path: "/readyz", status: 200},
+		{name: "api-docs", path: "/api/docs", status: 200},
+	}
+	runHandlerCases(t, r, cases)
+}
+
+// TestHandlersNPSFailure serves the synced parks while every NPS API call fails
+func TestHandlersNPSFailure(t *testing.T) {
+	fake := npsfake.New(npsfake.Fixtures())
+	fake.Fail("*", errors.New("nps api unavailable"))
+	r := newTestServer(t, fake)
+
+	cases := []handlerCase{
+		{name: "nps-failure-park-page", path: "/parks/yosemite", status: 200},
+		{name: "nps-failure-park-overview", path: "/api/parks/yose/overview", status: 200},
+		{name: "nps-failure-park-details", path: "/api/parks/yose/details", status: 200},
+		{name: "nps-failure-events-search", path: "/api/events/search", status: 500},
+		{name: "nps-failure-camping-search", path: "/api/camping/search?parkCode=yose", status: 500},
+		{name: "nps-failure-news-rss", path: "/feeds/news.rss", status: 500},
+		{name: "nps-failure-v1-events", path: "/api/v1/events", status: 502},
+		{name: "nps-failure-v1-event", path: "/api/v1/events/unknown", status: 502},
+		{name: "nps-failure-event-ics", path: "/api/events/unknown/event.ics", status: 502},
+		{name: "nps-failure-v1-park-tab", path: "/api/v1/parks/yose/news", status: 200},
+	}
+	runHandlerCases(t, r, cases)
+}
+
+// TestHandlersOutsideRepo serves pages and static files with the working directory outside
+// the repository, which only works because web/ is embedded
+func TestHandlersOutsideRepo(t *testing.T) {
+	r := newTestServer(t, npsfake.New(npsfake.Fixtures()))
+	t.Chdir(t.TempDir())
+
+	for _, path := range []string{"/", "/static/styles.css", "/robots.txt", "/parks/yosemite", "/api/parks/yose/overview"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: got status %d", path, w.Code)
+		}
+	}
+}
+
+// TestHandlerContentTypes checks the media type of responses that aren't HTML. Goldens record it
+// too, but -update rewrites whatever is served, so a route wrapped in the replay response cache,
+// which drops the headers a handler sets on a cache miss, would be locked in serving text/plain.
+// Each path is requested twice, so both a cache miss and a hit would be caught.
+func TestHandlerContentTypes(t *testing.T) {
+	r := newTestServer(t, npsfake.New(npsfake.Fixtures()))
+
+	cases := []struct {
+		path      string
+		mediaType string
+	}{
+		{"/api/events/" + eventID + "/event.ics", "text/calendar"},
+		{"/api/events/calendar.ics?park=yose", "text/calendar"},
+		{"/feeds/news.rss", "application/rss+xml"},
+		{"/feeds/news.atom", "application/atom+xml"},
+		{"/parks/yosemite/feed.atom", "application/atom+xml"},
+		{"/api/parks/nearby?lat=37.7&lng=-119.6&radius=100", "application/json"},
+		{"/api/search?q=yosemite", "application/json"},
+		{"/api/v1/parks", "application/json"},
+		{"/api/openapi.json", "application/json"},
+	}
+	for _, tc := range cases {
+		for range 2 {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+			if w.Code != http.StatusOK || mediaType != tc.mediaType {
+				t.Errorf("GET %s: got %d %q, want 200 %s", tc.path, w.Code, w.Header().Get("Content-Type"), tc.mediaType)
+				break
+			}
+		}
+	}
+
+	// The image proxy sets its own headers, which replay would drop on a miss
+	for range 2 {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/image-proxy", nil))
+		if w.Code != http.StatusBadRequest || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("GET /api/image-proxy: got %d %v, want 400 with nosniff", w.Code, w.Header())
+			break
+		}
+	}
+}
+
+func runHandlerCases(t *testing.T, r http.Handler, cases []handlerCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tc.session != "" {
+				req.AddCookie(&http.Cookie{Name: "session_token", Value: tc.session})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Errorf("%s %s: got status %d, want %d\n%s", method, tc.path, w.Code, tc.status, w.Body.String())
+			}
+			if tc.statusOnly {
+				return
+			}
+			got := fmt.Sprintf("%d %s\n\n%s", w.Code, w.Header().Get("Content-Type"), normalizeResponse(w.Body.String()))
+			compareGolden(t, tc.name, got)
+		})
+	}
+}
+
+// Response content that changes from run to run
+var (
+	timestampPattern = regexp.MustCompile(`\d{4}-\d\d-\d\d[T ]\d\d:\d\d:\d\d(\.\d+)?(Z|[+-]\d\d:\d\d)?`)
+	icsStampPattern  = regexp.MustCompile(`(DTSTAMP|LAST-MODIFIED):\d{8}T\d{6}Z`)
+	etagPattern      = regexp.MustCompile(`"etag":"[^"]*"`)
+)
+
+func normalizeResponse(body string) string {
+	body = timestampPattern.ReplaceAllString(body, "<timestamp>")
+	body = icsStampPattern.ReplaceAllString(body, "$1:<timestamp>")
+	return etagPattern.ReplaceAllString(body, `"etag":"<etag>"`)
+}
+
+// compareGolden compares got to testdata/golden/<name>.golden, rewriting it with -update
+func compareGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run go test -update to create it: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("response differs from %s, run go test -update to accept it\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,435 @@
+// Package config loads the server's settings into a Config and validates them at startup.
+//
+// Every setting is named by its environment variable, e.g. SERVER_PORT. Settings are read from, in
+// increasing order of precedence: their defaults, an optional config file of KEY=value lines in
+// the format of .env.example, the environment, and command-line flags named after the variable in
+// lower case with dashes, e.g. -server-port.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds every setting the server reads at startup
+type Config struct {
+	Env      string // "dev" serves HTTP and sets cookies without Secure, anything else serves HTTPS
+	Port     string
+	LogLevel string
+	DBPath   string
+	WebDir   string // Serve templates and static files from disk rather than the embedded copies
+
+	// BaseURL is the public URL of the site, used for absolute links in feeds. It defaults to
+	// localhost in dev.
+	BaseURL string
+
+	// TLS certificate and key, required outside dev
+	CertPath    string
+	CertKeyPath string
+
+	// AdminEmail is the Google account allowed to use the admin routes, none when empty
+	AdminEmail string
+
+	// MetricsAddr is where Prometheus metrics are served, on a listener of their own so they
+	// aren't public. Metrics aren't served when empty.
+	MetricsAddr string
+
+	NPS        NPS
+	Google     Google
+	Analytics  Analytics
+	ImageCache ImageCache
+	Sync       Sync
+
+	// CacheTTLs overrides how long each type of cached per-park data is fresh, by data type,
+	// e.g. "alerts". Types left out keep the dashboard's default.
+	CacheTTLs map[string]time.Duration
+}
+
+// CacheDataTypes are the types of per-park NPS data cached in the database, each with a
+// CACHE_TTL_<TYPE> setting, e.g. CACHE_TTL_ALERTS
+var CacheDataTypes = []string{
+	"things_to_do", "tours", "activities",
+	"galleries", "videos", "audio", "webcams",
+	"articles", "alerts", "events", "news_releases",
+	"visitor_centers", "campgrounds", "fees", "amenities", "parking_lots",
+}
+
+// NPS selects where NPS API data comes from
+type NPS struct {
+	APIKey      string
+	Mode        string // live, fake (recorded fixtures) or record (live, saving fixtures)
+	FixturesDir string
+
+	// How long to wait on an NPS call before giving up on it, overridden per endpoint by
+	// EndpointTimeouts, keyed like the parks_nps_* metrics, e.g. "events"
+	Timeout          time.Duration
+	EndpointTimeouts map[string]time.Duration
+}
+
+// Google holds the OAuth client used for sign in
+type Google struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+}
+
+// Analytics configures Google Analytics
+type Analytics struct {
+	ID      string
+	Enabled bool
+	Debug   bool // Log events rather than sending them
+}
+
+// ImageCache configures the on-disk cache of resized images
+type ImageCache struct {
+	Dir   string // Defaults to image-cache/ beside the database
+	MaxMB int64
+}
+
+// Sync controls how often the background sync runs and how hard it may use the NPS API
+type Sync struct {
+	ParksInterval    time.Duration // How often to re-sync the park list, 0 disables
+	ParkDataInterval time.Duration // How often to refresh stale per-park data, 0 disables
+	RequestsPerHour  int           // NPS request budget for background syncing
+}
+
+// Default returns the settings used for anything not configured. The sync runs daily, leaving
+// most of the NPS default 1000 requests/hour for visitor traffic.
+func Default() *Config {
+	return &Config{
+		Env:        "prod",
+		Port:       "8086",
+		LogLevel:   "info",
+		DBPath:     "./data/dashboard.db",
+		NPS:        NPS{Mode: "live", Timeout: 20 * time.Second},
+		ImageCache: ImageCache{MaxMB: 512},
+		Sync: Sync{
+			ParksInterval:    24 * time.Hour,
+			ParkDataInterval: 24 * time.Hour,
+			RequestsPerHour:  300,
+		},
+	}
+}
+
+// setting is one configurable value, named by its environment variable
+type setting struct {
+	name  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"ENV", "Environment mode, dev serves HTTP and anything else HTTPS", func(c *Config, v string) error { c.Env = v; return nil }},
+	{"SERVER_PORT", "Port to listen on", func(c *Config, v string) error { c.Port = v; return nil }},
+	{"LOG_LEVEL", "debug, info, warn or error", func(c *Config, v string) error { c.LogLevel = v; return nil }},
+	{"DB_PATH", "SQLite database path", func(c *Config, v string) error { c.DBPath = v; return nil }},
+	{"WEB_DIR", "Serve templates and static files from this directory", func(c *Config, v string) error { c.WebDir = v; return nil }},
+	{"BASE_URL", "Public URL of the site for absolute links in feeds, e.g. https://parksexplorer.us", func(c *Config, v string) error { c.BaseURL = strings.TrimRight(v, "/"); return nil }},
+	{"CERT_PATH", "PEM TLS certificate, reloaded when it changes", func(c *Config, v string) error { c.CertPath = v; return nil }},
+	{"CERT_KEY_PATH", "PEM private key for CERT_PATH", func(c *Config, v string) error { c.CertKeyPath = v; return nil }},
+	{"ADMIN_EMAIL", "Google account allowed to use the admin routes", func(c *Config, v string) error { c.AdminEmail = v; return nil }},
+	{"METRICS_ADDR", "Address to serve Prometheus /metrics on, separately from the site, e.g. localhost:9090", func(c *Config, v string) error { c.MetricsAddr = v; return nil }},
+	{"NPS_API_KEY", "National Park Service API key", func(c *Config, v string) error { c.NPS.APIKey = v; return nil }},
+	{"NPS_MODE", "NPS data source: live, fake or record", func(c *Config, v string) error { c.NPS.Mode = v; return nil }},
+	{"NPS_FIXTURES_DIR", "Fixture directory for fake and record modes", func(c *Config, v string) error { c.NPS.FixturesDir = v; return nil }},
+	{"NPS_TIMEOUT", "How long to wait on an NPS call before giving up", func(c *Config, v string) error { return parseDuration(v, &c.NPS.Timeout) }},
+	{"NPS_ENDPOINT_TIMEOUTS", "NPS_TIMEOUT for particular endpoints, e.g. events=30s,parks=10s", func(c *Config, v string) error {
+		return parseDurations(v, &c.NPS.EndpointTimeouts)
+	}},
+	{"GOOGLE_CLIENT_ID", "Google OAuth client ID", func(c *Config, v string) error { c.Google.ClientID = v; return nil }},
+	{"GOOGLE_CLIENT_SECRET", "Google OAuth client secret", func(c *Config, v string) error { c.Google.ClientSecret = v; return nil }},
+	{"GOOGLE_REDIRECT_URI", "Google OAuth redirect URI", func(c *Config, v string) error { c.Google.RedirectURI = v; return nil }},
+	{"GOOGLE_ANALYTICS_ID", "Google Analytics measurement ID, enables analytics unless GOOGLE_ANALYTICS_ENABLED=false", func(c *Config, v string) error {
+		c.Analytics.ID = v
+		c.Analytics.Enabled = v != ""
+		return nil
+	}},
+	{"GOOGLE_ANALYTICS_ENABLED", "Enable Google Analytics", func(c *Config, v string) error { return parseBool(v, &c.Analytics.Enabled) }},
+	{"GOOGLE_ANALYTICS_DEBUG", "Log analytics events instead of sending them", func(c *Config, v string) error { return parseBool(v, &c.Analytics.Debug) }},
+	{"IMAGE_CACHE_DIR", "Where resized images are cached", func(c *Config, v string) error { c.ImageCache.Dir = v; return nil }},
+	{"IMAGE_CACHE_MAX_MB", "Size limit of the resized image cache", func(c *Config, v string) error { return parseInt(v, &c.ImageCache.MaxMB) }},
+	{"SYNC_PARKS_INTERVAL", "How often to re-sync the park list, 0 disables", func(c *Config, v string) error { return parseDuration(v, &c.Sync.ParksInterval) }},
+	{"SYNC_PARK_DATA_INTERVAL", "How often to refresh stale per-park data, 0 disables", func(c *Config, v string) error { return parseDuration(v, &c.Sync.ParkDataInterval) }},
+	{"SYNC_REQUESTS_PER_HOUR", "NPS request budget for background syncing", func(c *Config, v string) error {
+		var n int64
+		err := parseInt(v, &n)
+		c.Sync.RequestsPerHour = int(n)
+		return err
+	}},
+}
+
+func init() {
+	for _, dataType := range CacheDataTypes {
+		settings = append(settings, setting{cacheTTLSetting(dataType), "How long cached " + strings.ReplaceAll(dataType, "_", " ") + " stay fresh before being refreshed", func(c *Config, v string) error {
+			var ttl time.Duration
+			if err := parseDuration(v, &ttl); err != nil {
+				return err
+			}
+			if c.CacheTTLs == nil {
+				c.CacheTTLs = make(map[string]time.Duration)
+			}
+			c.CacheTTLs[dataType] = ttl
+			return nil
+		}})
+	}
+}
+
+// cacheTTLSetting names the setting for a cached data type, e.g. CACHE_TTL_ALERTS
+func cacheTTLSetting(dataType string) string {
+	return "CACHE_TTL_" + strings.ToUpper(dataType)
+}
+
+// maxSyncRequestsPerHour caps the sync's request budget at one call a second, well above the NPS
+// default limit of 1000 an hour. It also keeps the sync's pacing interval from rounding to zero.
+const maxSyncRequestsPerHour = 3600
+
+// configFileVar names the config file when the -config flag isn't given
+const configFileVar = "CONFIG_FILE"
+
+// Load reads the config from args, the environment through lookupEnv and the config file named by
+// -config or CONFIG_FILE, then validates it. Every problem found is reported in the error.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	flags := flag.NewFlagSet("parks", flag.ContinueOnError)
+	configFile := flags.String("config", "", "File of KEY=value settings, as in .env.example (env "+configFileVar+")")
+	for _, s := range settings {
+		flags.String(flagName(s.name), "", s.usage+" (env "+s.name+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	if *configFile == "" {
+		*configFile, _ = lookupEnv(configFileVar)
+	}
+	if *configFile != "" {
+		if err := readFile(*configFile, values); err != nil {
+			return nil, err
+		}
+	}
+	// Empty values count as unset, so compose files can pass through variables that aren't set
+	for _, s := range settings {
+		if v, ok := lookupEnv(s.name); ok && strings.TrimSpace(v) != "" {
+			values[s.name] = v
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name != "config" && strings.TrimSpace(f.Value.String()) != "" {
+			values[envName(f.Name)] = f.Value.String()
+		}
+	})
+
+	c := Default()
+	var errs []error
+	for _, s := range settings {
+		if v := strings.TrimSpace(values[s.name]); v != "" {
+			if err := s.set(c, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if c.BaseURL == "" && c.IsDev() {
+		c.BaseURL = "http://localhost:" + c.Port
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks that the settings are complete and consistent
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("SERVER_PORT: %q is not a port number", c.Port)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		fail("LOG_LEVEL: %q is not debug, info, warn or error", c.LogLevel)
+	}
+	if c.DBPath == "" {
+		fail("DB_PATH is required")
+	}
+	if c.BaseURL == "" {
+		fail("BASE_URL is required outside dev for absolute links in feeds")
+	} else if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fail("BASE_URL: %q is not an http or https URL", c.BaseURL)
+	}
+	if c.AdminEmail != "" {
+		if _, err := mail.ParseAddress(c.AdminEmail); err != nil {
+			fail("ADMIN_EMAIL: %q is not an email address", c.AdminEmail)
+		}
+	}
+	if c.MetricsAddr != "" {
+		_, port, err := net.SplitHostPort(c.MetricsAddr)
+		if _, perr := strconv.Atoi(port); err != nil || perr != nil || port == c.Port {
+			fail("METRICS_ADDR: %q is not a host:port apart from SERVER_PORT", c.MetricsAddr)
+		}
+	}
+
+	switch c.NPS.Mode {
+	case "live", "record":
+		if c.NPS.APIKey == "" {
+			fail("NPS_API_KEY is required in %s mode, get one at https://www.nps.gov/subjects/developer/get-started.htm or set NPS_MODE=fake", c.NPS.Mode)
+		}
+	case "fake":
+	default:
+		fail("NPS_MODE: %q is not live, fake or record", c.NPS.Mode)
+	}
+	if c.NPS.Timeout <= 0 {
+		fail("NPS_TIMEOUT must be positive")
+	}
+	for _, endpoint := range slices.Sorted(maps.Keys(c.NPS.EndpointTimeouts)) {
+		if c.NPS.EndpointTimeouts[endpoint] <= 0 {
+			fail("NPS_ENDPOINT_TIMEOUTS: %s must be positive", endpoint)
+		}
+	}
+
+	if !c.IsDev() {
+		if c.CertPath == "" || c.CertKeyPath == "" {
+			fail("CERT_PATH and CERT_KEY_PATH are required outside dev, set ENV=dev to serve HTTP")
+		}
+		if c.Google.ClientID == "" || c.Google.ClientSecret == "" || c.Google.RedirectURI == "" {
+			fail("GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET and GOOGLE_REDIRECT_URI are required outside dev for Google sign in")
+		}
+	}
+
+	if c.ImageCache.MaxMB <= 0 {
+		fail("IMAGE_CACHE_MAX_MB must be positive")
+	}
+	if c.Sync.ParksInterval < 0 || c.Sync.ParkDataInterval < 0 {
+		fail("SYNC_PARKS_INTERVAL and SYNC_PARK_DATA_INTERVAL can't be negative")
+	}
+	if c.Sync.RequestsPerHour <= 0 || c.Sync.RequestsPerHour > maxSyncRequestsPerHour {
+		fail("SYNC_REQUESTS_PER_HOUR must be between 1 and %d", maxSyncRequestsPerHour)
+	}
+	for _, dataType := range CacheDataTypes {
+		if ttl, ok := c.CacheTTLs[dataType]; ok && ttl <= 0 {
+			fail("%s must be positive", cacheTTLSetting(dataType))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsDev reports whether the server runs in development mode. A nil Config isn't dev.
+func (c *Config) IsDev() bool {
+	return c != nil && c.Env == "dev"
+}
+
+// readFile reads KEY=value lines into values, skipping blank lines and # comments
+func readFile(path string, values map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+	return parseFile(f, path, values)
+}
+
+func parseFile(r io.Reader, path string, values map[string]string) error {
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.name] = true
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=value", path, line)
+		}
+		if !known[name] {
+			return fmt.Errorf("%s:%d: unknown setting %s", path, line, name)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	return nil
+}
+
+// flagName turns a setting name into its flag, e.g. SERVER_PORT into server-port
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// envName turns a flag back into its setting name
+func envName(flag string) string {
+	return strings.ReplaceAll(strings.ToUpper(flag), "-", "_")
+}
+
+func parseBool(value string, dst *bool) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", value)
+	}
+	*dst = b
+	return nil
+}
+
+func parseInt(value string, dst *int64) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a whole number", value)
+	}
+	*dst = n
+	return nil
+}
+
+func parseDuration(value string, dst *time.Duration) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration like 24h or 30m", value)
+	}
+	*dst = d
+	return nil
+}
+
+// parseDurations reads a comma separated list of name=duration pairs
+func parseDurations(value string, dst *map[string]time.Duration) error {
+	durations := make(map[string]time.Duration)
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("%q is not a list like events=30s,parks=10s", value)
+		}
+		var d time.Duration
+		if err := parseDuration(strings.TrimSpace(raw), &d); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		durations[name] = d
+	}
+	*dst = durations
+	return nil
+}
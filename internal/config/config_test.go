@@ -0,0 +1,217 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a lookupEnv backed by vars
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// devEnv is the least a dev server needs
+var devEnv = map[string]string{"ENV": "dev", "NPS_MODE": "fake"}
+
+func TestLoadDefaults(t *testing.T) {
+	c, err := Load(nil, env(devEnv))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if c.Port != "8086" || c.DBPath != "./data/dashboard.db" || c.Sync.RequestsPerHour != 300 {
+		t.Errorf("got %+v, want the defaults", c)
+	}
+	if !c.IsDev() {
+		t.Error("ENV=dev isn't dev")
+	}
+	if c.BaseURL != "http://localhost:8086" {
+		t.Errorf("got base URL %s, want localhost in dev", c.BaseURL)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "parks.env")
+	contents := `# Settings
+ENV=dev
+NPS_MODE=fake
+SERVER_PORT=9000
+LOG_LEVEL=debug
+DB_PATH="/var/lib/parks/parks.db"
+SYNC_PARKS_INTERVAL=1h
+`
+	if err := os.WriteFile(file, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load([]string{"-server-port", "9002"}, env(map[string]string{
+		"CONFIG_FILE": file,
+		"SERVER_PORT": "9001",
+		"LOG_LEVEL":   "warn",
+		"DB_PATH":     "", // Empty values don't override the file
+	}))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if c.Port != "9002" {
+		t.Errorf("got port %s, want the flag's 9002", c.Port)
+	}
+	if c.LogLevel != "warn" {
+		t.Errorf("got log level %s, want the environment's warn", c.LogLevel)
+	}
+	if c.DBPath != "/var/lib/parks/parks.db" {
+		t.Errorf("got db path %s, want the file's", c.DBPath)
+	}
+	if c.Sync.ParksInterval != time.Hour || c.Sync.ParkDataInterval != 24*time.Hour {
+		t.Errorf("got sync %+v, want the file's parks interval and the default park data interval", c.Sync)
+	}
+}
+
+func TestLoadAnalytics(t *testing.T) {
+	vars := map[string]string{"ENV": "dev", "NPS_MODE": "fake", "GOOGLE_ANALYTICS_ID": "G-TEST"}
+	c, err := Load(nil, env(vars))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if !c.Analytics.Enabled {
+		t.Error("analytics with an ID isn't enabled")
+	}
+
+	vars["GOOGLE_ANALYTICS_ENABLED"] = "false"
+	if c, err = Load(nil, env(vars)); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if c.Analytics.Enabled {
+		t.Error("GOOGLE_ANALYTICS_ENABLED=false didn't disable analytics")
+	}
+}
+
+func TestLoadCacheTTLs(t *testing.T) {
+	vars := map[string]string{"ENV": "dev", "NPS_MODE": "fake", "CACHE_TTL_ALERTS": "1h"}
+	c, err := Load([]string{"-cache-ttl-parking-lots", "168h"}, env(vars))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	want := map[string]time.Duration{"alerts": time.Hour, "parking_lots": 168 * time.Hour}
+	if !maps.Equal(c.CacheTTLs, want) {
+		t.Errorf("got cache TTLs %v, want %v", c.CacheTTLs, want)
+	}
+}
+
+func TestLoadNPSTimeouts(t *testing.T) {
+	c, err := Load(nil, env(devEnv))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if c.NPS.Timeout != 20*time.Second || c.NPS.EndpointTimeouts != nil {
+		t.Errorf("got timeout %s and %v, want the 20s default for every endpoint", c.NPS.Timeout, c.NPS.EndpointTimeouts)
+	}
+
+	vars := map[string]string{"ENV": "dev", "NPS_MODE": "fake", "NPS_TIMEOUT": "10s", "NPS_ENDPOINT_TIMEOUTS": "events=30s, parks = 5s"}
+	if c, err = Load(nil, env(vars)); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	want := map[string]time.Duration{"events": 30 * time.Second, "parks": 5 * time.Second}
+	if c.NPS.Timeout != 10*time.Second || !maps.Equal(c.NPS.EndpointTimeouts, want) {
+		t.Errorf("got timeout %s and %v, want 10s and %v", c.NPS.Timeout, c.NPS.EndpointTimeouts, want)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		args []string
+		want []string
+	}{
+		{
+			name: "production without secrets",
+			vars: map[string]string{},
+			want: []string{"NPS_API_KEY", "CERT_PATH", "GOOGLE_CLIENT_ID", "BASE_URL"},
+		},
+		{
+			name: "unparseable values",
+			vars: map[string]string{"ENV": "dev", "NPS_MODE": "fake", "SYNC_REQUESTS_PER_HOUR": "lots", "GOOGLE_ANALYTICS_DEBUG": "maybe", "CACHE_TTL_EVENTS": "weekly"},
+			want: []string{"SYNC_REQUESTS_PER_HOUR", "GOOGLE_ANALYTICS_DEBUG", "CACHE_TTL_EVENTS"},
+		},
+		{
+			name: "out of range values",
+			vars: map[string]string{"ENV": "dev", "NPS_MODE": "mock", "LOG_LEVEL": "loud", "ADMIN_EMAIL": "admin", "BASE_URL": "parksexplorer.us"},
+			args: []string{"-server-port", "80000", "-image-cache-max-mb", "-1"},
+			want: []string{"NPS_MODE", "LOG_LEVEL", "ADMIN_EMAIL", "BASE_URL", "SERVER_PORT", "IMAGE_CACHE_MAX_MB"},
+		},
+		{
+			name: "NPS timeouts",
+			vars: map[string]string{"ENV": "dev", "NPS_MODE": "fake", "NPS_TIMEOUT": "0s", "NPS_ENDPOINT_TIMEOUTS": "events=-1s"},
+			want: []string{"NPS_TIMEOUT must be positive", "NPS_ENDPOINT_TIMEOUTS: events"},
+		},
+		{
+			name: "unparseable NPS endpoint timeouts",
+			vars: map[string]string{"ENV": "dev", "NPS_MODE": "fake", "NPS_ENDPOINT_TIMEOUTS": "events"},
+			want: []string{"NPS_ENDPOINT_TIMEOUTS"},
+		},
+		{
+			name: "sync budget too high",
+			vars: map[string]string{"ENV": "dev", "NPS_MODE": "fake", "SYNC_REQUESTS_PER_HOUR": "4000000000000"},
+			want: []string{"SYNC_REQUESTS_PER_HOUR must be between 1 and 3600"},
+		},
+		{
+			name: "cache TTLs",
+			vars: map[string]string{"ENV": "dev", "NPS_MODE": "fake", "CACHE_TTL_ALERTS": "0s", "CACHE_TTL_FEES": "-1h"},
+			want: []string{"CACHE_TTL_ALERTS", "CACHE_TTL_FEES"},
+		},
+		{
+			name: "metrics on the site's port",
+			vars: map[string]string{"ENV": "dev", "NPS_MODE": "fake", "METRICS_ADDR": ":8086"},
+			want: []string{"METRICS_ADDR"},
+		},
+		{
+			name: "unknown flag",
+			vars: devEnv,
+			args: []string{"-port", "9000"},
+			want: []string{"flag provided but not defined"},
+		},
+		{
+			name: "missing config file",
+			vars: map[string]string{"CONFIG_FILE": filepath.Join(t.TempDir(), "missing.env")},
+			want: []string{"failed to open config file"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.args, env(tt.vars))
+			if err == nil {
+				t.Fatal("loaded an invalid config")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error doesn't mention %s: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	values := map[string]string{}
+	err := parseFile(strings.NewReader("\n# comment\nexport NPS_MODE = 'fake'\nADMIN_EMAIL=admin@example.com\n"), "parks.env", values)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if values["NPS_MODE"] != "fake" || values["ADMIN_EMAIL"] != "admin@example.com" {
+		t.Errorf("got %v", values)
+	}
+
+	if err := parseFile(strings.NewReader("NPS_MODE=fake\nSERVER_PROT=80\n"), "parks.env", values); err == nil || !strings.Contains(err.Error(), "parks.env:2") {
+		t.Errorf("got %v, want an unknown setting error on line 2", err)
+	}
+	if err := parseFile(strings.NewReader("NPS_MODE\n"), "parks.env", values); err == nil {
+		t.Error("parsed a line without a value")
+	}
+}
@@ -0,0 +1,54 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ztkent/parks-explorer/internal/database"
+)
+
+// SyncRunsHandler returns the most recent sync runs as JSON for admins
+func (dm *Dashboard) SyncRunsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	runs, err := dm.db.GetRecentSyncRuns(r.Context(), limit)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to get sync runs", "error", err)
+		http.Error(w, "Failed to get sync runs", http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []database.SyncRun{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"runs": runs,
+	})
+}
+
+// SchemaHandler returns the database schema version and migration history as JSON for admins
+func (dm *Dashboard) SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	migrations, err := dm.db.MigrationStatus(r.Context())
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to get migration status", "error", err)
+		http.Error(w, "Failed to get migration status", http.StatusInternalServerError)
+		return
+	}
+	version, err := dm.db.SchemaVersion(r.Context())
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to get schema version", "error", err)
+		http.Error(w, "Failed to get schema version", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"version":    version,
+		"migrations": migrations,
+	})
+}
@@ -2,9 +2,7 @@ package dashboard
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
-	"os"
 )
 
 // AnalyticsConfig represents the configuration for Google Analytics
@@ -14,53 +12,45 @@ type AnalyticsConfig struct {
 	Debug             bool   `json:"debug"`
 }
 
-// GetAnalyticsConfig returns the current analytics configuration
-func GetAnalyticsConfig() *AnalyticsConfig {
-	config := &AnalyticsConfig{
-		GoogleAnalyticsID: os.Getenv("GOOGLE_ANALYTICS_ID"),
-		Enabled:           os.Getenv("GOOGLE_ANALYTICS_ENABLED") == "true",
-		Debug:             os.Getenv("GOOGLE_ANALYTICS_DEBUG") == "true",
+// analyticsConfig returns the analytics configuration
+func (dm *Dashboard) analyticsConfig() *AnalyticsConfig {
+	return &AnalyticsConfig{
+		GoogleAnalyticsID: dm.cfg.Analytics.ID,
+		Enabled:           dm.cfg.Analytics.Enabled,
+		Debug:             dm.cfg.Analytics.Debug,
 	}
-
-	// Default to enabled if the environment variable is not set but ID is provided
-	if config.GoogleAnalyticsID != "" && os.Getenv("GOOGLE_ANALYTICS_ENABLED") == "" {
-		config.Enabled = true
-	}
-
-	return config
 }
 
 // AnalyticsConfigHandler returns the analytics configuration as JSON for client-side use
 func (dm *Dashboard) AnalyticsConfigHandler(w http.ResponseWriter, r *http.Request) {
-	config := GetAnalyticsConfig()
+	config := dm.analyticsConfig()
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(config); err != nil {
-		log.Printf("Failed to encode analytics config: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to encode analytics config", "error", err)
 		http.Error(w, "Failed to get analytics configuration", http.StatusInternalServerError)
 		return
 	}
 }
 
 // TrackPageView logs a page view for server-side tracking
-func TrackPageView(r *http.Request, page string) {
-	config := GetAnalyticsConfig()
+func (dm *Dashboard) TrackPageView(r *http.Request, page string) {
+	config := dm.analyticsConfig()
 	if !config.Enabled || config.Debug {
 		if config.Debug {
-			log.Printf("GA Debug: Page view tracked - Page: %s, User-Agent: %s, IP: %s",
-				page, r.UserAgent(), r.RemoteAddr)
+			dm.logger.DebugContext(r.Context(), "GA page view tracked", "page", page, "user_agent", r.UserAgent(), "ip", r.RemoteAddr)
 		}
 		return
 	}
 }
 
 // TrackEvent logs an event for server-side tracking
-func TrackEvent(r *http.Request, action, category, label string, value int) {
-	config := GetAnalyticsConfig()
+func (dm *Dashboard) TrackEvent(r *http.Request, action, category, label string, value int) {
+	config := dm.analyticsConfig()
 	if !config.Enabled || config.Debug {
 		if config.Debug {
-			log.Printf("GA Debug: Event tracked - Action: %s, Category: %s, Label: %s, Value: %d, User-Agent: %s",
-				action, category, label, value, r.UserAgent())
+			dm.logger.DebugContext(r.Context(), "GA event tracked", "action", action, "category", category,
+				"label", label, "value", value, "user_agent", r.UserAgent())
 		}
 		return
 	}
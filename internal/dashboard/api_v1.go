@@ -0,0 +1,520 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ztkent/parks-explorer/internal/database"
+)
+
+// Versioned JSON API.
+//
+// /api/v1 exposes the same data as the HTMX fragments, from the same ParkService calls. Lists are
+// wrapped in {"data": [...], "pagination": {...}}, single resources in {"data": {...}} and errors
+// in {"error": {...}}. Responses carry an ETag so clients can revalidate with If-None-Match.
+//
+// These routes don't go through the replay response cache, it keeps the headers a handler sets
+// for its own copy of the response, so the ETag and Content-Type would be lost on a cache miss.
+// Park data is already cached in SQLite by ParkService.
+
+const (
+	defaultAPILimit = 50
+	maxAPILimit     = 100
+
+	apiCacheControl = "public, max-age=300"
+)
+
+// apiPagination describes where a page sits in a list
+type apiPagination struct {
+	Total   int  `json:"total"`
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	HasMore bool `json:"has_more"`
+}
+
+// apiError is the body of every v1 error response
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// apiPark is a park as the v1 API returns it
+type apiPark struct {
+	ParkCode      string       `json:"park_code"`
+	Name          string       `json:"name"`
+	FullName      string       `json:"full_name"`
+	Slug          string       `json:"slug"`
+	States        []string     `json:"states"`
+	Designation   string       `json:"designation"`
+	Description   string       `json:"description"`
+	URL           string       `json:"url"`
+	Latitude      *float64     `json:"latitude"`
+	Longitude     *float64     `json:"longitude"`
+	DistanceMiles *float64     `json:"distance_miles,omitempty"`
+	Images        []apiImage   `json:"images"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	Links         apiParkLinks `json:"links"`
+}
+
+type apiImage struct {
+	URL     string `json:"url"`
+	Title   string `json:"title,omitempty"`
+	AltText string `json:"alt_text,omitempty"`
+	Caption string `json:"caption,omitempty"`
+	Credit  string `json:"credit,omitempty"`
+}
+
+type apiParkLinks struct {
+	Self string `json:"self"`
+	Page string `json:"page"`
+}
+
+// parkTabs maps the park tab names accepted by APIParkTabHandler to their loaders
+var parkTabs = map[string]func(ctx context.Context, ps *ParkService, parkCode string) interface{}{
+	"overview": func(ctx context.Context, ps *ParkService, parkCode string) interface{} {
+		return ps.GetParkOverview(ctx, parkCode)
+	},
+	"activities": func(ctx context.Context, ps *ParkService, parkCode string) interface{} {
+		return ps.GetParkActivitiesTab(ctx, parkCode)
+	},
+	"media": func(ctx context.Context, ps *ParkService, parkCode string) interface{} {
+		return ps.GetParkMedia(ctx, parkCode)
+	},
+	"news": func(ctx context.Context, ps *ParkService, parkCode string) interface{} {
+		return ps.GetParkNews(ctx, parkCode)
+	},
+	"details": func(ctx context.Context, ps *ParkService, parkCode string) interface{} {
+		return ps.GetParkDetails(ctx, parkCode)
+	},
+}
+
+// APIParksHandler lists parks by name, or nearest first when lat and lng are given.
+// Filters: q, state, lat, lng, radius.
+func (dm *Dashboard) APIParksHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parseAPIPage(w, r)
+	if !ok {
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	stateCode := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("state")))
+
+	var parks []apiPark
+	if lat, lng, hasLocation := parseLocation(r); hasLocation {
+		radius, ok := parseRadius(r)
+		if !ok {
+			radius = defaultNearbyRadius
+		}
+		nearby, err := dm.parkService.GetParksNear(r.Context(), lat, lng, radius, maxNearbyParks)
+		if err != nil {
+			dm.logger.ErrorContext(r.Context(), "Failed to get nearby parks for API", "lat", lat, "lng", lng, "error", err)
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", "Failed to get nearby parks")
+			return
+		}
+		for _, park := range nearby {
+			distance := park.DistanceMiles
+			p := toAPIPark(park.CachedPark)
+			p.DistanceMiles = &distance
+			parks = append(parks, p)
+		}
+	} else {
+		var cached []database.CachedPark
+		var err error
+		if query != "" {
+			cached, err = dm.parkService.SearchParks(r.Context(), query)
+		} else {
+			cached, err = dm.parkService.GetAllParks(r.Context())
+		}
+		if err != nil {
+			dm.logger.ErrorContext(r.Context(), "Failed to get parks for API", "error", err)
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", "Failed to get parks")
+			return
+		}
+		for _, park := range cached {
+			parks = append(parks, toAPIPark(park))
+		}
+	}
+
+	filtered := parks[:0]
+	for _, park := range parks {
+		if stateCode != "" && !containsString(park.States, stateCode) {
+			continue
+		}
+		if query != "" && park.DistanceMiles != nil && !parkMatchesQuery(park, query) {
+			continue
+		}
+		filtered = append(filtered, park)
+	}
+
+	total := len(filtered)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	writeAPIList(w, filtered[start:end], total, limit, offset)
+}
+
+// APIParkHandler returns a single park by park code
+func (dm *Dashboard) APIParkHandler(w http.ResponseWriter, r *http.Request) {
+	park, ok := dm.apiPark(w, r, chi.URLParam(r, "parkCode"))
+	if !ok {
+		return
+	}
+	writeAPIData(w, toAPIPark(*park))
+}
+
+// APIParkTabHandler returns the data behind one of a park page's tabs
+func (dm *Dashboard) APIParkTabHandler(w http.ResponseWriter, r *http.Request) {
+	load, ok := parkTabs[chi.URLParam(r, "tab")]
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Unknown park tab, expected overview, activities, media, news or details")
+		return
+	}
+	park, ok := dm.apiPark(w, r, chi.URLParam(r, "parkCode"))
+	if !ok {
+		return
+	}
+	writeAPIData(w, load(r.Context(), dm.parkService, park.ParkCode))
+}
+
+// APIThingsToDoHandler searches things to do. Filters: q, park, state, activity.
+func (dm *Dashboard) APIThingsToDoHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parseAPIPage(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+
+	results, err := dm.parkService.SearchThingsToDo(r.Context(), q.Get("activity"), q.Get("park"), q.Get("state"), q.Get("q"), limit, offset)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error searching things to do for API", "error", err)
+		writeAPIError(w, http.StatusBadGateway, "upstream_error", "Failed to search things to do")
+		return
+	}
+	writeAPIList(w, nonNil(results.Data), apiTotal(results.Total, offset, len(results.Data)), limit, offset)
+}
+
+// APIEventsHandler searches events. Filters: q, park, state, event_type, date_start, date_end.
+// Without dates it covers today to 3 months out, like the events page.
+func (dm *Dashboard) APIEventsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parseAPIPage(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+
+	dateStart, dateEnd := q.Get("date_start"), q.Get("date_end")
+	for _, date := range []string{dateStart, dateEnd} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid_parameter", "Dates must be formatted YYYY-MM-DD")
+			return
+		}
+	}
+	if dateStart == "" && dateEnd == "" {
+		now := time.Now()
+		dateStart = now.Format("2006-01-02")
+		dateEnd = now.AddDate(0, 3, 0).Format("2006-01-02")
+	}
+
+	results, err := dm.parkService.SearchEvents(r.Context(), q.Get("q"), q.Get("park"), q.Get("state"), q.Get("event_type"), dateStart, dateEnd, limit, offset)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error searching events for API", "error", err)
+		writeAPIError(w, http.StatusBadGateway, "upstream_error", "Failed to search events")
+		return
+	}
+	writeAPIList(w, nonNil(results.Data), apiTotal(results.Total, offset, len(results.Data)), limit, offset)
+}
+
+// APIEventHandler returns a single event by NPS event ID
+func (dm *Dashboard) APIEventHandler(w http.ResponseWriter, r *http.Request) {
+	eventID := chi.URLParam(r, "eventID")
+	event, err := dm.parkService.GetEventByID(r.Context(), eventID)
+	if errors.Is(err, errEventNotFound) {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Event not found")
+		return
+	}
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error fetching event for API", "event", eventID, "error", err)
+		writeAPIError(w, http.StatusBadGateway, "upstream_error", "Failed to fetch event")
+		return
+	}
+	writeAPIData(w, event)
+}
+
+// APICampgroundsHandler searches campgrounds. Filters: q, park, state.
+func (dm *Dashboard) APICampgroundsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parseAPIPage(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+
+	results, err := dm.parkService.SearchCampgrounds(r.Context(), q.Get("q"), q.Get("park"), q.Get("state"), limit, offset)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error searching campgrounds for API", "error", err)
+		writeAPIError(w, http.StatusBadGateway, "upstream_error", "Failed to search campgrounds")
+		return
+	}
+	writeAPIList(w, nonNil(results.Data), apiTotal(results.Total, offset, len(results.Data)), limit, offset)
+}
+
+// APINewsHandler searches news releases, or articles or alerts with news_type.
+// Filters: q, park, state, news_type.
+func (dm *Dashboard) APINewsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parseAPIPage(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+
+	newsType := q.Get("news_type")
+	switch newsType {
+	case "", "releases":
+		newsType = "releases"
+	case "articles", "alerts":
+	default:
+		writeAPIError(w, http.StatusBadRequest, "invalid_parameter", "news_type must be releases, articles or alerts")
+		return
+	}
+
+	newsData, err := dm.parkService.SearchNews(r.Context(), q.Get("q"), q.Get("park"), q.Get("state"), newsType, limit, offset)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error searching news for API", "error", err)
+		writeAPIError(w, http.StatusBadGateway, "upstream_error", "Failed to search news")
+		return
+	}
+	unifiedData := normalizeNewsData(newsType, newsData)
+	if unifiedData == nil {
+		dm.logger.WarnContext(r.Context(), "Failed to normalize news data for API", "type", newsType)
+		writeAPIError(w, http.StatusBadGateway, "upstream_error", "Failed to search news")
+		return
+	}
+	writeAPIList(w, nonNil(unifiedData.Data), apiTotal(unifiedData.Total, offset, len(unifiedData.Data)), limit, offset)
+}
+
+// APINotFoundHandler answers unknown v1 routes with a JSON error
+func (dm *Dashboard) APINotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, "not_found", "No such API endpoint")
+}
+
+// APIMethodNotAllowedHandler answers v1 routes called with the wrong method with a JSON error
+func (dm *Dashboard) APIMethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+}
+
+// ETagMiddleware adds a content hash ETag to successful GET responses and answers a matching
+// If-None-Match with 304 Not Modified
+func (dm *Dashboard) ETagMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &etagRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status != http.StatusOK {
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(rec.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(rec.body.Bytes())
+	})
+}
+
+// etagRecorder buffers a response so its ETag can be set before anything is written
+type etagRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *etagRecorder) WriteHeader(status int) {
+	rec.status = status
+}
+
+func (rec *etagRecorder) Write(b []byte) (int, error) {
+	return rec.body.Write(b)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using weak comparison
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// apiPark looks up a park by code, writing a 404 when it doesn't exist
+func (dm *Dashboard) apiPark(w http.ResponseWriter, r *http.Request, parkCode string) (*database.CachedPark, bool) {
+	parkID, err := dm.db.GetParkIDByCode(r.Context(), strings.ToLower(parkCode))
+	if err == nil {
+		var park *database.CachedPark
+		if park, err = dm.db.GetParkByID(r.Context(), parkID); err == nil {
+			return park, true
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(w, http.StatusNotFound, "not_found", "Park not found")
+		return nil, false
+	}
+	dm.logger.ErrorContext(r.Context(), "Failed to get park for API", "park", parkCode, "error", err)
+	writeAPIError(w, http.StatusInternalServerError, "internal_error", "Failed to get park")
+	return nil, false
+}
+
+func toAPIPark(park database.CachedPark) apiPark {
+	p := apiPark{
+		ParkCode:    park.ParkCode,
+		Name:        park.Name,
+		FullName:    park.FullName,
+		Slug:        park.Slug,
+		States:      []string{},
+		Designation: park.Designation,
+		Description: park.Description,
+		URL:         park.URL,
+		Images:      []apiImage{},
+		UpdatedAt:   park.UpdatedAt,
+		Links: apiParkLinks{
+			Self: "/api/v1/parks/" + park.ParkCode,
+			Page: "/parks/" + park.Slug,
+		},
+	}
+	for _, state := range strings.Split(park.States, ",") {
+		if state = strings.TrimSpace(state); state != "" {
+			p.States = append(p.States, state)
+		}
+	}
+	if lat, lng, ok := park.Coordinates(); ok {
+		p.Latitude, p.Longitude = &lat, &lng
+	}
+	for _, image := range park.Images {
+		p.Images = append(p.Images, apiImage{
+			URL:     image.URL,
+			Title:   image.Title,
+			AltText: image.AltText,
+			Caption: image.Caption,
+			Credit:  image.Credit,
+		})
+	}
+	return p
+}
+
+// parkMatchesQuery is the plain substring match used to filter nearby parks by q
+func parkMatchesQuery(park apiPark, query string) bool {
+	query = strings.ToLower(query)
+	for _, field := range []string{park.Name, park.FullName, park.ParkCode, park.Designation, park.Description} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAPIPage reads limit and offset, writing a 400 when either is invalid
+func parseAPIPage(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	limit, offset := defaultAPILimit, 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		l, err := strconv.Atoi(s)
+		if err != nil || l < 1 || l > maxAPILimit {
+			writeAPIError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("limit must be between 1 and %d", maxAPILimit))
+			return 0, 0, false
+		}
+		limit = l
+	}
+	if s := r.URL.Query().Get("offset"); s != "" {
+		o, err := strconv.Atoi(s)
+		if err != nil || o < 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_parameter", "offset must be a non-negative integer")
+			return 0, 0, false
+		}
+		offset = o
+	}
+	return limit, offset, true
+}
+
+// apiTotal parses an NPS total, falling back to what has been seen so far
+func apiTotal(total string, offset, count int) int {
+	if t, err := strconv.Atoi(total); err == nil && t >= offset+count {
+		return t
+	}
+	return offset + count
+}
+
+// nonNil keeps empty lists encoding as [] rather than null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func writeAPIList[T any](w http.ResponseWriter, items []T, total, limit, offset int) {
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{
+		"data": nonNil(items),
+		"pagination": apiPagination{
+			Total:   total,
+			Limit:   limit,
+			Offset:  offset,
+			HasMore: offset+len(items) < total,
+		},
+	})
+}
+
+func writeAPIData(w http.ResponseWriter, data interface{}) {
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeAPIJSON(w, status, map[string]interface{}{
+		"error": apiError{Status: status, Code: code, Message: message},
+	})
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusOK {
+		w.Header().Set("Cache-Control", apiCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Failed to encode API response", "error", err)
+	}
+}
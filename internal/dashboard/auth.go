@@ -6,11 +6,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
+	"github.com/ztkent/parks-explorer/internal/config"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
@@ -48,13 +48,19 @@ type GoogleUserInfo struct {
 	Picture string `json:"picture"`
 }
 
-var googleOAuthConfig = &oauth2.Config{
-	ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-	ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-	// Protocol: Use http:// for local development, https:// for production
-	RedirectURL: os.Getenv("GOOGLE_REDIRECT_URI"),
-	Scopes:      []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-	Endpoint:    google.Endpoint,
+// googleTimeout bounds the token exchange and profile request made by the OAuth callback
+const googleTimeout = 10 * time.Second
+
+// newGoogleOAuthConfig creates the OAuth client for Google sign in
+func newGoogleOAuthConfig(cfg config.Google) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		// Protocol: Use http:// for local development, https:// for production
+		RedirectURL: cfg.RedirectURI,
+		Scopes:      []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
+		Endpoint:    google.Endpoint,
+	}
 }
 
 func (s *Dashboard) GoogleLoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -64,10 +70,7 @@ func (s *Dashboard) GoogleLoginHandler(w http.ResponseWriter, r *http.Request) {
 	state := base64.URLEncoding.EncodeToString(b)
 
 	// Store state in session cookie
-	secure := true
-	if os.Getenv("ENV") == "dev" {
-		secure = false
-	}
+	secure := !s.cfg.IsDev()
 	http.SetCookie(w, &http.Cookie{
 		Name:     "oauth_state",
 		Value:    state,
@@ -77,7 +80,7 @@ func (s *Dashboard) GoogleLoginHandler(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode,
 	})
 
-	url := googleOAuthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	url := s.oauth.AuthCodeURL(state, oauth2.AccessTypeOffline)
 	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
 }
 
@@ -98,43 +101,42 @@ func (s *Dashboard) GoogleCallbackHandler(w http.ResponseWriter, r *http.Request
 	})
 
 	// Exchange code for token
+	ctx, cancel := context.WithTimeout(r.Context(), googleTimeout)
+	defer cancel()
 	code := r.URL.Query().Get("code")
-	token, err := googleOAuthConfig.Exchange(context.Background(), code)
+	token, err := s.oauth.Exchange(ctx, code)
 	if err != nil {
-		log.Printf("Failed to exchange token: %v", err)
+		s.logger.ErrorContext(r.Context(), "Failed to exchange token", "error", err)
 		http.Error(w, "Failed to exchange token", http.StatusInternalServerError)
 		return
 	}
 
 	// Get user info from Google
-	userInfo, err := s.getUserInfoFromGoogle(token.AccessToken)
+	userInfo, err := s.getUserInfoFromGoogle(ctx, token.AccessToken)
 	if err != nil {
-		log.Printf("Failed to get user info: %v", err)
+		s.logger.ErrorContext(r.Context(), "Failed to get user info", "error", err)
 		http.Error(w, "Failed to get user info", http.StatusInternalServerError)
 		return
 	}
 
 	// Create or update user in database
-	user, err := s.createOrUpdateUser(userInfo)
+	user, err := s.createOrUpdateUser(r.Context(), userInfo)
 	if err != nil {
-		log.Printf("Failed to create/update user: %v", err)
+		s.logger.ErrorContext(r.Context(), "Failed to create/update user", "error", err)
 		http.Error(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
 
 	// Create session
-	sessionToken, err := s.createSession(user.ID, token.AccessToken, token.RefreshToken)
+	sessionToken, err := s.createSession(r.Context(), user.ID, token.AccessToken, token.RefreshToken)
 	if err != nil {
-		log.Printf("Failed to create session: %v", err)
+		s.logger.ErrorContext(r.Context(), "Failed to create session", "error", err)
 		http.Error(w, "Failed to create session", http.StatusInternalServerError)
 		return
 	}
 
 	// Set session cookie
-	secure := true
-	if os.Getenv("ENV") == "dev" {
-		secure = false
-	}
+	secure := !s.cfg.IsDev()
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session_token",
 		Value:    sessionToken,
@@ -156,7 +158,7 @@ func (s *Dashboard) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("session_token")
 	if err == nil {
 		// Delete session from database
-		s.db.Exec("DELETE FROM sessions WHERE session_token = ?", cookie.Value)
+		s.db.ExecContext(r.Context(), "DELETE FROM sessions WHERE session_token = ?", cookie.Value)
 	}
 
 	// Clear session cookie
@@ -172,8 +174,12 @@ func (s *Dashboard) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-func (s *Dashboard) getUserInfoFromGoogle(accessToken string) (*GoogleUserInfo, error) {
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + accessToken)
+func (s *Dashboard) getUserInfoFromGoogle(ctx context.Context, accessToken string) (*GoogleUserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.googleapis.com/oauth2/v2/userinfo?access_token="+accessToken, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -187,10 +193,10 @@ func (s *Dashboard) getUserInfoFromGoogle(accessToken string) (*GoogleUserInfo,
 	return &userInfo, nil
 }
 
-func (s *Dashboard) createOrUpdateUser(userInfo *GoogleUserInfo) (*User, error) {
+func (s *Dashboard) createOrUpdateUser(ctx context.Context, userInfo *GoogleUserInfo) (*User, error) {
 	// Check if user exists
 	var user User
-	err := s.db.QueryRow(`
+	err := s.db.QueryRowContext(ctx, `
 		SELECT id, email, username, google_id, avatar_url, created_at, updated_at, is_active
 		FROM users WHERE google_id = ?
 	`, userInfo.ID).Scan(
@@ -205,7 +211,7 @@ func (s *Dashboard) createOrUpdateUser(userInfo *GoogleUserInfo) (*User, error)
 			username = userInfo.Email
 		}
 
-		result, err := s.db.Exec(`
+		result, err := s.db.ExecContext(ctx, `
 			INSERT INTO users (email, username, google_id, avatar_url)
 			VALUES (?, ?, ?, ?)
 		`, userInfo.Email, username, userInfo.ID, userInfo.Picture)
@@ -223,7 +229,7 @@ func (s *Dashboard) createOrUpdateUser(userInfo *GoogleUserInfo) (*User, error)
 		user.IsActive = true
 	} else {
 		// User exists, update info
-		_, err = s.db.Exec(`
+		_, err = s.db.ExecContext(ctx, `
 			UPDATE users SET email = ?, avatar_url = ?, updated_at = CURRENT_TIMESTAMP
 			WHERE google_id = ?
 		`, userInfo.Email, userInfo.Picture, userInfo.ID)
@@ -236,14 +242,14 @@ func (s *Dashboard) createOrUpdateUser(userInfo *GoogleUserInfo) (*User, error)
 	return &user, nil
 }
 
-func (s *Dashboard) createSession(userID int, accessToken, refreshToken string) (string, error) {
+func (s *Dashboard) createSession(ctx context.Context, userID int, accessToken, refreshToken string) (string, error) {
 	// Generate session token
 	b := make([]byte, 32)
 	rand.Read(b)
 	sessionToken := base64.URLEncoding.EncodeToString(b)
 
 	// Insert session into database
-	_, err := s.db.Exec(`
+	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO sessions (user_id, session_token, access_token, refresh_token, expires_at)
 		VALUES (?, ?, ?, ?, ?)
 	`, userID, sessionToken, accessToken, refreshToken, time.Now().Add(7*24*time.Hour))
@@ -258,7 +264,7 @@ func (s *Dashboard) GetCurrentUser(r *http.Request) (*User, error) {
 	}
 
 	var user User
-	err = s.db.QueryRow(`
+	err = s.db.QueryRowContext(r.Context(), `
 		SELECT u.id, u.email, u.username, u.google_id, u.avatar_url, u.created_at, u.updated_at, u.is_active
 		FROM users u
 		JOIN sessions s ON u.id = s.user_id
@@ -289,7 +295,7 @@ func (s *Dashboard) AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// AdminMiddleware restricts access to admin-only endpoints to ztkent@gmail.com
+// AdminMiddleware restricts access to admin-only endpoints to the configured ADMIN_EMAIL
 func (s *Dashboard) AdminMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user, err := s.GetCurrentUser(r)
@@ -298,8 +304,8 @@ func (s *Dashboard) AdminMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Check if user is the admin
-		if user.Email != "ztkent@gmail.com" {
+		// Check if user is the admin, with no admin configured nobody is
+		if s.cfg.AdminEmail == "" || !strings.EqualFold(user.Email, s.cfg.AdminEmail) {
 			http.Error(w, "Forbidden - Admin access required", http.StatusForbidden)
 			return
 		}
@@ -0,0 +1,478 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ztkent/go-nps"
+	"github.com/ztkent/parks-explorer/internal/database"
+)
+
+// iCalendar (RFC 5545) export for NPS events.
+//
+// EventICSHandler downloads a single event, EventsCalendarHandler serves any events search as a
+// feed that calendar apps can subscribe to with a webcal:// link. The NPS API already expands
+// recurring events, so each occurrence becomes its own VEVENT with a UID built from the NPS event
+// ID and the occurrence date and time. That keeps UIDs stable between refreshes, so calendar apps
+// update events in place rather than duplicating them.
+
+const (
+	icsProdID         = "-//Parks Explorer//NPS Events//EN"
+	icsUIDDomain      = "parksexplorer.us"
+	icsDateFormat     = "20060102"
+	icsDateTimeFormat = "20060102T150405"
+	icsLineLimit      = 75
+
+	// Calendar apps poll subscriptions at roughly this interval, and may reuse a response as long
+	calendarRefreshInterval = "PT6H"
+	calendarCacheControl    = "public, max-age=21600"
+
+	calendarPageSize  = 100
+	maxCalendarEvents = 500
+)
+
+// stateTimeZones maps a park's first listed state to its time zone, states split across zones use
+// the zone most of their parks are in
+var stateTimeZones = map[string]string{
+	"AL": "America/Chicago", "AK": "America/Anchorage", "AS": "Pacific/Pago_Pago", "AZ": "America/Phoenix",
+	"AR": "America/Chicago", "CA": "America/Los_Angeles", "CO": "America/Denver", "CT": "America/New_York",
+	"DE": "America/New_York", "DC": "America/New_York", "FL": "America/New_York", "GA": "America/New_York",
+	"GU": "Pacific/Guam", "HI": "Pacific/Honolulu", "ID": "America/Boise", "IL": "America/Chicago",
+	"IN": "America/Indiana/Indianapolis", "IA": "America/Chicago", "KS": "America/Chicago", "KY": "America/New_York",
+	"LA": "America/Chicago", "ME": "America/New_York", "MD": "America/New_York", "MA": "America/New_York",
+	"MI": "America/Detroit", "MN": "America/Chicago", "MS": "America/Chicago", "MO": "America/Chicago",
+	"MP": "Pacific/Saipan", "MT": "America/Denver", "NE": "America/Chicago", "NV": "America/Los_Angeles",
+	"NH": "America/New_York", "NJ": "America/New_York", "NM": "America/Denver", "NY": "America/New_York",
+	"NC": "America/New_York", "ND": "America/Chicago", "OH": "America/New_York", "OK": "America/Chicago",
+	"OR": "America/Los_Angeles", "PA": "America/New_York", "PR": "America/Puerto_Rico", "RI": "America/New_York",
+	"SC": "America/New_York", "SD": "America/Chicago", "TN": "America/Chicago", "TX": "America/Chicago",
+	"UT": "America/Denver", "VT": "America/New_York", "VI": "America/St_Thomas", "VA": "America/New_York",
+	"WA": "America/Los_Angeles", "WV": "America/New_York", "WI": "America/Chicago", "WY": "America/Denver",
+}
+
+// eventTimeFormats are the layouts NPS uses for event start and end times
+var eventTimeFormats = []string{"03:04 PM", "3:04 PM", "03:04PM", "3:04PM", "15:04"}
+
+// eventUpdatedFormats are the layouts NPS uses for datetimeupdated
+var eventUpdatedFormats = []string{"2006-01-02 15:04:05.0", "2006-01-02 15:04:05", time.RFC3339}
+
+// EventICSHandler downloads a single event, and all of its dates, as an .ics file
+func (dm *Dashboard) EventICSHandler(w http.ResponseWriter, r *http.Request) {
+	eventID := chi.URLParam(r, "eventID")
+	if eventID == "" {
+		http.Error(w, "Event ID required", http.StatusBadRequest)
+		return
+	}
+
+	event, err := dm.parkService.GetEventByID(r.Context(), eventID)
+	if errors.Is(err, errEventNotFound) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error fetching event for calendar", "event", eventID, "error", err)
+		http.Error(w, "Error fetching event", http.StatusBadGateway)
+		return
+	}
+
+	cal := newEventCalendar(r.Context(), dm, event.Title)
+	cal.addEvent(*event, true)
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", calendarCacheControl)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, calendarFilename(event.Title)))
+	w.Write([]byte(cal.String()))
+}
+
+// EventsCalendarHandler serves an events search as a subscribable calendar feed. It takes the same
+// filters as EventsSearchHandler, without dates it covers a rolling window from today to 3 months out.
+func (dm *Dashboard) EventsCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	parkCode := r.URL.Query().Get("park")
+	stateCode := r.URL.Query().Get("state")
+	eventType := r.URL.Query().Get("event_type")
+	dateStart := r.URL.Query().Get("date_start")
+	dateEnd := r.URL.Query().Get("date_end")
+
+	now := time.Now()
+	if dateStart == "" {
+		dateStart = now.Format("2006-01-02")
+	}
+	if dateEnd == "" {
+		dateEnd = now.AddDate(0, 3, 0).Format("2006-01-02")
+	}
+	for _, date := range []string{dateStart, dateEnd} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			http.Error(w, "Dates must be formatted YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	cal := newEventCalendar(r.Context(), dm, calendarName(query, parkCode, stateCode, eventType))
+	cal.dateStart, cal.dateEnd = dateStart, dateEnd
+
+	// Page through the search, the NPS API returns one record per occurrence of recurring events
+	fetched := 0
+	for start := 0; fetched < maxCalendarEvents; start += calendarPageSize {
+		eventsData, err := dm.parkService.SearchEvents(r.Context(), query, parkCode, stateCode, eventType, dateStart, dateEnd, calendarPageSize, start)
+		if err != nil {
+			dm.logger.ErrorContext(r.Context(), "Error searching events for calendar", "error", err)
+			http.Error(w, "Error searching events", http.StatusInternalServerError)
+			return
+		}
+
+		for _, event := range eventsData.Data {
+			cal.addEvent(event, false)
+		}
+		fetched += len(eventsData.Data)
+
+		total, _ := strconv.Atoi(eventsData.Total)
+		if len(eventsData.Data) < calendarPageSize || fetched >= total {
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", calendarCacheControl)
+	w.Header().Set("Content-Disposition", `inline; filename="park-events.ics"`)
+	w.Write([]byte(cal.String()))
+}
+
+// eventCalendar builds a VCALENDAR from NPS events
+type eventCalendar struct {
+	ctx       context.Context // The request building the calendar, for park lookups
+	dm        *Dashboard
+	name      string
+	dateStart string // Occurrences outside dateStart..dateEnd are skipped, when set
+	dateEnd   string
+	stamp     time.Time
+	events    []calendarEvent
+	uids      map[string]bool
+	zones     map[string]*time.Location
+}
+
+func newEventCalendar(ctx context.Context, dm *Dashboard, name string) *eventCalendar {
+	return &eventCalendar{
+		ctx:   ctx,
+		dm:    dm,
+		name:  name,
+		stamp: time.Now().UTC(),
+		uids:  make(map[string]bool),
+		zones: make(map[string]*time.Location),
+	}
+}
+
+// calendarEvent is a rendered VEVENT and when it starts
+type calendarEvent struct {
+	start time.Time
+	text  string
+}
+
+// eventOccurrence is one date, and optionally one time slot, of an event
+type eventOccurrence struct {
+	date    time.Time
+	endDate time.Time // Last day of a multi-day all-day event
+	start   string
+	end     string
+}
+
+// addEvent adds a VEVENT for each occurrence of the event and returns how many were new.
+// allDates includes every date of a recurring event, rather than just the expanded instance.
+func (c *eventCalendar) addEvent(event nps.Event, allDates bool) int {
+	id := event.EventID
+	if id == "" {
+		id = event.ID
+	}
+	if id == "" {
+		return 0
+	}
+
+	loc := c.parkLocation(event.SiteCode)
+	stamp := c.stamp
+	for _, layout := range eventUpdatedFormats {
+		if t, err := time.Parse(layout, event.DateTimeUpdated); err == nil {
+			stamp = t.UTC()
+			break
+		}
+	}
+
+	added := 0
+	for _, occ := range eventOccurrences(event, allDates) {
+		day := occ.date.Format("2006-01-02")
+		if c.dateStart != "" && (day < c.dateStart || day > c.dateEnd) {
+			continue
+		}
+
+		start, end, timed := occurrenceTimes(occ, loc)
+		uid := id + "-" + occ.date.Format(icsDateFormat)
+		if timed {
+			uid += "-" + start.Format("1504")
+		}
+		uid += "@" + icsUIDDomain
+		if c.uids[uid] {
+			continue
+		}
+		c.uids[uid] = true
+
+		var b strings.Builder
+		writeICSLine(&b, "BEGIN", "VEVENT")
+		writeICSLine(&b, "UID", uid)
+		writeICSLine(&b, "DTSTAMP", stamp.Format(icsDateTimeFormat)+"Z")
+		if timed {
+			writeICSLine(&b, "DTSTART", formatICSTime(start, loc))
+			writeICSLine(&b, "DTEND", formatICSTime(end, loc))
+		} else {
+			writeICSLine(&b, "DTSTART;VALUE=DATE", start.Format(icsDateFormat))
+			writeICSLine(&b, "DTEND;VALUE=DATE", end.Format(icsDateFormat))
+		}
+		writeICSLine(&b, "SUMMARY", escapeICSText(event.Title))
+		if description := eventDescription(event); description != "" {
+			writeICSLine(&b, "DESCRIPTION", escapeICSText(description))
+		}
+		if location := eventLocation(event); location != "" {
+			writeICSLine(&b, "LOCATION", escapeICSText(location))
+		}
+		if lat, lng, ok := database.ParseCoordinates(event.Latitude, event.Longitude, ""); ok {
+			writeICSLine(&b, "GEO", fmt.Sprintf("%.6f;%.6f", lat, lng))
+		}
+		if len(event.Types) > 0 {
+			categories := make([]string, len(event.Types))
+			for i, t := range event.Types {
+				categories[i] = escapeICSText(t)
+			}
+			writeICSLine(&b, "CATEGORIES", strings.Join(categories, ","))
+		}
+		if strings.HasPrefix(event.InfoUrl, "http://") || strings.HasPrefix(event.InfoUrl, "https://") {
+			writeICSLine(&b, "URL", event.InfoUrl)
+		}
+		writeICSLine(&b, "END", "VEVENT")
+
+		c.events = append(c.events, calendarEvent{start: start, text: b.String()})
+		added++
+	}
+	return added
+}
+
+// parkLocation returns the time zone of a park from its first state, or nil when it is unknown
+func (c *eventCalendar) parkLocation(parkCode string) *time.Location {
+	if parkCode == "" {
+		return nil
+	}
+	parkCode = strings.ToLower(parkCode)
+	if loc, ok := c.zones[parkCode]; ok {
+		return loc
+	}
+
+	var loc *time.Location
+	if parkID, err := c.dm.db.GetParkIDByCode(c.ctx, parkCode); err == nil {
+		if park, err := c.dm.db.GetParkByID(c.ctx, parkID); err == nil {
+			state := strings.TrimSpace(strings.Split(park.States, ",")[0])
+			if name, ok := stateTimeZones[strings.ToUpper(state)]; ok {
+				if l, err := time.LoadLocation(name); err == nil {
+					loc = l
+				}
+			}
+		}
+	}
+	c.zones[parkCode] = loc
+	return loc
+}
+
+// String renders the calendar, with events in start order
+func (c *eventCalendar) String() string {
+	sort.SliceStable(c.events, func(i, j int) bool {
+		return c.events[i].start.Before(c.events[j].start)
+	})
+
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN", "VCALENDAR")
+	writeICSLine(&b, "VERSION", "2.0")
+	writeICSLine(&b, "PRODID", icsProdID)
+	writeICSLine(&b, "CALSCALE", "GREGORIAN")
+	writeICSLine(&b, "METHOD", "PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME", escapeICSText(c.name))
+	writeICSLine(&b, "REFRESH-INTERVAL;VALUE=DURATION", calendarRefreshInterval)
+	writeICSLine(&b, "X-PUBLISHED-TTL", calendarRefreshInterval)
+	for _, event := range c.events {
+		b.WriteString(event.text)
+	}
+	writeICSLine(&b, "END", "VCALENDAR")
+	return b.String()
+}
+
+// eventOccurrences lists the dates and time slots of an event
+func eventOccurrences(event nps.Event, allDates bool) []eventOccurrence {
+	var dates []string
+	switch {
+	case event.Date != "" && !allDates:
+		dates = []string{event.Date}
+	case len(event.Dates) > 0:
+		dates = event.Dates
+	case event.Date != "":
+		dates = []string{event.Date}
+	case event.DateStart != "":
+		dates = []string{event.DateStart}
+	}
+
+	allDay := event.IsAllDay == "true" || len(event.Times) == 0
+	var occurrences []eventOccurrence
+	for _, d := range dates {
+		date, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			continue
+		}
+
+		if allDay {
+			occ := eventOccurrence{date: date, endDate: date}
+			// A single undated range, like an exhibit, spans every day from start to end
+			if len(dates) == 1 && d == event.DateStart {
+				if end, err := time.Parse("2006-01-02", event.DateEnd); err == nil && end.After(date) {
+					occ.endDate = end
+				}
+			}
+			occurrences = append(occurrences, occ)
+			continue
+		}
+
+		for _, t := range event.Times {
+			occurrences = append(occurrences, eventOccurrence{date: date, endDate: date, start: t.TimeStart, end: t.TimeEnd})
+		}
+	}
+	return occurrences
+}
+
+// occurrenceTimes returns the start and end of an occurrence, and whether it is timed.
+// Occurrences without a parseable start time, like those starting at sunrise, are all day.
+func occurrenceTimes(occ eventOccurrence, loc *time.Location) (time.Time, time.Time, bool) {
+	tz := loc
+	if tz == nil {
+		tz = time.UTC
+	}
+
+	start, ok := parseEventTime(occ.date, occ.start, tz)
+	if !ok {
+		return occ.date, occ.endDate.AddDate(0, 0, 1), false
+	}
+	end, ok := parseEventTime(occ.date, occ.end, tz)
+	if !ok || !end.After(start) {
+		end = start.Add(time.Hour)
+	}
+	return start, end, true
+}
+
+func parseEventTime(date time.Time, value string, loc *time.Location) (time.Time, bool) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	for _, layout := range eventTimeFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, loc), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatICSTime formats a time in UTC, or as floating local time when the park's zone is unknown
+func formatICSTime(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		return t.Format(icsDateTimeFormat)
+	}
+	return t.UTC().Format(icsDateTimeFormat) + "Z"
+}
+
+func eventDescription(event nps.Event) string {
+	var parts []string
+	if description := database.StripHTML(event.Description); description != "" {
+		parts = append(parts, description)
+	}
+	if event.FeeInfo != "" {
+		parts = append(parts, "Fees: "+database.StripHTML(event.FeeInfo))
+	}
+	if event.IsRegresRequired == "true" {
+		registration := "Registration required."
+		if event.RegresUrl != "" {
+			registration += " " + event.RegresUrl
+		}
+		parts = append(parts, registration)
+	}
+	if event.InfoUrl != "" {
+		parts = append(parts, "More info: "+event.InfoUrl)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func eventLocation(event nps.Event) string {
+	var parts []string
+	for _, part := range []string{event.Location, event.ParkFullName} {
+		if part = database.StripHTML(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// calendarName describes the search a feed was built from
+func calendarName(query, parkCode, stateCode, eventType string) string {
+	var filters []string
+	for _, filter := range []string{query, strings.ToUpper(parkCode), strings.ToUpper(stateCode), eventType} {
+		if filter != "" {
+			filters = append(filters, filter)
+		}
+	}
+	if len(filters) == 0 {
+		return "National Park Events"
+	}
+	return "National Park Events: " + strings.Join(filters, ", ")
+}
+
+// calendarFilename turns an event title into a safe download filename
+func calendarFilename(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if name == "" {
+		return "event"
+	}
+	return name
+}
+
+// escapeICSText escapes a TEXT property value
+func escapeICSText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(s)
+}
+
+// writeICSLine writes a content line, folding it at 75 octets without splitting characters
+func writeICSLine(b *strings.Builder, name, value string) {
+	line := name + ":" + value
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward their length
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -1,33 +1,175 @@
 package dashboard
 
 import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
 	"github.com/ztkent/go-nps"
+	"github.com/ztkent/parks-explorer/internal/config"
 	"github.com/ztkent/parks-explorer/internal/database"
+	"github.com/ztkent/parks-explorer/internal/npsfake"
+	"github.com/ztkent/parks-explorer/web"
+	"golang.org/x/oauth2"
 )
 
+// defaultFixturesDir is where record mode writes fixtures, the ones embedded in npsfake
+const defaultFixturesDir = "internal/npsfake/fixtures"
+
 type Dashboard struct {
 	npsApi      nps.NpsApi
 	db          *database.DB
 	parkService *ParkService
+	scheduler   *SyncScheduler
+	logger      *slog.Logger
+	cfg         *config.Config
+	oauth       *oauth2.Config
+
+	// web holds the templates/ and static/ directories, templates are parsed from it once
+	web       fs.FS
+	templates *templateRegistry
+
+	// images caches resized copies of proxied images, nil resizes on every request
+	images *imageCache
+
+	// imageFetcher and avatarFetcher download images for the proxies, see safeFetcher
+	imageFetcher  *safeFetcher
+	avatarFetcher *safeFetcher
+
+	// npsKey caches the NPS API key check reported by StatusHandler
+	npsKey npsKeyCheck
 }
 
-func NewDashboard(apiKey string, dbPath string) *Dashboard {
-	// Initialize database
-	db, err := database.NewDatabase(dbPath)
+// NewDashboard creates a dashboard backed by the NPS API client cfg selects
+func NewDashboard(cfg *config.Config, logger *slog.Logger) *Dashboard {
+	// Initialize NPS API
+	npsApi, err := NewNpsApiFromConfig(cfg.NPS, logger)
 	if err != nil {
 		panic(err)
 	}
+	return NewDashboardWithAPI(npsApi, cfg, logger)
+}
 
-	// Initialize NPS API
-	npsApi := nps.NewNpsApi(apiKey)
+// NewDashboardWithAPI creates a dashboard backed by npsApi, e.g. an offline npsfake.Client
+func NewDashboardWithAPI(npsApi nps.NpsApi, cfg *config.Config, logger *slog.Logger) *Dashboard {
+	// Parse templates first, so an invalid one stops startup before anything else runs
+	templates, err := newTemplateRegistry(web.Files(), false)
+	if err != nil {
+		panic(err)
+	}
+
+	// Initialize database
+	db, err := database.NewDatabase(cfg.DBPath, logger)
+	if err != nil {
+		panic(err)
+	}
 
 	// Initialize park service
-	parkService := NewParkService(npsApi, db)
-	go parkService.GetAllParks()
+	parkService := NewParkService(npsApi, db, logger)
+	if cfg.NPS.Timeout > 0 {
+		parkService.SetNPSTimeouts(cfg.NPS.Timeout, cfg.NPS.EndpointTimeouts)
+	}
+	for dataType, ttl := range cfg.CacheTTLs {
+		if err := parkService.SetCacheTTL(dataType, ttl); err != nil {
+			panic(err)
+		}
+	}
+
+	// Keep the park list and per-park data fresh in the background
+	scheduler := NewSyncScheduler(parkService, db, cfg.Sync, logger)
+	scheduler.Start()
 
-	return &Dashboard{
+	// Resized images are cached next to the database unless IMAGE_CACHE_DIR says otherwise
+	imageDir := cfg.ImageCache.Dir
+	if imageDir == "" {
+		imageDir = imageCacheDir(cfg.DBPath)
+	}
+
+	dm := &Dashboard{
 		npsApi:      npsApi,
 		db:          db,
 		parkService: parkService,
+		scheduler:   scheduler,
+		logger:      logger,
+		cfg:         cfg,
+		oauth:       newGoogleOAuthConfig(cfg.Google),
+		web:         web.Files(),
+		templates:   templates,
+		images:      openImageCache(imageDir, cfg.ImageCache.MaxMB<<20, logger),
+
+		imageFetcher:  newSafeFetcher("image", imageProxyHosts, maxSourceImageBytes),
+		avatarFetcher: newSafeFetcher("avatar", avatarProxyHosts, maxAvatarImageBytes),
+	}
+
+	// Serve templates and static files from disk when WEB_DIR is set, reparsing templates on
+	// every request so edits show up without a rebuild
+	if cfg.WebDir != "" {
+		logger.Info("Serving templates and static files from disk", "dir", cfg.WebDir)
+		if err := dm.useWebFiles(os.DirFS(cfg.WebDir), true); err != nil {
+			panic(err)
+		}
+	}
+	return dm
+}
+
+// imageCacheDir is the default image cache directory, beside the database
+func imageCacheDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "image-cache")
+}
+
+// openImageCache opens the resized image cache, resizing without one if it can't be opened
+func openImageCache(dir string, maxBytes int64, logger *slog.Logger) *imageCache {
+	images, err := newImageCache(dir, maxBytes, logger)
+	if err != nil {
+		logger.Warn("Resized images will not be cached", "error", err)
+		return nil
+	}
+	return images
+}
+
+// useWebFiles serves templates and static files from fsys, failing if any template is invalid
+func (dm *Dashboard) useWebFiles(fsys fs.FS, reload bool) error {
+	templates, err := newTemplateRegistry(fsys, reload)
+	if err != nil {
+		return err
+	}
+	dm.web = fsys
+	dm.templates = templates
+	return nil
+}
+
+// Close stops background syncing and closes the database
+func (dm *Dashboard) Close() error {
+	dm.scheduler.Stop()
+	return dm.db.Close()
+}
+
+// NewNpsApiFromConfig picks the NPS API client from the NPS mode:
+//   - live (default): the real NPS API
+//   - fake: recorded fixtures, from the fixtures dir or the ones built into npsfake
+//   - record: the real NPS API, saving every response to the fixtures dir
+func NewNpsApiFromConfig(cfg config.NPS, logger *slog.Logger) (nps.NpsApi, error) {
+	fixturesDir := cfg.FixturesDir
+
+	switch mode := cfg.Mode; mode {
+	case "", "live":
+		return nps.NewNpsApi(cfg.APIKey), nil
+	case "fake":
+		if fixturesDir == "" {
+			logger.Info("Serving NPS data from built-in fixtures")
+			return npsfake.New(npsfake.Fixtures()), nil
+		}
+		logger.Info("Serving NPS data from fixtures", "dir", fixturesDir)
+		return npsfake.New(os.DirFS(fixturesDir)), nil
+	case "record":
+		if fixturesDir == "" {
+			fixturesDir = defaultFixturesDir
+		}
+		logger.Info("Recording NPS responses", "dir", fixturesDir)
+		return npsfake.NewRecorder(nps.NewNpsApi(cfg.APIKey), fixturesDir)
+	default:
+		return nil, fmt.Errorf("unknown NPS_MODE %q, expected live, fake or record", mode)
 	}
 }
@@ -0,0 +1,343 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/config"
+	"github.com/ztkent/parks-explorer/internal/logging"
+	"github.com/ztkent/parks-explorer/internal/npsfake"
+)
+
+func newTestDashboard(t *testing.T) (*Dashboard, *npsfake.Client) {
+	t.Helper()
+	fake := npsfake.New(npsfake.Fixtures())
+	return newTestDashboardWithAPI(t, fake), fake
+}
+
+func newTestDashboardWithAPI(t *testing.T, fake *npsfake.Client) *Dashboard {
+	t.Helper()
+	dm := NewDashboardWithAPI(fake, testConfig(t), slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { dm.Close() })
+	return dm
+}
+
+// copyFixtures is a copy of the recorded fixtures that tests can change
+func copyFixtures(t *testing.T) fstest.MapFS {
+	t.Helper()
+	fixtures := fstest.MapFS{}
+	err := fs.WalkDir(npsfake.Fixtures(), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(npsfake.Fixtures(), path)
+		fixtures[path] = &fstest.MapFile{Data: data}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixtures
+}
+
+// fixtureData decodes the data items of a fixture
+func fixtureData(t *testing.T, fixtures fstest.MapFS, name string) []map[string]interface{} {
+	t.Helper()
+	var recorded struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(fixtures[name].Data, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	return recorded.Data
+}
+
+// setFixtureData replaces the data items of a fixture
+func setFixtureData(t *testing.T, fixtures fstest.MapFS, name string, items []map[string]interface{}) {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"data": items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures[name] = &fstest.MapFile{Data: data}
+}
+
+// fixturesWithEvents is the recorded fixtures with events.json replaced by n one-off events
+// tomorrow, copied from the first recorded event
+func fixturesWithEvents(t *testing.T, n int) fs.FS {
+	t.Helper()
+	fixtures := copyFixtures(t)
+	recorded := fixtureData(t, fixtures, "events.json")
+	day := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	events := make([]map[string]interface{}, n)
+	for i := range events {
+		event := maps.Clone(recorded[0])
+		id := fmt.Sprintf("EVENT-%03d", i)
+		event["id"], event["eventid"], event["title"] = id, id, "Event "+id
+		event["date"], event["datestart"], event["dateend"], event["dates"] = day, day, day, []string{day}
+		event["isrecurring"], event["recurrencedatestart"], event["recurrencedateend"] = "false", "", ""
+		events[i] = event
+	}
+	setFixtureData(t, fixtures, "events.json", events)
+	return fixtures
+}
+
+// testConfig stores the database in a temp dir and doesn't sync in the background
+func testConfig(t *testing.T) *config.Config {
+	cfg := config.Default()
+	cfg.DBPath = filepath.Join(t.TempDir(), "test.db")
+	cfg.BaseURL = "http://example.com"
+	cfg.Sync = config.Sync{}
+	return cfg
+}
+
+func TestParkServiceWithFake(t *testing.T) {
+	dm, fake := newTestDashboard(t)
+	ps := dm.parkService
+
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+	park, err := ps.GetParkBySlug(t.Context(), "yosemite")
+	if err != nil {
+		t.Fatalf("failed to get park by slug: %v", err)
+	}
+	if park.ParkCode != "yose" {
+		t.Errorf("got park code %q, want yose", park.ParkCode)
+	}
+
+	campgrounds, err := ps.GetParkCampgrounds(t.Context(), "yose")
+	if err != nil {
+		t.Fatalf("failed to get campgrounds: %v", err)
+	}
+	if len(campgrounds.Data) != 1 {
+		t.Errorf("got %d campgrounds, want 1", len(campgrounds.Data))
+	}
+	// The second lookup is served from the database cache
+	before := fake.Calls("campgrounds")
+	if _, err := ps.GetParkCampgrounds(t.Context(), "yose"); err != nil {
+		t.Fatalf("failed to get cached campgrounds: %v", err)
+	}
+	if after := fake.Calls("campgrounds"); after != before {
+		t.Errorf("cached lookup called the NPS API: %d calls, want %d", after, before)
+	}
+
+	assets, err := ps.getParkMultimediaGalleriesAssets(t.Context(), "9B8A7C6D-5E4F-4A3B-2C1D-0E9F8A7B6C5D", "yose")
+	if err != nil {
+		t.Fatalf("failed to get gallery assets: %v", err)
+	}
+	if len(assets.Data) != 2 {
+		t.Errorf("got %d gallery assets, want 2", len(assets.Data))
+	}
+	// Gallery assets are cached by gallery ID too
+	before = fake.Calls("multimedia_galleries_assets")
+	if _, err := ps.getParkMultimediaGalleriesAssets(t.Context(), "9B8A7C6D-5E4F-4A3B-2C1D-0E9F8A7B6C5D", "yose"); err != nil {
+		t.Fatalf("failed to get cached gallery assets: %v", err)
+	}
+	if after := fake.Calls("multimedia_galleries_assets"); before != 1 || after != before {
+		t.Errorf("cached lookup called the NPS API: %d calls, want 1", after)
+	}
+}
+
+func TestParkServiceTrace(t *testing.T) {
+	dm, _ := newTestDashboard(t)
+	ps := dm.parkService
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+
+	traced := func() map[string]string {
+		ctx, trace := logging.WithTrace(t.Context())
+		ps.GetParkDetails(ctx, "yose")
+		// The tab's sections load concurrently, so compare them in sorted order
+		attrs := map[string]string{}
+		for _, attr := range trace.Attrs() {
+			attrs[attr.Key] = attr.Value.String()
+			if list, ok := attr.Value.Any().([]string); ok {
+				attrs[attr.Key] = fmt.Sprint(slices.Sorted(slices.Values(list)))
+			}
+		}
+		return attrs
+	}
+
+	first := traced()
+	if got, want := first["cache_miss"], "[park_details/campgrounds park_details/fees park_details/parking_lots park_details/visitor_centers]"; got != want {
+		t.Errorf("first load cache_miss = %s, want %s", got, want)
+	}
+	if got, want := first["nps_calls"], "[campgrounds feespasses parkinglots visitorcenters]"; got != want {
+		t.Errorf("first load nps_calls = %s, want %s", got, want)
+	}
+	if first["cache_writes"] != first["cache_miss"] {
+		t.Errorf("first load cache_writes = %s, want every miss written", first["cache_writes"])
+	}
+
+	second := traced()
+	if second["cache_hit"] != first["cache_miss"] || second["nps_calls"] != "" {
+		t.Errorf("second load = %v, want only cache hits", second)
+	}
+}
+
+func TestParkServiceCanceled(t *testing.T) {
+	dm, fake := newTestDashboard(t)
+	ps := dm.parkService
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := ps.GetParkCampgrounds(ctx, "yose"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	ps.GetParkDetails(ctx, "yose")
+	if _, err := ps.SearchEvents(ctx, "", "yose", "", "", "", "", 10, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	for _, endpoint := range []string{"visitorcenters", "campgrounds", "events"} {
+		if calls := fake.Calls(endpoint); calls != 0 {
+			t.Errorf("canceled request made %d %s calls, want 0", calls, endpoint)
+		}
+	}
+}
+
+func TestParkTabPartialFailure(t *testing.T) {
+	dm, fake := newTestDashboard(t)
+	ps := dm.parkService
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+	fake.Fail("events", errors.New("status code: 500"))
+
+	overview := ps.GetParkOverview(t.Context(), "yose")
+	if !slices.Equal(overview.Unavailable, UnavailableSections{"events"}) {
+		t.Errorf("got unavailable sections %v, want [events]", overview.Unavailable)
+	}
+	if overview.ParkEvents != nil || overview.ThingsToDo == nil || overview.VisitorCenters == nil {
+		t.Errorf("only the events section should be missing: %+v", overview)
+	}
+
+	media := ps.GetParkMedia(t.Context(), "yose")
+	if len(media.Unavailable) != 0 || media.Galleries == nil {
+		t.Fatalf("got unavailable media sections %v, want none", media.Unavailable)
+	}
+	for _, gallery := range media.Galleries.Data {
+		if len(gallery.Images) == 0 {
+			t.Errorf("gallery %s has no images, want its assets added", gallery.ID)
+		}
+	}
+}
+
+func TestParkMediaSkipsFailedGallery(t *testing.T) {
+	// Yosemite gets a second gallery, a copy of the first
+	fixtures := copyFixtures(t)
+	galleries := fixtureData(t, fixtures, "multimedia_galleries.json")
+	second := maps.Clone(galleries[0])
+	second["id"], second["title"] = "SECOND-GALLERY", "Second gallery"
+	setFixtureData(t, fixtures, "multimedia_galleries.json", append(galleries, second))
+	fake := npsfake.New(fixtures)
+	ps := newTestDashboardWithAPI(t, fake).parkService
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+
+	// The first gallery's assets are cached, so only the second gallery's fetch fails
+	first := galleries[0]["id"].(string)
+	if _, err := ps.getParkMultimediaGalleriesAssets(t.Context(), first, "yose"); err != nil {
+		t.Fatalf("failed to get gallery assets: %v", err)
+	}
+	fake.Fail("multimedia_galleries_assets", errors.New("status code: 500"))
+
+	media := ps.GetParkMedia(t.Context(), "yose")
+	if len(media.Unavailable) != 0 || media.Galleries == nil {
+		t.Fatalf("got unavailable media sections %v, want none", media.Unavailable)
+	}
+	if len(media.Galleries.Data) != 1 || media.Galleries.Data[0].ID != first || len(media.Galleries.Data[0].Images) == 0 {
+		t.Errorf("got galleries %+v, want only the first with its images", media.Galleries.Data)
+	}
+}
+
+func TestSearchEventsPaging(t *testing.T) {
+	dm, _ := newTestDashboard(t)
+	ps := dm.parkService
+
+	ids := func(offset, limit int) []string {
+		t.Helper()
+		events, err := ps.SearchEvents(t.Context(), "", "", "", "", "", "", limit, offset)
+		if err != nil {
+			t.Fatalf("failed to search events: %v", err)
+		}
+		var ids []string
+		for _, event := range events.Data {
+			ids = append(ids, event.ID)
+		}
+		return ids
+	}
+
+	all := ids(0, 10)
+	if len(all) < 3 {
+		t.Fatalf("got %d events, want at least 3 fixtures", len(all))
+	}
+	first, second := ids(0, 1), ids(1, 1)
+	if !slices.Equal(first, all[:1]) || !slices.Equal(second, all[1:2]) {
+		t.Errorf("got pages %v and %v, want %v and %v", first, second, all[:1], all[1:2])
+	}
+	// Offsets inside a page start exactly where asked
+	if got := ids(1, 2); !slices.Equal(got, all[1:3]) {
+		t.Errorf("got %v at offset 1, want %v", got, all[1:3])
+	}
+	if got := ids(len(all)-1, 2); !slices.Equal(got, all[len(all)-1:]) {
+		t.Errorf("got %v at the last offset, want %v", got, all[len(all)-1:])
+	}
+}
+
+func TestEventsCalendarPaging(t *testing.T) {
+	// More events than fit on one page of the NPS search
+	const events = calendarPageSize + 50
+	fake := npsfake.New(fixturesWithEvents(t, events))
+	dm := newTestDashboardWithAPI(t, fake)
+
+	rec := httptest.NewRecorder()
+	dm.EventsCalendarHandler(rec, httptest.NewRequest(http.MethodGet, "/api/events/calendar.ics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	if got := strings.Count(rec.Body.String(), "BEGIN:VEVENT"); got != events {
+		t.Errorf("got %d events in the calendar, want %d", got, events)
+	}
+	for _, id := range []string{"EVENT-000", fmt.Sprintf("EVENT-%03d", events-1)} {
+		if !strings.Contains(rec.Body.String(), "UID:"+id+"-") {
+			t.Errorf("calendar is missing %s", id)
+		}
+	}
+	if calls := fake.Calls("events"); calls != 2 {
+		t.Errorf("made %d events calls, want 2 pages", calls)
+	}
+}
+
+func TestFeedLinksIgnoreHost(t *testing.T) {
+	dm, _ := newTestDashboard(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/news.rss", nil)
+	req.Host = "evil.example"
+	rec := httptest.NewRecorder()
+	dm.NewsRSSHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "evil.example") || !strings.Contains(body, "<link>http://example.com/news</link>") {
+		t.Errorf("feed links should come from BASE_URL, not the Host header:\n%s", body)
+	}
+}
@@ -0,0 +1,145 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Favorites let signed-in users save parks to a "My Parks" list.
+//
+// Park cards and park pages are cached and shared between visitors, so they render a hidden
+// favorite toggle for every park. script.js loads the visitor's favorites from /api/favorites
+// and shows and fills in the toggles when they are signed in.
+
+// userFromContext returns the user AuthMiddleware added to the request context
+func userFromContext(r *http.Request) *User {
+	user, _ := r.Context().Value("user").(*User)
+	return user
+}
+
+// FavoritesHandler returns the signed-in user's favorite park codes as JSON
+func (dm *Dashboard) FavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r)
+	codes, err := dm.db.GetFavoriteParkCodes(r.Context(), user.ID)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to get favorites", "user", user.ID, "error", err)
+		http.Error(w, "Failed to get favorites", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"park_codes": codes,
+	})
+}
+
+// AddFavoriteHandler saves a park to the signed-in user's favorites
+func (dm *Dashboard) AddFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r)
+	parkCode := chi.URLParam(r, "parkCode")
+
+	if err := dm.db.AddFavorite(r.Context(), user.ID, parkCode); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Park not found", http.StatusNotFound)
+			return
+		}
+		dm.logger.ErrorContext(r.Context(), "Failed to add favorite", "park", parkCode, "user", user.ID, "error", err)
+		http.Error(w, "Failed to add favorite", http.StatusInternalServerError)
+		return
+	}
+	writeFavoriteStatus(w, parkCode, true)
+}
+
+// RemoveFavoriteHandler removes a park from the signed-in user's favorites
+func (dm *Dashboard) RemoveFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r)
+	parkCode := chi.URLParam(r, "parkCode")
+
+	if err := dm.db.RemoveFavorite(r.Context(), user.ID, parkCode); err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to remove favorite", "park", parkCode, "user", user.ID, "error", err)
+		http.Error(w, "Failed to remove favorite", http.StatusInternalServerError)
+		return
+	}
+	writeFavoriteStatus(w, parkCode, false)
+}
+
+func writeFavoriteStatus(w http.ResponseWriter, parkCode string, favorite bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"park_code": parkCode,
+		"favorite":  favorite,
+	})
+}
+
+// FavoriteParksHandler returns HTML park cards for the signed-in user's favorites,
+// or a prompt to sign in
+func (dm *Dashboard) FavoriteParksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-store")
+
+	user, err := dm.GetCurrentUser(r)
+	if err != nil {
+		w.Write([]byte(`<div class="no-results">
+			<div class="no-results-icon">🔒</div>
+			<h3 class="no-results-title">Sign in to see your parks</h3>
+			<p class="no-results-message"><a href="/api/auth/google" hx-boost="false">Sign in with Google</a> to save parks to My Parks.</p>
+		</div>`))
+		return
+	}
+
+	parks, err := dm.db.GetFavoriteParks(r.Context(), user.ID)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to get favorite parks", "user", user.ID, "error", err)
+		w.Write([]byte(`<div class="loading">Error loading your parks</div>`))
+		return
+	}
+
+	if len(parks) == 0 {
+		w.Write([]byte(`<div class="no-results">
+			<div class="no-results-icon">🏔️</div>
+			<h3 class="no-results-title">No saved parks yet</h3>
+			<p class="no-results-message">Tap the ♡ on any park to save it here.</p>
+		</div>`))
+		return
+	}
+
+	var html strings.Builder
+	for _, park := range parks {
+		html.WriteString(parkCardHTML(park, formatStatesDisplay(park.States)))
+	}
+	w.Write([]byte(html.String()))
+}
+
+// MyParksPageHandler returns the My Parks page, its cards are loaded per user by FavoriteParksHandler
+func (dm *Dashboard) MyParksPageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tmpl, err := dm.templates.Lookup("my-parks.html")
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to load My Parks template", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to load My Parks template: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, nil)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to render My Parks page", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to render My Parks page: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// favoriteButtonHTML renders the hidden favorite toggle for a park card
+func favoriteButtonHTML(parkCode, parkName string) string {
+	return fmt.Sprintf(`<button type="button" class="favorite-toggle" data-park-code="%s" aria-pressed="false" aria-label="Save %s to My Parks" title="Save to My Parks" hidden>♡</button>`,
+		html.EscapeString(parkCode), html.EscapeString(parkName))
+}
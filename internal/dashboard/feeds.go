@@ -0,0 +1,405 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RSS 2.0 and Atom feeds for NPS news releases, articles and alerts.
+//
+// Feeds are built from the same UnifiedNewsItem data as NewsSearchHandler and take the same
+// park, state, news_type and q filters. Entry IDs are tag URIs built from the news type and NPS
+// ID so they stay stable if NPS changes an item's URL, and images are enclosed through the
+// image proxy. Links are built from the configured BASE_URL rather than the request's Host, since
+// feeds are cached and shared between every subscriber.
+//
+// Feeds don't go through the replay response cache, which drops the Content-Type on a cache miss,
+// and are cached by readers and proxies through Cache-Control instead.
+
+const (
+	feedTagPrefix    = "tag:parksexplorer.us,2025:"
+	defaultFeedLimit = 25
+	maxFeedLimit     = 100
+
+	// Feed readers poll, so let them and any proxy in between reuse a feed for a while
+	feedCacheControl = "public, max-age=1800"
+)
+
+// newsDateFormats are the layouts NPS uses for news release dates and alert index dates
+var newsDateFormats = []string{
+	"2006-01-02 15:04:05.0",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// feedImageTypes maps image extensions to enclosure MIME types
+var feedImageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Summary    *atomText      `xml:"summary"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// newsFeed is a news search ready to be rendered as RSS or Atom
+type newsFeed struct {
+	title    string
+	link     string // Page the feed describes
+	self     string // URL of the feed itself
+	newsType string
+	items    []UnifiedNewsItem
+	baseURL  string
+}
+
+// NewsRSSHandler serves a news search as an RSS 2.0 feed
+func (dm *Dashboard) NewsRSSHandler(w http.ResponseWriter, r *http.Request) {
+	feed, ok := dm.loadNewsFeed(w, r, r.URL.Query().Get("park"))
+	if !ok {
+		return
+	}
+	dm.writeFeedXML(w, r, "application/rss+xml; charset=utf-8", feed.rss())
+}
+
+// NewsAtomHandler serves a news search as an Atom feed
+func (dm *Dashboard) NewsAtomHandler(w http.ResponseWriter, r *http.Request) {
+	feed, ok := dm.loadNewsFeed(w, r, r.URL.Query().Get("park"))
+	if !ok {
+		return
+	}
+	dm.writeFeedXML(w, r, "application/atom+xml; charset=utf-8", feed.atom())
+}
+
+// ParkNewsAtomHandler serves the news for a single park as an Atom feed
+func (dm *Dashboard) ParkNewsAtomHandler(w http.ResponseWriter, r *http.Request) {
+	park, err := dm.parkService.GetParkBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	feed, ok := dm.loadNewsFeed(w, r, park.ParkCode)
+	if !ok {
+		return
+	}
+	feed.link = feed.baseURL + "/parks/" + park.Slug
+	dm.writeFeedXML(w, r, "application/atom+xml; charset=utf-8", feed.atom())
+}
+
+// loadNewsFeed searches news with the request's filters, writing an error response when it fails
+func (dm *Dashboard) loadNewsFeed(w http.ResponseWriter, r *http.Request, parkCode string) (*newsFeed, bool) {
+	query := r.URL.Query().Get("q")
+	stateCode := r.URL.Query().Get("state")
+	newsType := r.URL.Query().Get("news_type")
+	if newsType != "articles" && newsType != "alerts" {
+		newsType = "releases"
+	}
+
+	limit := defaultFeedLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, maxFeedLimit)
+	}
+
+	newsData, err := dm.parkService.SearchNews(r.Context(), query, parkCode, stateCode, newsType, limit, 0)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error searching news for feed", "error", err)
+		http.Error(w, "Error searching news", http.StatusInternalServerError)
+		return nil, false
+	}
+	unifiedData := normalizeNewsData(newsType, newsData)
+	if unifiedData == nil {
+		dm.logger.WarnContext(r.Context(), "Failed to normalize news data for feed", "type", newsType)
+		http.Error(w, "Error searching news", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	baseURL := dm.cfg.BaseURL
+	return &newsFeed{
+		title:    dm.newsFeedTitle(r.Context(), newsType, parkCode, stateCode),
+		link:     baseURL + "/news",
+		self:     baseURL + r.URL.RequestURI(),
+		newsType: newsType,
+		items:    unifiedData.Data,
+		baseURL:  baseURL,
+	}, true
+}
+
+// newsFeedTitle names a feed after its news type and the park or state it covers
+func (dm *Dashboard) newsFeedTitle(ctx context.Context, newsType, parkCode, stateCode string) string {
+	kind := "News Releases"
+	switch newsType {
+	case "articles":
+		kind = "Articles"
+	case "alerts":
+		kind = "Alerts"
+	}
+
+	if parkCode != "" {
+		if parkID, err := dm.db.GetParkIDByCode(ctx, parkCode); err == nil {
+			if park, err := dm.db.GetParkByID(ctx, parkID); err == nil {
+				return park.Name + " " + kind
+			}
+		}
+		return strings.ToUpper(parkCode) + " " + kind
+	}
+	if stateCode != "" {
+		return "National Park " + kind + " in " + strings.ToUpper(stateCode)
+	}
+	return "National Park " + kind
+}
+
+func (f *newsFeed) rss() rssFeed {
+	channel := rssChannel{
+		Title:         f.title,
+		Link:          f.link,
+		Description:   f.title + " from the National Park Service",
+		Language:      "en-us",
+		LastBuildDate: f.updated().Format(time.RFC1123Z),
+		SelfLink:      atomLink{Href: f.self, Rel: "self", Type: "application/rss+xml"},
+	}
+
+	for _, item := range f.items {
+		rssItem := rssItem{
+			Title:       item.Title,
+			Link:        f.itemLink(item),
+			Description: item.Description,
+			GUID:        rssGUID{Value: f.itemID(item)},
+			Categories:  itemCategories(item),
+		}
+		if date, ok := newsItemDate(item); ok {
+			rssItem.PubDate = date.Format(time.RFC1123Z)
+		}
+		if href, mimeType, ok := f.itemImage(item); ok {
+			rssItem.Enclosure = &rssEnclosure{URL: href, Type: mimeType}
+		}
+		channel.Items = append(channel.Items, rssItem)
+	}
+
+	return rssFeed{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom", Channel: channel}
+}
+
+func (f *newsFeed) atom() atomFeed {
+	updated := f.updated()
+	feed := atomFeed{
+		Title:   f.title,
+		ID:      f.self,
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.link, Rel: "alternate", Type: "text/html"},
+		},
+		Author: atomAuthor{Name: "National Park Service"},
+	}
+
+	for _, item := range f.items {
+		entry := atomEntry{
+			Title:   item.Title,
+			ID:      f.itemID(item),
+			Updated: updated.Format(time.RFC3339),
+			Links:   []atomLink{{Href: f.itemLink(item), Rel: "alternate", Type: "text/html"}},
+		}
+		// Items without a date, like articles, take the feed's updated time as Atom requires one
+		if date, ok := newsItemDate(item); ok {
+			entry.Updated = date.Format(time.RFC3339)
+			entry.Published = entry.Updated
+		}
+		if item.Description != "" {
+			entry.Summary = &atomText{Type: "html", Value: item.Description}
+		}
+		for _, category := range itemCategories(item) {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if href, mimeType, ok := f.itemImage(item); ok {
+			entry.Links = append(entry.Links, atomLink{Href: href, Rel: "enclosure", Type: mimeType})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+// updated returns the newest item date, or now when no item has one
+func (f *newsFeed) updated() time.Time {
+	var latest time.Time
+	for _, item := range f.items {
+		if date, ok := newsItemDate(item); ok && date.After(latest) {
+			latest = date
+		}
+	}
+	if latest.IsZero() {
+		return time.Now().UTC()
+	}
+	return latest
+}
+
+// itemID returns a stable tag URI for an item
+func (f *newsFeed) itemID(item UnifiedNewsItem) string {
+	if item.ID == "" {
+		return feedTagPrefix + f.newsType + "/" + createSlug(item.Title)
+	}
+	return feedTagPrefix + f.newsType + "/" + item.ID
+}
+
+// itemLink returns the item's NPS page, falling back to the feed's page
+func (f *newsFeed) itemLink(item UnifiedNewsItem) string {
+	if strings.HasPrefix(item.URL, "http://") || strings.HasPrefix(item.URL, "https://") {
+		return item.URL
+	}
+	if strings.HasPrefix(item.URL, "/") {
+		return "https://www.nps.gov" + item.URL
+	}
+	return f.link
+}
+
+// itemImage returns the absolute proxied URL and MIME type of an item's image
+func (f *newsFeed) itemImage(item UnifiedNewsItem) (string, string, bool) {
+	if item.Image == nil || item.Image.URL == "" {
+		return "", "", false
+	}
+	proxied := proxyImageURL(item.Image.URL)
+	if strings.HasPrefix(proxied, "/") {
+		proxied = f.baseURL + proxied
+	}
+
+	mimeType, ok := feedImageTypes[strings.ToLower(path.Ext(strings.SplitN(item.Image.URL, "?", 2)[0]))]
+	if !ok {
+		mimeType = "image/jpeg"
+	}
+	return proxied, mimeType, true
+}
+
+func itemCategories(item UnifiedNewsItem) []string {
+	var categories []string
+	if item.Category != "" {
+		categories = append(categories, item.Category)
+	}
+	for _, park := range item.RelatedParks {
+		if park.FullName != "" {
+			categories = append(categories, park.FullName)
+		}
+	}
+	return categories
+}
+
+// newsItemDate returns when an item was released, or for alerts last updated
+func newsItemDate(item UnifiedNewsItem) (time.Time, bool) {
+	value := item.ReleaseDate
+	if value == "" {
+		value = item.UpdatedDate
+	}
+	return parseNewsDate(value)
+}
+
+// parseNewsDate parses the date formats NPS uses for news, in UTC
+func parseNewsDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range newsDateFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (dm *Dashboard) writeFeedXML(w http.ResponseWriter, r *http.Request, contentType string, feed interface{}) {
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to render feed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to render feed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", feedCacheControl)
+	w.Write([]byte(xml.Header))
+	w.Write(output)
+}
@@ -0,0 +1,129 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/database"
+)
+
+// npsKeyCheckInterval is how long the result of checking the NPS API key is reused, so the
+// status page doesn't spend the hourly request budget
+const npsKeyCheckInterval = 15 * time.Minute
+
+// AdminStatus is the response of StatusHandler
+type AdminStatus struct {
+	Ready    bool                         `json:"ready"`
+	NotReady string                       `json:"not_ready,omitempty"`
+	Parks    int                          `json:"parks"`
+	Caches   []database.CacheFreshness    `json:"caches"`
+	LastSync map[string]*database.SyncRun `json:"last_sync"`
+	NPSKey   NPSKeyStatus                 `json:"nps_key"`
+}
+
+// NPSKeyStatus is the result of the last NPS API key check
+type NPSKeyStatus struct {
+	Status    string    `json:"status"` // 'valid', 'invalid' or 'unknown' when the API couldn't be reached
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// npsKeyCheck caches the last NPSKeyStatus
+type npsKeyCheck struct {
+	mu     sync.Mutex
+	status NPSKeyStatus
+}
+
+// HealthzHandler reports that the process is up
+func (dm *Dashboard) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// ReadyzHandler reports whether the database is reachable, migrated and has the park list,
+// answering 503 until it does
+func (dm *Dashboard) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := dm.db.Ready(ctx); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "not ready",
+			"error":  err.Error(),
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ready",
+	})
+}
+
+// StatusHandler returns park counts, cache freshness, the last sync runs and the NPS API key status as JSON for admins
+func (dm *Dashboard) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := AdminStatus{
+		Ready:    true,
+		Caches:   []database.CacheFreshness{},
+		LastSync: map[string]*database.SyncRun{},
+	}
+	if err := dm.db.Ready(r.Context()); err != nil {
+		status.Ready = false
+		status.NotReady = err.Error()
+	}
+
+	parks, err := dm.db.CountParks(r.Context())
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to count parks", "error", err)
+		http.Error(w, "Failed to count parks", http.StatusInternalServerError)
+		return
+	}
+	status.Parks = parks
+
+	for _, cache := range dm.parkService.caches {
+		freshness, err := dm.db.GetCacheFreshness(r.Context(), cache.Table(), cache.DataType(), cache.TTL())
+		if err != nil {
+			dm.logger.ErrorContext(r.Context(), "Failed to get cache freshness", "error", err)
+			http.Error(w, "Failed to get cache freshness", http.StatusInternalServerError)
+			return
+		}
+		status.Caches = append(status.Caches, *freshness)
+	}
+
+	for _, kind := range []string{SyncKindParks, SyncKindParkData} {
+		// No run yet is reported as null
+		run, _ := dm.db.GetLastSyncRun(r.Context(), kind)
+		status.LastSync[kind] = run
+	}
+
+	status.NPSKey = dm.checkNPSKey()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// checkNPSKey makes a one-park request to the NPS API to see whether the key is accepted,
+// reusing the result for npsKeyCheckInterval
+func (dm *Dashboard) checkNPSKey() NPSKeyStatus {
+	dm.npsKey.mu.Lock()
+	defer dm.npsKey.mu.Unlock()
+	if !dm.npsKey.status.CheckedAt.IsZero() && time.Since(dm.npsKey.status.CheckedAt) < npsKeyCheckInterval {
+		return dm.npsKey.status
+	}
+
+	status := NPSKeyStatus{Status: "valid", CheckedAt: time.Now()}
+	if _, err := dm.parkService.npsApi.GetParks(nil, nil, 0, 1, "", nil); err != nil {
+		status.Status = "unknown"
+		status.Error = err.Error()
+		// api.data.gov rejects missing and invalid keys with 401 or 403
+		if strings.Contains(status.Error, "status code: 401") || strings.Contains(status.Error, "status code: 403") {
+			status.Status = "invalid"
+		}
+	}
+	dm.npsKey.status = status
+	return status
+}
@@ -0,0 +1,90 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ztkent/parks-explorer/internal/npsfake"
+)
+
+func TestReadyz(t *testing.T) {
+	// Fail the sync on boot, so the park list stays empty until it's synced below
+	fake := npsfake.New(npsfake.Fixtures())
+	fake.Fail("parks", errors.New("nps api unavailable"))
+	dm := NewDashboardWithAPI(fake, testConfig(t), slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { dm.Close() })
+
+	// Until the park list is synced the app can't serve pages
+	rec := httptest.NewRecorder()
+	dm.ReadyzHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d before syncing parks, want 503", rec.Code)
+	}
+
+	fake.Fail("parks", nil)
+
+	if _, err := dm.parkService.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	dm.ReadyzHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("got %d after syncing parks, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	dm, fake := newTestDashboard(t)
+	if _, err := dm.parkService.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+	if _, err := dm.parkService.GetParkCampgrounds(t.Context(), "yose"); err != nil {
+		t.Fatalf("failed to get campgrounds: %v", err)
+	}
+
+	getStatus := func() AdminStatus {
+		rec := httptest.NewRecorder()
+		dm.StatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/admin/status", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got %d: %s", rec.Code, rec.Body)
+		}
+		var status AdminStatus
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	status := getStatus()
+	if !status.Ready || status.Parks == 0 {
+		t.Errorf("got ready %v with %d parks, want ready with the synced parks", status.Ready, status.Parks)
+	}
+	if len(status.Caches) != len(dm.parkService.caches) {
+		t.Errorf("got %d caches, want %d", len(status.Caches), len(dm.parkService.caches))
+	}
+	for _, cache := range status.Caches {
+		if cache.DataType != "campgrounds" {
+			continue
+		}
+		if cache.Rows != 1 || cache.Stale != 0 || cache.NewestFetched == nil {
+			t.Errorf("campgrounds freshness = %+v, want one fresh row", cache)
+		}
+	}
+	if status.NPSKey.Status != "valid" {
+		t.Errorf("got NPS key status %q, want valid", status.NPSKey.Status)
+	}
+
+	// The key check is reused rather than spending NPS requests on every page view
+	fake.Fail("parks", errors.New("unexpected status code: 403. Response body: API_KEY_INVALID"))
+	if got := getStatus().NPSKey.Status; got != "valid" {
+		t.Errorf("got NPS key status %q, want the cached valid result", got)
+	}
+	dm.npsKey.status.CheckedAt = dm.npsKey.status.CheckedAt.Add(-npsKeyCheckInterval)
+	if got := getStatus().NPSKey.Status; got != "invalid" {
+		t.Errorf("got NPS key status %q after the key was rejected, want invalid", got)
+	}
+}
@@ -0,0 +1,200 @@
+package dashboard
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// imageCacheTypes maps cached file extensions to their content types
+var imageCacheTypes = map[string]string{
+	".jpg": "image/jpeg",
+	".png": "image/png",
+}
+
+// imageCache keeps resized images in a directory, evicting the least recently used once the
+// files add up to more than maxBytes. File modification times record use, so the order
+// survives restarts.
+type imageCache struct {
+	dir      string
+	maxBytes int64
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	lru     *list.List // of *imageCacheEntry, most recently used first
+	entries map[string]*list.Element
+	size    int64
+}
+
+type imageCacheEntry struct {
+	key  string
+	ext  string
+	size int64
+}
+
+// newImageCache opens the cache in dir, indexing any images already there
+func newImageCache(dir string, maxBytes int64, logger *slog.Logger) (*imageCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image cache directory: %w", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image cache directory: %w", err)
+	}
+
+	type cachedFile struct {
+		entry   *imageCacheEntry
+		modTime time.Time
+	}
+	var existing []cachedFile
+	for _, file := range files {
+		// Left behind by a Put that was interrupted
+		if strings.HasPrefix(file.Name(), "tmp-") {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		ext := filepath.Ext(file.Name())
+		if _, ok := imageCacheTypes[ext]; !ok || file.IsDir() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		existing = append(existing, cachedFile{
+			entry:   &imageCacheEntry{key: strings.TrimSuffix(file.Name(), ext), ext: ext, size: info.Size()},
+			modTime: info.ModTime(),
+		})
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].modTime.After(existing[j].modTime) })
+
+	c := &imageCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		logger:   logger,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+	for _, file := range existing {
+		c.entries[file.entry.key] = c.lru.PushBack(file.entry)
+		c.size += file.entry.size
+	}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// imageCacheKey names the cached copy of an image at a size
+func imageCacheKey(imageURL string, size imageSize) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%dx%d|%s", imageURL, size.Width, size.Height, size.Fit)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns a cached image and its content type
+func (c *imageCache) Get(key string) ([]byte, string, bool) {
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil, "", false
+	}
+	c.lru.MoveToFront(elem)
+	entry := elem.Value.(*imageCacheEntry)
+	c.mu.Unlock()
+
+	path := c.path(entry)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Evicted while we were reading, or removed from disk
+		c.remove(key)
+		return nil, "", false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, imageCacheTypes[entry.ext], true
+}
+
+// Put stores an image, evicting the least recently used ones to stay within maxBytes
+func (c *imageCache) Put(key, contentType string, data []byte) error {
+	ext := ""
+	for e, t := range imageCacheTypes {
+		if t == contentType {
+			ext = e
+		}
+	}
+	if ext == "" {
+		return fmt.Errorf("unsupported image cache content type %s", contentType)
+	}
+	entry := &imageCacheEntry{key: key, ext: ext, size: int64(len(data))}
+
+	// Write to a temp file first, so readers never see a partial image
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cached image: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cached image: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cached image: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Rename(tmp.Name(), c.path(entry)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store cached image: %w", err)
+	}
+	if elem, ok := c.entries[key]; ok {
+		old := elem.Value.(*imageCacheEntry)
+		if old.ext != entry.ext {
+			os.Remove(c.path(old))
+		}
+		c.size -= old.size
+		c.lru.Remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.size
+	c.evict()
+	return nil
+}
+
+func (c *imageCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.size -= elem.Value.(*imageCacheEntry).size
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// evict removes the least recently used images until the cache fits. Callers hold c.mu.
+func (c *imageCache) evict() {
+	for c.size > c.maxBytes && c.lru.Len() > 0 {
+		elem := c.lru.Back()
+		entry := elem.Value.(*imageCacheEntry)
+		if err := os.Remove(c.path(entry)); err != nil && !os.IsNotExist(err) {
+			c.logger.Warn("Failed to evict cached image", "key", entry.key, "error", err)
+		}
+		c.lru.Remove(elem)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+	}
+}
+
+func (c *imageCache) path(entry *imageCacheEntry) string {
+	return filepath.Join(c.dir, entry.key+entry.ext)
+}
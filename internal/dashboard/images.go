@@ -0,0 +1,168 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Image proxy resizing limits
+const (
+	maxImageDimension    = 2048
+	maxSourceImageBytes  = 25 << 20
+	maxSourceImagePixels = 40_000_000 // decoding allocates about 4 bytes a pixel, whatever the file size
+	resizedJPEGQuality   = 82
+)
+
+// Ways a resized image can fit the requested box
+const (
+	fitContain = "contain" // scale to fit inside the box, keeping the whole image
+	fitCover   = "cover"   // scale to fill the box, cropping the overflow from the center
+)
+
+// imageVariantWidths are the widths proxyImageSrcset offers browsers to pick from
+var imageVariantWidths = []int{320, 640, 960, 1280}
+
+// imageSize is the box an image proxy request asks for, zero sides are left free
+type imageSize struct {
+	Width  int
+	Height int
+	Fit    string
+}
+
+func (s imageSize) isZero() bool {
+	return s.Width == 0 && s.Height == 0
+}
+
+// parseImageSize reads the w, h and fit query parameters
+func parseImageSize(query url.Values) (imageSize, error) {
+	size := imageSize{Fit: fitContain}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"w", &size.Width}, {"h", &size.Height}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxImageDimension {
+			return imageSize{}, fmt.Errorf("%s must be between 1 and %d", param.name, maxImageDimension)
+		}
+		*param.value = n
+	}
+	switch fit := query.Get("fit"); fit {
+	case "", fitContain:
+	case fitCover:
+		size.Fit = fitCover
+	default:
+		return imageSize{}, fmt.Errorf("fit must be %s or %s", fitContain, fitCover)
+	}
+	return size, nil
+}
+
+// resizeImage scales src into size, never enlarging it. Cover crops from the center when
+// both sides are given.
+func resizeImage(src image.Image, size imageSize) image.Image {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw == 0 || sh == 0 || size.isZero() {
+		return src
+	}
+
+	crop := bounds
+	var scale float64
+	switch {
+	case size.Width == 0:
+		scale = float64(size.Height) / float64(sh)
+	case size.Height == 0:
+		scale = float64(size.Width) / float64(sw)
+	case size.Fit == fitCover:
+		// Crop the largest centered region with the box's aspect ratio, then scale it to the box
+		cw, ch := sw, sh
+		if sw*size.Height > sh*size.Width {
+			cw = max(1, int(math.Round(float64(sh*size.Width)/float64(size.Height))))
+		} else {
+			ch = max(1, int(math.Round(float64(sw*size.Height)/float64(size.Width))))
+		}
+		x0 := bounds.Min.X + (sw-cw)/2
+		y0 := bounds.Min.Y + (sh-ch)/2
+		crop = image.Rect(x0, y0, x0+cw, y0+ch)
+		scale = float64(size.Width) / float64(cw)
+	default:
+		scale = math.Min(float64(size.Width)/float64(sw), float64(size.Height)/float64(sh))
+	}
+	scale = math.Min(scale, 1)
+
+	dw := max(1, int(math.Round(float64(crop.Dx())*scale)))
+	dh := max(1, int(math.Round(float64(crop.Dy())*scale)))
+	if crop == bounds && dw == sw && dh == sh {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
+
+// encodeImage re-encodes a resized image with the standard library encoders: PNG when it
+// has transparency, JPEG otherwise
+func encodeImage(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode png: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: resizedJPEGQuality}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// resizableImageFormat reports whether the proxy can decode and resize an image format.
+// Vector and animated images are served as they are.
+func resizableImageFormat(format string) bool {
+	return format == "jpeg" || format == "png" || format == "webp"
+}
+
+// proxyImageURLSized returns the proxied URL of an image resized to fit width x height,
+// either of which can be 0 to leave that side free
+func proxyImageURLSized(imageURL string, width, height int, fit string) string {
+	proxied := proxyImageURL(imageURL)
+	if !strings.HasPrefix(proxied, "/api/image-proxy?") {
+		return proxied
+	}
+	if width > 0 {
+		proxied += "&w=" + strconv.Itoa(width)
+	}
+	if height > 0 {
+		proxied += "&h=" + strconv.Itoa(height)
+	}
+	if fit != "" && fit != fitContain {
+		proxied += "&fit=" + fit
+	}
+	return proxied
+}
+
+// proxyImageSrcset returns a srcset offering the image at each of imageVariantWidths
+func proxyImageSrcset(imageURL string) string {
+	if !strings.HasPrefix(proxyImageURL(imageURL), "/api/image-proxy?") {
+		return ""
+	}
+	variants := make([]string, len(imageVariantWidths))
+	for i, width := range imageVariantWidths {
+		variants[i] = fmt.Sprintf("%s %dw", proxyImageURLSized(imageURL, width, 0, ""), width)
+	}
+	return strings.Join(variants, ", ")
+}
@@ -0,0 +1,184 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseImageSize(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    imageSize
+		wantErr bool
+	}{
+		{"", imageSize{Fit: fitContain}, false},
+		{"w=640", imageSize{Width: 640, Fit: fitContain}, false},
+		{"w=400&h=300&fit=cover", imageSize{Width: 400, Height: 300, Fit: fitCover}, false},
+		{"w=0", imageSize{}, true},
+		{"h=99999", imageSize{}, true},
+		{"w=abc", imageSize{}, true},
+		{"w=100&fit=stretch", imageSize{}, true},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		got, err := parseImageSize(query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseImageSize(%q): got err %v, want error %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseImageSize(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	tests := []struct {
+		name          string
+		size          imageSize
+		width, height int
+	}{
+		{"width only", imageSize{Width: 400, Fit: fitContain}, 400, 200},
+		{"height only", imageSize{Height: 100, Fit: fitContain}, 200, 100},
+		{"contain", imageSize{Width: 300, Height: 300, Fit: fitContain}, 300, 150},
+		{"cover crops to the box", imageSize{Width: 300, Height: 300, Fit: fitCover}, 300, 300},
+		{"never enlarges", imageSize{Width: 4000, Fit: fitContain}, 1000, 500},
+		{"cover never enlarges", imageSize{Width: 2000, Height: 2000, Fit: fitCover}, 500, 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds := resizeImage(src, tt.size).Bounds()
+			if bounds.Dx() != tt.width || bounds.Dy() != tt.height {
+				t.Errorf("got %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	opaque := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range opaque.Pix {
+		opaque.Pix[i] = 0xff
+	}
+	if _, contentType, err := encodeImage(opaque); err != nil || contentType != "image/jpeg" {
+		t.Errorf("opaque image: got %s, err %v, want image/jpeg", contentType, err)
+	}
+
+	transparent := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	transparent.Set(1, 1, color.RGBA{R: 0xff, A: 0x80})
+	data, contentType, err := encodeImage(transparent)
+	if err != nil || contentType != "image/png" {
+		t.Fatalf("transparent image: got %s, err %v, want image/png", contentType, err)
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || format != "png" {
+		t.Errorf("transparent image decoded as %s, err %v", format, err)
+	}
+}
+
+// pngHeader is a PNG that's only a signature and an IHDR chunk claiming width x height
+func pngHeader(width, height uint32) []byte {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, width)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, height)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0) // 8 bit RGBA, no interlacing
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(ihdr)-4))
+	data = append(data, ihdr...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestServeResizedImageRefusesDecompressionBombs(t *testing.T) {
+	dm := &Dashboard{logger: slog.New(slog.DiscardHandler)}
+	size := imageSize{Width: 320, Fit: fitContain}
+
+	// 50000 x 50000 would need 10GB to decode, from a 33 byte file
+	w := httptest.NewRecorder()
+	dm.serveResizedImage(w, httptest.NewRequest(http.MethodGet, "/api/image-proxy", nil), pngHeader(50000, 50000), "https://www.nps.gov/bomb.png", "image/png", size, "")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("oversized image: got %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+
+	var small bytes.Buffer
+	if err := png.Encode(&small, image.NewRGBA(image.Rect(0, 0, 640, 480))); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	dm.serveResizedImage(w, httptest.NewRequest(http.MethodGet, "/api/image-proxy", nil), small.Bytes(), "https://www.nps.gov/small.png", "image/png", size, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("small image: got %d, want %d", w.Code, http.StatusOK)
+	}
+	if config, _, err := image.DecodeConfig(w.Body); err != nil || config.Width != 320 {
+		t.Errorf("small image: got %dx%d, err %v, want 320 wide", config.Width, config.Height, err)
+	}
+}
+
+func TestProxyImageSrcset(t *testing.T) {
+	srcset := proxyImageSrcset("https://www.nps.gov/common/uploads/yose.jpg")
+	variants := strings.Split(srcset, ", ")
+	if len(variants) != len(imageVariantWidths) {
+		t.Fatalf("got %d variants, want %d: %s", len(variants), len(imageVariantWidths), srcset)
+	}
+	if want := "/api/image-proxy?url=https%3A%2F%2Fwww.nps.gov%2Fcommon%2Fuploads%2Fyose.jpg&w=320 320w"; variants[0] != want {
+		t.Errorf("first variant = %q, want %q", variants[0], want)
+	}
+	if got := proxyImageURLSized("/common/uploads/yose.jpg", 400, 300, fitCover); !strings.HasSuffix(got, "&w=400&h=300&fit=cover") {
+		t.Errorf("proxyImageURLSized = %q", got)
+	}
+	if got := proxyImageSrcset(""); got != "" {
+		t.Errorf("srcset for no image = %q, want empty", got)
+	}
+}
+
+func TestImageCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := newImageCache(dir, 25, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ten := bytes.Repeat([]byte("x"), 10)
+
+	cache.Put("a", "image/jpeg", ten)
+	cache.Put("b", "image/png", ten)
+	if _, _, ok := cache.Get("a"); !ok {
+		t.Fatal("a should be cached")
+	}
+	// Over the limit, b is now the least recently used
+	cache.Put("c", "image/jpeg", ten)
+
+	if _, _, ok := cache.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.png")); !os.IsNotExist(err) {
+		t.Errorf("b.png should have been removed, stat err %v", err)
+	}
+	data, contentType, ok := cache.Get("a")
+	if !ok || contentType != "image/jpeg" || !bytes.Equal(data, ten) {
+		t.Errorf("a: got %q %s %v", data, contentType, ok)
+	}
+
+	// A reopened cache finds the images already on disk
+	reopened, err := newImageCache(dir, 25, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := reopened.Get("c"); !ok {
+		t.Error("c should survive reopening the cache")
+	}
+	if reopened.size != 20 {
+		t.Errorf("reopened cache size = %d, want 20", reopened.size)
+	}
+}
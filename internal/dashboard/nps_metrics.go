@@ -0,0 +1,277 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ztkent/go-nps"
+	"github.com/ztkent/parks-explorer/internal/logging"
+	"github.com/ztkent/parks-explorer/internal/metrics"
+)
+
+// instrumentedNPS passes calls through to an NPS API, recording their count, latency and
+// errors per endpoint in the parks_nps_* metrics. Calls made through withContext are also
+// logged at debug level and added to the request's trace.
+type instrumentedNPS struct {
+	api      nps.NpsApi
+	logger   *slog.Logger
+	ctx      context.Context
+	timeouts *npsTimeouts
+}
+
+var _ nps.NpsApi = (*instrumentedNPS)(nil)
+
+// defaultNPSTimeout bounds each NPS call unless configured otherwise, short of the go-nps
+// client's own 30 second timeout
+const defaultNPSTimeout = 20 * time.Second
+
+// npsTimeouts bounds NPS calls, by endpoint. It's set up before any calls are made.
+type npsTimeouts struct {
+	fallback   time.Duration
+	byEndpoint map[string]time.Duration
+}
+
+// forEndpoint returns how long to wait on a call to endpoint
+func (t *npsTimeouts) forEndpoint(endpoint string) time.Duration {
+	if timeout, ok := t.byEndpoint[endpoint]; ok {
+		return timeout
+	}
+	return t.fallback
+}
+
+// instrumentNPS wraps api with metrics and logging, unless it already is wrapped
+func instrumentNPS(api nps.NpsApi, logger *slog.Logger) *instrumentedNPS {
+	if i, ok := api.(*instrumentedNPS); ok {
+		return i
+	}
+	return &instrumentedNPS{api: api, logger: logger, ctx: context.Background(), timeouts: &npsTimeouts{fallback: defaultNPSTimeout}}
+}
+
+// withContext returns a copy of the API whose calls are traced against ctx's request
+func (i *instrumentedNPS) withContext(ctx context.Context) *instrumentedNPS {
+	return &instrumentedNPS{api: i.api, logger: i.logger, ctx: ctx, timeouts: i.timeouts}
+}
+
+// setTimeouts changes how long calls wait, fallback for any endpoint not in byEndpoint
+func (i *instrumentedNPS) setTimeouts(fallback time.Duration, byEndpoint map[string]time.Duration) {
+	*i.timeouts = npsTimeouts{fallback: fallback, byEndpoint: byEndpoint}
+}
+
+// observe records a call to endpoint that started at began
+func (i *instrumentedNPS) observe(endpoint string, began time.Time, err error) {
+	metrics.ObserveNPSRequest(endpoint, began, err)
+	logging.RecordNPSCall(i.ctx, endpoint, err)
+	if err != nil {
+		i.logger.WarnContext(i.ctx, "NPS request failed", "endpoint", endpoint, "duration", time.Since(began), "error", err)
+		return
+	}
+	i.logger.DebugContext(i.ctx, "NPS request", "endpoint", endpoint, "duration", time.Since(began))
+}
+
+// callNPS runs call against endpoint, giving up when i's context is done or after the endpoint's timeout.
+// go-nps can't cancel a request, so one that is given up on finishes in the background and its
+// result is dropped.
+func callNPS[T any](i *instrumentedNPS, endpoint string, call func() (T, error)) (T, error) {
+	var zero T
+	if err := i.ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		resp T
+		err  error
+	}
+	began := time.Now()
+	ctx, cancel := context.WithTimeout(i.ctx, i.timeouts.forEndpoint(endpoint))
+	defer cancel()
+	done := make(chan result, 1)
+	go func() {
+		resp, err := call()
+		done <- result{resp, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("NPS %s request abandoned: %w", endpoint, ctx.Err())
+	}
+	i.observe(endpoint, began, res.err)
+	return res.resp, res.err
+}
+
+func (i *instrumentedNPS) GetActivities(id, q string, limit, start int, sort string) (*nps.ActivityResponse, error) {
+	return callNPS(i, "activities", func() (*nps.ActivityResponse, error) {
+		return i.api.GetActivities(id, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetActivityParks(id []string, q string, limit, start int, sort string) (*nps.ActivityParkResponse, error) {
+	return callNPS(i, "activities_parks", func() (*nps.ActivityParkResponse, error) {
+		return i.api.GetActivityParks(id, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetAlerts(parkCode, stateCode []string, q string, limit, start int) (*nps.AlertResponse, error) {
+	return callNPS(i, "alerts", func() (*nps.AlertResponse, error) {
+		return i.api.GetAlerts(parkCode, stateCode, q, limit, start)
+	})
+}
+
+func (i *instrumentedNPS) GetAmenities(id []string, q string, limit, start int) (*nps.AmenityResponse, error) {
+	return callNPS(i, "amenities", func() (*nps.AmenityResponse, error) {
+		return i.api.GetAmenities(id, q, limit, start)
+	})
+}
+
+func (i *instrumentedNPS) GetAmenitiesParksPlaces(parkCode, id []string, q string, limit, start int, sort string) (*nps.AmenityParkPlaceResponse, error) {
+	return callNPS(i, "amenities_parksplaces", func() (*nps.AmenityParkPlaceResponse, error) {
+		return i.api.GetAmenitiesParksPlaces(parkCode, id, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetAmenitiesParksVisitorCenters(parkCode, id, q string, limit, start int, sort []string) (*nps.AmenityParkVisitorCenterResponse, error) {
+	return callNPS(i, "amenities_parksvisitorcenters", func() (*nps.AmenityParkVisitorCenterResponse, error) {
+		return i.api.GetAmenitiesParksVisitorCenters(parkCode, id, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetArticles(parkCode, stateCode []string, q string, limit, start int) (*nps.ArticleData, error) {
+	return callNPS(i, "articles", func() (*nps.ArticleData, error) {
+		return i.api.GetArticles(parkCode, stateCode, q, limit, start)
+	})
+}
+
+func (i *instrumentedNPS) GetCampgrounds(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.CampgroundData, error) {
+	return callNPS(i, "campgrounds", func() (*nps.CampgroundData, error) {
+		return i.api.GetCampgrounds(parkCode, stateCode, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetEvents(parkCode, stateCode, organization, subject, portal, tagsAll, tagsOne, tagsNone []string, dateStart, dateEnd string, eventType []string, id, q string, pageSize, pageNumber int, expandRecurring bool) (*nps.EventResponse, error) {
+	return callNPS(i, "events", func() (*nps.EventResponse, error) {
+		return i.api.GetEvents(parkCode, stateCode, organization, subject, portal, tagsAll, tagsOne, tagsNone, dateStart, dateEnd, eventType, id, q, pageSize, pageNumber, expandRecurring)
+	})
+}
+
+func (i *instrumentedNPS) GetFeesPasses(parkCode, stateCode []string, q string, start, limit int, sort []string) (*nps.FeePassResponse, error) {
+	return callNPS(i, "feespasses", func() (*nps.FeePassResponse, error) {
+		return i.api.GetFeesPasses(parkCode, stateCode, q, start, limit, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetLessonPlans(parkCode, stateCode []string, q string, start, limit int, sort []string) (*nps.LessonPlanResponse, error) {
+	return callNPS(i, "lessonplans", func() (*nps.LessonPlanResponse, error) {
+		return i.api.GetLessonPlans(parkCode, stateCode, q, start, limit, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetParkBoundaries(sitecode string) (*nps.MapdataParkboundaryResponse, error) {
+	return callNPS(i, "mapdata_parkboundaries", func() (*nps.MapdataParkboundaryResponse, error) {
+		return i.api.GetParkBoundaries(sitecode)
+	})
+}
+
+func (i *instrumentedNPS) GetMultimediaAudio(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaAudioResponse, error) {
+	return callNPS(i, "multimedia_audio", func() (*nps.MultimediaAudioResponse, error) {
+		return i.api.GetMultimediaAudio(parkCode, stateCode, q, start, limit)
+	})
+}
+
+func (i *instrumentedNPS) GetMultimediaGalleries(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaGalleriesResponse, error) {
+	return callNPS(i, "multimedia_galleries", func() (*nps.MultimediaGalleriesResponse, error) {
+		return i.api.GetMultimediaGalleries(parkCode, stateCode, q, start, limit)
+	})
+}
+
+func (i *instrumentedNPS) GetMultimediaVideos(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaVideosResponse, error) {
+	return callNPS(i, "multimedia_videos", func() (*nps.MultimediaVideosResponse, error) {
+		return i.api.GetMultimediaVideos(parkCode, stateCode, q, start, limit)
+	})
+}
+
+func (i *instrumentedNPS) GetNewsReleases(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.NewsReleaseResponse, error) {
+	return callNPS(i, "newsreleases", func() (*nps.NewsReleaseResponse, error) {
+		return i.api.GetNewsReleases(parkCode, stateCode, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetParkinglots(parkCode, stateCode []string, q string, start, limit int) (*nps.ParkinglotResponse, error) {
+	return callNPS(i, "parkinglots", func() (*nps.ParkinglotResponse, error) {
+		return i.api.GetParkinglots(parkCode, stateCode, q, start, limit)
+	})
+}
+
+func (i *instrumentedNPS) GetParks(parkCode, stateCode []string, start, limit int, q string, sort []string) (*nps.ParkResponse, error) {
+	return callNPS(i, "parks", func() (*nps.ParkResponse, error) {
+		return i.api.GetParks(parkCode, stateCode, start, limit, q, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetPassportStampLocations(parkCode, stateCode []string, q string, limit, start int) (*nps.PassportStampLocationResponse, error) {
+	return callNPS(i, "passportstamplocations", func() (*nps.PassportStampLocationResponse, error) {
+		return i.api.GetPassportStampLocations(parkCode, stateCode, q, limit, start)
+	})
+}
+
+func (i *instrumentedNPS) GetPeople(parkCode, stateCode []string, q string, limit, start int) (*nps.PersonResponse, error) {
+	return callNPS(i, "people", func() (*nps.PersonResponse, error) {
+		return i.api.GetPeople(parkCode, stateCode, q, limit, start)
+	})
+}
+
+func (i *instrumentedNPS) GetPlaces(parkCode, stateCode []string, q string, limit, start int) (*nps.PlaceResponse, error) {
+	return callNPS(i, "places", func() (*nps.PlaceResponse, error) {
+		return i.api.GetPlaces(parkCode, stateCode, q, limit, start)
+	})
+}
+
+func (i *instrumentedNPS) GetRoadEvents(parkCode, eventType string) (*nps.RoadEventResponse, error) {
+	return callNPS(i, "roadevents", func() (*nps.RoadEventResponse, error) {
+		return i.api.GetRoadEvents(parkCode, eventType)
+	})
+}
+
+func (i *instrumentedNPS) GetThingsToDo(id, parkCode, stateCode, q string, limit, start int, sort []string) (*nps.ThingsToDoResponse, error) {
+	return callNPS(i, "thingstodo", func() (*nps.ThingsToDoResponse, error) {
+		return i.api.GetThingsToDo(id, parkCode, stateCode, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetTopics(id, q string, limit, start int, sort string) (*nps.TopicResponse, error) {
+	return callNPS(i, "topics", func() (*nps.TopicResponse, error) {
+		return i.api.GetTopics(id, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetTopicParks(id []string, q string, limit, start int, sort string) (*nps.TopicParkResponse, error) {
+	return callNPS(i, "topics_parks", func() (*nps.TopicParkResponse, error) {
+		return i.api.GetTopicParks(id, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetTours(id, parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.TourResponse, error) {
+	return callNPS(i, "tours", func() (*nps.TourResponse, error) {
+		return i.api.GetTours(id, parkCode, stateCode, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetVisitorCenters(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.VisitorCenterResponse, error) {
+	return callNPS(i, "visitorcenters", func() (*nps.VisitorCenterResponse, error) {
+		return i.api.GetVisitorCenters(parkCode, stateCode, q, limit, start, sort)
+	})
+}
+
+func (i *instrumentedNPS) GetWebcams(id string, parkCode, stateCode []string, q string, limit, start int) (*nps.WebcamResponse, error) {
+	return callNPS(i, "webcams", func() (*nps.WebcamResponse, error) {
+		return i.api.GetWebcams(id, parkCode, stateCode, q, limit, start)
+	})
+}
+
+func (i *instrumentedNPS) GetMultimediaGalleriesAssets(id, galleryId string, parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaGalleriesAssetsResponse, error) {
+	return callNPS(i, "multimedia_galleries_assets", func() (*nps.MultimediaGalleriesAssetsResponse, error) {
+		return i.api.GetMultimediaGalleriesAssets(id, galleryId, parkCode, stateCode, q, start, limit)
+	})
+}
@@ -0,0 +1,53 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/npsfake"
+)
+
+func TestNPSEndpointTimeouts(t *testing.T) {
+	fake := npsfake.New(npsfake.Fixtures())
+	ps := NewParkService(fake, nil, slog.New(slog.DiscardHandler))
+	ps.SetNPSTimeouts(5*time.Second, map[string]time.Duration{"campgrounds": 20 * time.Millisecond})
+
+	releaseCampgrounds := fake.Hold("campgrounds")
+	defer releaseCampgrounds()
+	releaseFees := fake.Hold("feespasses")
+	defer releaseFees()
+
+	campgrounds := make(chan error, 1)
+	go func() {
+		_, err := ps.api(t.Context()).GetCampgrounds([]string{"yose"}, nil, "", 10, 0, nil)
+		campgrounds <- err
+	}()
+	fees := make(chan error, 1)
+	go func() {
+		_, err := ps.api(t.Context()).GetFeesPasses([]string{"yose"}, nil, "", 0, 20, nil)
+		fees <- err
+	}()
+
+	// Campgrounds are given up on after their own timeout, fees keep waiting on the default
+	select {
+	case err := <-campgrounds:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got campgrounds error %v, want the call abandoned", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("held campgrounds call wasn't abandoned after its 20ms timeout")
+	}
+	select {
+	case err := <-fees:
+		t.Fatalf("held fees call returned %v before its timeout", err)
+	default:
+	}
+
+	releaseFees()
+	if err := <-fees; err != nil {
+		t.Errorf("released fees call: %v", err)
+	}
+}
@@ -0,0 +1,627 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ztkent/go-nps"
+	"github.com/ztkent/parks-explorer/internal/database"
+)
+
+// OpenAPI specification.
+//
+// The spec at /api/openapi.json is built from the router itself, every route chi knows about is
+// looked up in routeDocs by method and pattern. Response schemas are derived from the Go types
+// the handlers encode, so they follow the code when a type changes. A route missing from
+// routeDocs still shows up in the spec, and fails TestRoutesDocumented.
+
+const openAPIVersion = "3.0.3"
+
+// routeDoc documents one route
+type routeDoc struct {
+	Summary     string
+	Tag         string
+	Params      []queryParam
+	Auth        string      // "user" or "admin" when the route needs a session
+	Body        interface{} // JSON request body, a value of the Go type the handler decodes
+	Status      int         // success status, defaults to 200
+	Response    interface{} // JSON response body, a value of the Go type the handler encodes
+	ContentType string      // content type of non-JSON responses, defaults to text/html
+	APIErrors   bool        // errors are v1 JSON errors rather than plain text
+}
+
+// queryParam documents one query string parameter
+type queryParam struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// jsonObject documents a JSON object the handler builds from a map. Values are examples of the
+// Go type each key holds.
+type jsonObject map[string]interface{}
+
+// oneOf documents a response that is one of several Go types
+type oneOf []interface{}
+
+var (
+	paramQ           = queryParam{"q", "string", "Search text"}
+	paramPark        = queryParam{"park", "string", "NPS park code, e.g. yose"}
+	paramState       = queryParam{"state", "string", "Two letter state code, e.g. CA"}
+	paramLimit       = queryParam{"limit", "integer", "Maximum number of results"}
+	paramOffset      = queryParam{"offset", "integer", "Number of results to skip"}
+	paramStart       = queryParam{"start", "integer", "Number of results to skip"}
+	paramDateStart   = queryParam{"date_start", "string", "First date, YYYY-MM-DD"}
+	paramDateEnd     = queryParam{"date_end", "string", "Last date, YYYY-MM-DD"}
+	paramEventType   = queryParam{"event_type", "string", "Event type, e.g. Guided Tour"}
+	paramNewsType    = queryParam{"news_type", "string", "releases, articles or alerts"}
+	paramAmenityType = queryParam{"amenity_type", "string", "Campground amenity"}
+	paramLat         = queryParam{"lat", "number", "Latitude"}
+	paramLng         = queryParam{"lng", "number", "Longitude"}
+	paramRadius      = queryParam{"radius", "number", "Search radius in miles"}
+)
+
+// apiListDoc and apiDataDoc document the v1 response envelopes
+func apiListDoc(items interface{}) jsonObject {
+	return jsonObject{"data": items, "pagination": apiPagination{}}
+}
+
+func apiDataDoc(data interface{}) jsonObject {
+	return jsonObject{"data": data}
+}
+
+// routeDocs documents every route registered in DefineRoutes, keyed by "METHOD pattern"
+var routeDocs = map[string]routeDoc{
+	// Pages
+	"GET /":                     {Summary: "Home page", Tag: "Pages"},
+	"GET /things-to-do":         {Summary: "Things to do page", Tag: "Pages"},
+	"GET /events":               {Summary: "Events page", Tag: "Pages"},
+	"GET /camping":              {Summary: "Camping page", Tag: "Pages"},
+	"GET /news":                 {Summary: "News page", Tag: "Pages"},
+	"GET /parks/{slug}":         {Summary: "Park page", Tag: "Pages"},
+	"GET /my-parks":             {Summary: "Saved parks page", Tag: "Pages"},
+	"GET /trips":                {Summary: "Trip planner page", Tag: "Pages"},
+	"GET /trips/{tripID}":       {Summary: "Trip itinerary page", Tag: "Pages"},
+	"GET /trips/{tripID}/print": {Summary: "Printable trip itinerary", Tag: "Pages"},
+	"GET /api/docs":             {Summary: "Browsable API documentation", Tag: "Pages"},
+
+	// Feeds
+	"GET /feeds/news.rss": {Summary: "Park news as RSS", Tag: "Feeds", ContentType: "application/rss+xml",
+		Params: []queryParam{paramQ, paramPark, paramState, paramNewsType, paramLimit}},
+	"GET /feeds/news.atom": {Summary: "Park news as Atom", Tag: "Feeds", ContentType: "application/atom+xml",
+		Params: []queryParam{paramQ, paramPark, paramState, paramNewsType, paramLimit}},
+	"GET /parks/{slug}/feed.atom": {Summary: "A park's news as Atom", Tag: "Feeds", ContentType: "application/atom+xml",
+		Params: []queryParam{paramQ, paramNewsType, paramLimit}},
+
+	// Static files
+	"GET /robots.txt":       {Summary: "Robots exclusion rules", Tag: "Static", ContentType: "text/plain"},
+	"GET /site.webmanifest": {Summary: "Web app manifest", Tag: "Static", ContentType: "application/manifest+json"},
+	"GET /sitemap.xml":      {Summary: "Sitemap", Tag: "Static", ContentType: "application/xml"},
+	"GET /static/*":         {Summary: "Static assets", Tag: "Static", ContentType: "application/octet-stream"},
+
+	// Auth
+	"GET /api/auth/google": {Summary: "Start Google sign in", Tag: "Auth", Status: http.StatusTemporaryRedirect},
+	"GET /api/auth/google/callback": {Summary: "Google OAuth callback", Tag: "Auth", Status: http.StatusSeeOther,
+		Params: []queryParam{{"code", "string", "OAuth authorization code"}, {"state", "string", "OAuth state"}}},
+	"GET /api/auth/logout": {Summary: "Sign out", Tag: "Auth", Status: http.StatusSeeOther},
+	"GET /api/user-info": {Summary: "Signed-in user", Tag: "Auth", Response: jsonObject{
+		"authenticated": true,
+		"user":          jsonObject{"id": 0, "email": "", "username": "", "avatar_url": ""},
+	}},
+	"GET /api/avatar":      {Summary: "Signed-in user's avatar image", Tag: "Auth", ContentType: "image/*"},
+	"GET /api/auth-status": {Summary: "Sign in button or user menu fragment", Tag: "Auth"},
+
+	"GET /api/analytics/config": {Summary: "Analytics configuration", Tag: "Site", Response: AnalyticsConfig{}},
+	"GET /api/image-proxy": {Summary: "Proxy an NPS image, optionally resized", Tag: "Site", ContentType: "image/*",
+		Params: []queryParam{
+			{"url", "string", "NPS image URL"},
+			{"w", "integer", "Resize to at most this width, up to 2048"},
+			{"h", "integer", "Resize to at most this height, up to 2048"},
+			{"fit", "string", "contain (default) keeps the whole image, cover crops it to fill w x h"},
+		}},
+	"GET /api/templates/{template}": {Summary: "Shared header or footer fragment", Tag: "Site"},
+	"GET /api/openapi.json":         {Summary: "This OpenAPI document", Tag: "Site", Response: jsonObject{}},
+	"GET /healthz":                  {Summary: "Liveness probe, ok while the process is up", Tag: "Site", ContentType: "text/plain"},
+	"GET /readyz": {Summary: "Readiness probe, 503 until the database is migrated and parks are synced", Tag: "Site",
+		Response: jsonObject{"status": "", "error": ""}},
+
+	// Favorites
+	"GET /api/favorites/parks": {Summary: "Saved park cards fragment", Tag: "Favorites"},
+	"GET /api/favorites": {Summary: "Saved park codes", Tag: "Favorites", Auth: "user",
+		Response: jsonObject{"park_codes": []string{}}},
+	"PUT /api/favorites/{parkCode}": {Summary: "Save a park", Tag: "Favorites", Auth: "user",
+		Response: jsonObject{"park_code": "", "favorite": true}},
+	"DELETE /api/favorites/{parkCode}": {Summary: "Remove a saved park", Tag: "Favorites", Auth: "user",
+		Response: jsonObject{"park_code": "", "favorite": false}},
+
+	// Trips
+	"GET /api/trips": {Summary: "List trips", Tag: "Trips", Auth: "user",
+		Response: jsonObject{"trips": []database.Trip{}}},
+	"POST /api/trips": {Summary: "Create a trip", Tag: "Trips", Auth: "user",
+		Body: tripRequest{}, Status: http.StatusCreated, Response: database.Trip{}},
+	"GET /api/trips/{tripID}": {Summary: "Get a trip and its items", Tag: "Trips", Auth: "user",
+		Response: database.Trip{}},
+	"PATCH /api/trips/{tripID}": {Summary: "Update a trip", Tag: "Trips", Auth: "user",
+		Body: tripRequest{}, Response: database.Trip{}},
+	"DELETE /api/trips/{tripID}": {Summary: "Delete a trip", Tag: "Trips", Auth: "user",
+		Status: http.StatusNoContent},
+	"POST /api/trips/{tripID}/items": {Summary: "Add an item to a trip", Tag: "Trips", Auth: "user",
+		Body: tripItemRequest{}, Status: http.StatusCreated, Response: database.TripItem{}},
+	"PATCH /api/trips/{tripID}/items/{itemID}": {Summary: "Move a trip item or edit its notes", Tag: "Trips", Auth: "user",
+		Body: tripItemRequest{}, Response: database.Trip{}},
+	"DELETE /api/trips/{tripID}/items/{itemID}": {Summary: "Remove a trip item", Tag: "Trips", Auth: "user",
+		Status: http.StatusNoContent},
+
+	// Admin
+	"GET /api/admin/sync-runs": {Summary: "Recent NPS sync runs", Tag: "Admin", Auth: "admin",
+		Params:   []queryParam{paramLimit},
+		Response: jsonObject{"runs": []database.SyncRun{}}},
+	"GET /api/admin/schema": {Summary: "Database schema version and migrations", Tag: "Admin", Auth: "admin",
+		Response: jsonObject{"version": 0, "migrations": []database.Migration{}}},
+	"GET /api/admin/status": {Summary: "Readiness, park count, cache freshness, last syncs and NPS key status", Tag: "Admin", Auth: "admin",
+		Response: AdminStatus{}},
+
+	// HTMX fragments
+	"GET /api/parks": {Summary: "Park cards fragment", Tag: "Fragments",
+		Params: []queryParam{paramOffset, paramLimit}},
+	"GET /api/parks/featured": {Summary: "Featured parks fragment", Tag: "Fragments"},
+	"GET /api/parks/search": {Summary: "Park search results fragment", Tag: "Fragments",
+		Params: []queryParam{paramQ}},
+	"GET /api/things-to-do/search": {Summary: "Things to do results fragment", Tag: "Fragments",
+		Params: []queryParam{paramQ, {"activity-search", "string", "Search text from the search box"},
+			{"parkCode", "string", "NPS park code"}, {"stateCode", "string", "Two letter state code"},
+			{"activityId", "string", "NPS activity ID"}, {"difficulty", "string", "Difficulty"}, paramStart, paramLimit}},
+	"GET /api/events/search": {Summary: "Event results fragment", Tag: "Fragments",
+		Params: []queryParam{paramQ, paramPark, paramState, paramEventType, paramDateStart, paramDateEnd,
+			{"date", "string", "Single date, YYYY-MM-DD"}, paramStart, paramLimit}},
+	"GET /api/events/{eventID}/details": {Summary: "Event details fragment", Tag: "Fragments"},
+	"GET /api/camping/search": {Summary: "Campground results fragment", Tag: "Fragments",
+		Params: []queryParam{paramQ, paramPark, paramState, paramAmenityType, paramStart, paramLimit}},
+	"GET /api/news/search": {Summary: "News results fragment", Tag: "Fragments",
+		Params: []queryParam{paramQ, paramPark, paramState, paramNewsType, paramStart, paramLimit}},
+	"GET /api/parks/{parkCode}/overview":   {Summary: "Park overview tab fragment", Tag: "Fragments"},
+	"GET /api/parks/{parkCode}/activities": {Summary: "Park activities tab fragment", Tag: "Fragments"},
+	"GET /api/parks/{parkCode}/media":      {Summary: "Park media tab fragment", Tag: "Fragments"},
+	"GET /api/parks/{parkCode}/news":       {Summary: "Park news tab fragment", Tag: "Fragments"},
+	"GET /api/parks/{parkCode}/details":    {Summary: "Park details tab fragment", Tag: "Fragments"},
+
+	// JSON search and nearby parks
+	"GET /api/parks/nearby": {Summary: "Parks near a location, nearest first", Tag: "Search",
+		Params: []queryParam{paramLat, paramLng, paramRadius, paramLimit},
+		Response: jsonObject{"lat": 0.0, "lng": 0.0, "radius": 0.0, "parks": []jsonObject{{
+			"park_code": "", "name": "", "full_name": "", "slug": "", "states": "", "designation": "",
+			"latitude": "", "longitude": "", "url": "", "image_url": "", "distance_miles": 0.0,
+		}}}},
+	"GET /api/search": {Summary: "Full-text search across parks, things to do, events and news", Tag: "Search",
+		Params:   []queryParam{paramQ, {"type", "string", "Comma separated entity types"}, paramLimit, paramOffset},
+		Response: jsonObject{"query": "", "results": []database.SearchResult{}, "limit": 0, "offset": 0}},
+
+	// Calendars
+	"GET /api/events/{eventID}/event.ics": {Summary: "An event as iCalendar", Tag: "Calendars", ContentType: "text/calendar"},
+	"GET /api/events/calendar.ics": {Summary: "Matching events as a subscribable iCalendar feed", Tag: "Calendars", ContentType: "text/calendar",
+		Params: []queryParam{paramQ, paramPark, paramState, paramEventType, paramDateStart, paramDateEnd}},
+
+	// Versioned JSON API
+	"GET /api/v1/parks": {Summary: "List parks", Tag: "v1", APIErrors: true,
+		Params:   []queryParam{paramQ, paramState, paramLat, paramLng, paramRadius, paramLimit, paramOffset},
+		Response: apiListDoc([]apiPark{})},
+	"GET /api/v1/parks/{parkCode}": {Summary: "Get a park", Tag: "v1", APIErrors: true,
+		Response: apiDataDoc(apiPark{})},
+	"GET /api/v1/parks/{parkCode}/{tab}": {Summary: "Get a park page tab: overview, activities, media, news or details", Tag: "v1", APIErrors: true,
+		Response: apiDataDoc(oneOf{ParkOverview{}, ParkActivities{}, ParkMedia{}, ParkNews{}, ParkDetails{}})},
+	"GET /api/v1/things-to-do": {Summary: "Search things to do", Tag: "v1", APIErrors: true,
+		Params:   []queryParam{paramQ, paramPark, paramState, {"activity", "string", "NPS activity ID"}, paramLimit, paramOffset},
+		Response: apiListDoc([]nps.ThingsToDo{})},
+	"GET /api/v1/events": {Summary: "Search events", Tag: "v1", APIErrors: true,
+		Params:   []queryParam{paramQ, paramPark, paramState, paramEventType, paramDateStart, paramDateEnd, paramLimit, paramOffset},
+		Response: apiListDoc([]nps.Event{})},
+	"GET /api/v1/events/{eventID}": {Summary: "Get an event", Tag: "v1", APIErrors: true,
+		Response: apiDataDoc(nps.Event{})},
+	"GET /api/v1/campgrounds": {Summary: "Search campgrounds", Tag: "v1", APIErrors: true,
+		Params:   []queryParam{paramQ, paramPark, paramState, paramLimit, paramOffset},
+		Response: apiListDoc([]nps.Campground{})},
+	"GET /api/v1/news": {Summary: "Search news releases, articles or alerts", Tag: "v1", APIErrors: true,
+		Params:   []queryParam{paramQ, paramPark, paramState, paramNewsType, paramLimit, paramOffset},
+		Response: apiListDoc([]UnifiedNewsItem{})},
+}
+
+type openAPISpec struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type openAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Required    bool           `json:"required,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*openAPISchema          `json:"oneOf,omitempty"`
+}
+
+// OpenAPIHandler serves the OpenAPI spec for routes. The spec is built on the first request,
+// once every route has been registered.
+func (dm *Dashboard) OpenAPIHandler(routes chi.Routes) http.HandlerFunc {
+	var once sync.Once
+	var body []byte
+	var buildErr error
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			spec, err := buildOpenAPI(routes)
+			if err != nil {
+				buildErr = err
+				return
+			}
+			body, buildErr = json.MarshalIndent(spec, "", "  ")
+		})
+		if buildErr != nil {
+			dm.logger.ErrorContext(r.Context(), "Failed to build OpenAPI spec", "error", buildErr)
+			http.Error(w, "Failed to build OpenAPI spec", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// APIDocsPageHandler serves the browsable API documentation
+func (dm *Dashboard) APIDocsPageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tmpl, err := dm.templates.Lookup("api-docs.html")
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to load API docs template", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to load API docs template: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, nil)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to render API docs page", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to render API docs page: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// UndocumentedRoutes lists the routes with no entry in routeDocs, as "METHOD pattern"
+func UndocumentedRoutes(routes chi.Routes) ([]string, error) {
+	var missing []string
+	err := walkRoutes(routes, func(key string) {
+		if _, ok := routeDocs[key]; !ok {
+			missing = append(missing, key)
+		}
+	})
+	sort.Strings(missing)
+	return missing, err
+}
+
+// UnregisteredRouteDocs lists the routeDocs entries that match no route
+func UnregisteredRouteDocs(routes chi.Routes) ([]string, error) {
+	registered := map[string]bool{}
+	err := walkRoutes(routes, func(key string) {
+		registered[key] = true
+	})
+	var stale []string
+	for key := range routeDocs {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	return stale, err
+}
+
+// walkRoutes calls fn with "METHOD pattern" for every route
+func walkRoutes(routes chi.Routes, fn func(key string)) error {
+	return chi.Walk(routes, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		fn(method + " " + route)
+		return nil
+	})
+}
+
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+func buildOpenAPI(routes chi.Routes) (*openAPISpec, error) {
+	spec := &openAPISpec{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:       "Parks Explorer",
+			Description: "Pages, HTMX fragments, feeds and the versioned JSON API. Prefer /api/v1 for integrations.",
+			Version:     "1.0.0",
+		},
+		Paths: map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				"session": {Type: "apiKey", In: "cookie", Name: "session_token", Description: "Set by Google sign in"},
+			},
+		},
+	}
+	schemas := newSchemaRegistry()
+
+	err := chi.Walk(routes, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		doc, ok := routeDocs[method+" "+route]
+		if !ok {
+			doc = routeDoc{Summary: "Undocumented"}
+		}
+
+		path := route
+		var params []openAPIParameter
+		if strings.HasSuffix(path, "/*") {
+			path = strings.TrimSuffix(path, "*") + "{path}"
+		}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+			params = append(params, openAPIParameter{Name: match[1], In: "path", Required: true, Schema: &openAPISchema{Type: "string"}})
+		}
+		for _, p := range doc.Params {
+			params = append(params, openAPIParameter{Name: p.Name, In: "query", Description: p.Description, Schema: &openAPISchema{Type: p.Type}})
+		}
+
+		op := &openAPIOperation{
+			Summary:    doc.Summary,
+			Parameters: params,
+			Responses:  map[string]openAPIResponse{},
+		}
+		if doc.Tag != "" {
+			op.Tags = []string{doc.Tag}
+		}
+		if doc.Body != nil {
+			op.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content:  map[string]openAPIMediaType{"application/json": {Schema: schemas.schemaFor(doc.Body)}},
+			}
+		}
+
+		status := doc.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := openAPIResponse{Description: http.StatusText(status)}
+		switch {
+		case doc.Response != nil:
+			success.Content = map[string]openAPIMediaType{"application/json": {Schema: schemas.schemaFor(doc.Response)}}
+		case status == http.StatusOK:
+			contentType := doc.ContentType
+			if contentType == "" {
+				contentType = "text/html"
+			}
+			schema := &openAPISchema{Type: "string"}
+			if strings.HasPrefix(contentType, "image/") || contentType == "application/octet-stream" {
+				schema.Format = "binary"
+			}
+			success.Content = map[string]openAPIMediaType{contentType: {Schema: schema}}
+		}
+		op.Responses[fmt.Sprint(status)] = success
+
+		if doc.APIErrors {
+			op.Responses["default"] = openAPIResponse{
+				Description: "Error",
+				Content: map[string]openAPIMediaType{"application/json": {
+					Schema: schemas.schemaFor(jsonObject{"error": apiError{}}),
+				}},
+			}
+		} else {
+			op.Responses["default"] = openAPIResponse{
+				Description: "Error",
+				Content:     map[string]openAPIMediaType{"text/plain": {Schema: &openAPISchema{Type: "string"}}},
+			}
+		}
+		if doc.Auth != "" {
+			op.Security = []map[string][]string{{"session": {}}}
+			op.Responses["401"] = openAPIResponse{Description: "Not signed in"}
+			if doc.Auth == "admin" {
+				op.Responses["403"] = openAPIResponse{Description: "Not an admin"}
+			}
+		}
+
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = map[string]*openAPIOperation{}
+		}
+		spec.Paths[path][strings.ToLower(method)] = op
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk routes: %w", err)
+	}
+
+	spec.Components.Schemas = schemas.schemas
+	return spec, nil
+}
+
+// schemaRegistry derives JSON schemas from Go types, named structs become shared components
+type schemaRegistry struct {
+	schemas map[string]*openAPISchema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: map[string]*openAPISchema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema for a documented value
+func (sr *schemaRegistry) schemaFor(v interface{}) *openAPISchema {
+	switch v := v.(type) {
+	case jsonObject:
+		schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+		for key, value := range v {
+			schema.Properties[key] = sr.schemaFor(value)
+		}
+		return schema
+	case []jsonObject:
+		items := &openAPISchema{Type: "object"}
+		if len(v) > 0 {
+			items = sr.schemaFor(v[0])
+		}
+		return &openAPISchema{Type: "array", Items: items}
+	case oneOf:
+		schema := &openAPISchema{}
+		for _, value := range v {
+			schema.OneOf = append(schema.OneOf, sr.schemaFor(value))
+		}
+		return schema
+	}
+	return sr.typeSchema(reflect.TypeOf(v))
+}
+
+func (sr *schemaRegistry) typeSchema(t reflect.Type) *openAPISchema {
+	if t == timeType {
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := sr.typeSchema(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: sr.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: sr.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sr.structSchema(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + sr.register(t)}
+	}
+	// interface{} and anything else JSON can't describe more precisely
+	return &openAPISchema{}
+}
+
+// register adds a named struct to the components, prefixing the package name when two
+// packages use the same type name
+func (sr *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := sr.names[t]; ok {
+		return name
+	}
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, taken := sr.schemas[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	// Claim the name before walking the fields, so recursive types terminate
+	sr.names[t] = name
+	sr.schemas[name] = nil
+	sr.schemas[name] = sr.structSchema(t)
+	return name
+}
+
+func (sr *schemaRegistry) structSchema(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a name are flattened into the parent, like encoding/json does
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := sr.structSchema(fieldType)
+			for key, value := range embedded.Properties {
+				schema.Properties[key] = value
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "string") {
+			schema.Properties[name] = &openAPISchema{Type: "string"}
+		} else {
+			schema.Properties[name] = sr.typeSchema(field.Type)
+		}
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
@@ -0,0 +1,240 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ztkent/go-nps"
+	"github.com/ztkent/parks-explorer/internal/database"
+	"github.com/ztkent/parks-explorer/internal/logging"
+	"github.com/ztkent/parks-explorer/internal/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is how long cached per-park NPS data is considered fresh
+const DefaultCacheTTL = 24 * time.Hour
+
+// parkCache is the type-independent view of a parkDataCache, used to configure and refresh caches in bulk
+type parkCache interface {
+	Table() string
+	DataType() string
+	TTL() time.Duration
+	SetTTL(ttl time.Duration)
+	Refresh(ctx context.Context, parkCode string) error
+}
+
+// parkDataCache is a cache-through loader for one type of per-park NPS data.
+// Responses are stored as JSON in one of the park_* cache tables, keyed by park ID and data type,
+// or by park ID and a key within the park, like a gallery ID, for caches made by newGalleryAssetsCache.
+//
+// Stale rows are served immediately while a background refresh runs, and concurrent
+// fetches for the same park are coalesced into a single upstream call. Values returned
+// by Get may be shared between callers and must not be modified. Lookups and writes are
+// recorded in the trace of the request in ctx.
+type parkDataCache[T any] struct {
+	db       *database.DB
+	logger   *slog.Logger
+	table    string
+	dataType string
+	ttl      atomic.Int64
+	fetch    func(ctx context.Context, parkCode, key string) (*T, error)
+	read     func(ctx context.Context, parkID int, key string) (apiData string, lastFetched time.Time, err error)
+	write    func(ctx context.Context, parkID int, key string, data *T) error
+	inflight singleflight.Group
+	waiting  atomic.Int64 // Callers waiting on a fetch in inflight
+}
+
+// newParkDataCache creates a cache for dataType rows in table, refreshed by fetch once older than ttl
+func newParkDataCache[T any](db *database.DB, logger *slog.Logger, table, dataType string, ttl time.Duration, fetch func(ctx context.Context, parkCode string) (*T, error)) *parkDataCache[T] {
+	c := &parkDataCache[T]{
+		db:       db,
+		logger:   logger,
+		table:    table,
+		dataType: dataType,
+		fetch: func(ctx context.Context, parkCode, _ string) (*T, error) {
+			return fetch(ctx, parkCode)
+		},
+		read: func(ctx context.Context, parkID int, _ string) (string, time.Time, error) {
+			cachedData, err := db.GetCachedParkData(ctx, parkID, dataType, table)
+			if err != nil {
+				return "", time.Time{}, err
+			}
+			return cachedData.APIData, cachedData.LastFetchedAt, nil
+		},
+		write: func(ctx context.Context, parkID int, _ string, data *T) error {
+			return db.UpsertParkData(ctx, parkID, dataType, table, data)
+		},
+	}
+	c.ttl.Store(int64(ttl))
+	return c
+}
+
+// newGalleryAssetsCache creates a cache for the assets of each multimedia gallery, stored in
+// park_gallery_assets and keyed by gallery ID. Use GetKey, there's no data for a park as a whole.
+func newGalleryAssetsCache(db *database.DB, logger *slog.Logger, ttl time.Duration, fetch func(ctx context.Context, parkCode, galleryID string) (*nps.MultimediaGalleriesAssetsResponse, error)) *parkDataCache[nps.MultimediaGalleriesAssetsResponse] {
+	c := &parkDataCache[nps.MultimediaGalleriesAssetsResponse]{
+		db:       db,
+		logger:   logger,
+		table:    "park_gallery_assets",
+		dataType: "gallery_assets",
+		fetch:    fetch,
+		read: func(ctx context.Context, parkID int, galleryID string) (string, time.Time, error) {
+			asset, err := db.GetCachedGalleryAssets(ctx, parkID, galleryID)
+			if err != nil {
+				return "", time.Time{}, err
+			}
+			return asset.APIData, asset.LastFetchedAt, nil
+		},
+		write: db.UpsertGalleryAssets,
+	}
+	c.ttl.Store(int64(ttl))
+	return c
+}
+
+func (c *parkDataCache[T]) Table() string      { return c.table }
+func (c *parkDataCache[T]) DataType() string   { return c.dataType }
+func (c *parkDataCache[T]) TTL() time.Duration { return time.Duration(c.ttl.Load()) }
+
+// SetTTL changes how long cached rows are served before being refreshed
+func (c *parkDataCache[T]) SetTTL(ttl time.Duration) {
+	c.ttl.Store(int64(ttl))
+}
+
+// Get returns the data for a park. Fresh rows are served from the cache, stale rows are served
+// while a background refresh runs, and missing rows block on a fetch from the API.
+func (c *parkDataCache[T]) Get(ctx context.Context, parkCode string) (*T, error) {
+	return c.GetKey(ctx, parkCode, "")
+}
+
+// GetKey is Get for the row stored under key within a park
+func (c *parkDataCache[T]) GetKey(ctx context.Context, parkCode, key string) (*T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	parkID, err := c.db.GetParkIDByCode(ctx, parkCode)
+	if err != nil {
+		// Parks missing from the local catalog can't be cached, go straight to the API
+		c.observe(ctx, metrics.CacheMiss)
+		return c.coalesce(ctx, parkCode, key, func(ctx context.Context) (*T, error) {
+			return c.fetch(ctx, parkCode, key)
+		})
+	}
+
+	data, lastFetched, err := c.load(ctx, parkID, key)
+	if err == nil {
+		if time.Since(lastFetched) > c.TTL() {
+			c.observe(ctx, metrics.CacheStale)
+			c.revalidate(ctx, parkID, parkCode, key)
+		} else {
+			c.observe(ctx, metrics.CacheHit)
+		}
+		return data, nil
+	}
+	c.observe(ctx, metrics.CacheMiss)
+	return c.coalesce(ctx, parkCode, key, func(ctx context.Context) (*T, error) {
+		return c.update(ctx, parkID, parkCode, key)
+	})
+}
+
+// observe records a lookup in the metrics and the request's trace
+func (c *parkDataCache[T]) observe(ctx context.Context, result string) {
+	metrics.ObserveParkCacheLookup(c.table, c.dataType, result)
+	logging.RecordCacheLookup(ctx, c.table, c.dataType, result)
+}
+
+// Refresh fetches the data for a park from the API and stores it, regardless of freshness
+func (c *parkDataCache[T]) Refresh(ctx context.Context, parkCode string) error {
+	parkID, err := c.db.GetParkIDByCode(ctx, parkCode)
+	if err != nil {
+		return err
+	}
+	_, err = c.coalesce(ctx, parkCode, "", func(ctx context.Context) (*T, error) {
+		return c.update(ctx, parkID, parkCode, "")
+	})
+	return err
+}
+
+// revalidate refreshes a park's row in the background, unless a fetch for it is already in flight.
+// The refresh outlives the request, but is still logged with its request ID.
+func (c *parkDataCache[T]) revalidate(ctx context.Context, parkID int, parkCode, key string) {
+	ctx = context.WithoutCancel(ctx)
+	ch := c.inflight.DoChan(flightKey(parkCode, key), func() (interface{}, error) {
+		return c.update(ctx, parkID, parkCode, key)
+	})
+	go func() {
+		if res := <-ch; res.Err != nil {
+			c.logger.WarnContext(ctx, "Background refresh failed", c.logAttrs(parkCode, key, "error", res.Err)...)
+		}
+	}()
+}
+
+// coalesce runs fn, sharing its result with any concurrent callers for the same park and key.
+// fn isn't canceled with ctx, so a fetch other callers are waiting on still completes and is
+// cached, but each caller stops waiting once its own ctx is done.
+func (c *parkDataCache[T]) coalesce(ctx context.Context, parkCode, key string, fn func(ctx context.Context) (*T, error)) (*T, error) {
+	shared := context.WithoutCancel(ctx)
+	ch := c.inflight.DoChan(flightKey(parkCode, key), func() (interface{}, error) {
+		return fn(shared)
+	})
+	c.waiting.Add(1)
+	defer c.waiting.Add(-1)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*T), nil
+	}
+}
+
+// load reads and decodes the cached row for a park, along with when it was fetched
+func (c *parkDataCache[T]) load(ctx context.Context, parkID int, key string) (*T, time.Time, error) {
+	apiData, lastFetched, err := c.read(ctx, parkID, key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var data T
+	if err := json.Unmarshal([]byte(apiData), &data); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode cached %s for park %d: %w", c.dataType, parkID, err)
+	}
+	return &data, lastFetched, nil
+}
+
+// update fetches fresh data from the API and writes it to the cache table
+func (c *parkDataCache[T]) update(ctx context.Context, parkID int, parkCode, key string) (*T, error) {
+	data, err := c.fetch(ctx, parkCode, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.write(ctx, parkID, key, data); err != nil {
+		c.logger.WarnContext(ctx, "Failed to cache park data", c.logAttrs(parkCode, key, "error", err)...)
+		return data, nil
+	}
+	logging.RecordCacheWrite(ctx, c.table, c.dataType)
+	c.logger.DebugContext(ctx, "Cached park data", c.logAttrs(parkCode, key)...)
+	return data, nil
+}
+
+// logAttrs identifies a row in log messages, followed by any extra attributes
+func (c *parkDataCache[T]) logAttrs(parkCode, key string, extra ...any) []any {
+	attrs := []any{"table", c.table, "data_type", c.dataType, "park", parkCode}
+	if key != "" {
+		attrs = append(attrs, "key", key)
+	}
+	return append(attrs, extra...)
+}
+
+// flightKey identifies a fetch to coalesce
+func flightKey(parkCode, key string) string {
+	if key == "" {
+		return parkCode
+	}
+	return parkCode + "/" + key
+}
@@ -0,0 +1,137 @@
+package dashboard
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/config"
+	"github.com/ztkent/parks-explorer/internal/logging"
+	"github.com/ztkent/parks-explorer/internal/npsfake"
+)
+
+// traced reports whether a trace has recorded the attribute key, e.g. cache_miss
+func traced(trace *logging.Trace, key string) bool {
+	for _, attr := range trace.Attrs() {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// waitFor polls until done reports true, failing the test after a few seconds
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestParkCacheCoalescesMisses(t *testing.T) {
+	dm, fake := newTestDashboard(t)
+	ps := dm.parkService
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+
+	const callers = 10
+	release := fake.Hold("campgrounds")
+	defer release()
+	errs := make(chan error, callers)
+	for range callers {
+		go func() {
+			_, err := ps.GetParkCampgrounds(t.Context(), "yose")
+			errs <- err
+		}()
+	}
+
+	// Let the fetch finish only once every caller is waiting on it
+	waitFor(t, "every caller to join the fetch", func() bool { return ps.campgrounds.waiting.Load() == callers })
+	release()
+	for range callers {
+		if err := <-errs; err != nil {
+			t.Errorf("failed to get campgrounds: %v", err)
+		}
+	}
+	if calls := fake.Calls("campgrounds"); calls != 1 {
+		t.Errorf("%d concurrent misses made %d NPS calls, want 1", callers, calls)
+	}
+}
+
+func TestParkCacheServesStaleWhileRefreshing(t *testing.T) {
+	dm, fake := newTestDashboard(t)
+	ps := dm.parkService
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+	if _, err := ps.GetParkCampgrounds(t.Context(), "yose"); err != nil {
+		t.Fatalf("failed to get campgrounds: %v", err)
+	}
+	ps.campgrounds.SetTTL(time.Nanosecond)
+
+	// The refresh is held, so these only return if they don't wait for it
+	release := fake.Hold("campgrounds")
+	defer release()
+	ctx, trace := logging.WithTrace(t.Context())
+	campgrounds, err := ps.GetParkCampgrounds(ctx, "yose")
+	if err != nil || len(campgrounds.Data) != 1 {
+		t.Fatalf("stale lookup: got %v, err %v, want the cached campground", campgrounds, err)
+	}
+	if !traced(trace, "cache_stale") {
+		t.Errorf("stale lookup trace = %v, want cache_stale", trace.Attrs())
+	}
+	waitFor(t, "the background refresh", func() bool { return fake.Calls("campgrounds") == 2 })
+	if _, err := ps.GetParkCampgrounds(t.Context(), "yose"); err != nil {
+		t.Fatalf("second stale lookup: %v", err)
+	}
+	if calls := fake.Calls("campgrounds"); calls != 2 {
+		t.Errorf("a refresh already in flight was started again: %d NPS calls, want 2", calls)
+	}
+
+	// The refresh outlives the lookup, and is written to the cache once released
+	release()
+	waitFor(t, "the refresh to be cached", func() bool { return traced(trace, "cache_writes") })
+}
+
+func TestParkCacheConfiguredTTL(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.CacheTTLs = map[string]time.Duration{"campgrounds": time.Nanosecond}
+	dm := NewDashboardWithAPI(npsfake.New(npsfake.Fixtures()), cfg, slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { dm.Close() })
+	ps := dm.parkService
+	if _, err := ps.SyncParks(t.Context()); err != nil {
+		t.Fatalf("failed to sync parks: %v", err)
+	}
+	ps.GetParkCampgrounds(t.Context(), "yose")
+	ps.GetParkFees(t.Context(), "yose")
+
+	// Campgrounds are stale as soon as they're cached, fees keep the default TTL
+	ctx, trace := logging.WithTrace(t.Context())
+	ps.GetParkCampgrounds(ctx, "yose")
+	ps.GetParkFees(ctx, "yose")
+	attrs := map[string]string{}
+	for _, attr := range trace.Attrs() {
+		attrs[attr.Key] = attr.Value.String()
+	}
+	if attrs["cache_stale"] != "[park_details/campgrounds]" || attrs["cache_hit"] != "[park_details/fees]" {
+		t.Errorf("got trace %v, want stale campgrounds and fresh fees", attrs)
+	}
+	waitFor(t, "the campgrounds refresh", func() bool { return traced(trace, "cache_writes") })
+}
+
+func TestCacheTTLSettingsKnown(t *testing.T) {
+	ps := NewParkService(npsfake.New(npsfake.Fixtures()), nil, slog.New(slog.DiscardHandler))
+	for _, dataType := range config.CacheDataTypes {
+		if err := ps.SetCacheTTL(dataType, time.Hour); err != nil {
+			t.Errorf("CACHE_TTL setting for %s: %v", dataType, err)
+		}
+	}
+	if len(ps.caches) != len(config.CacheDataTypes) {
+		t.Errorf("got %d caches and %d CACHE_TTL settings, want one each", len(ps.caches), len(config.CacheDataTypes))
+	}
+}
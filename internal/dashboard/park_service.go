@@ -1,95 +1,231 @@
 package dashboard
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"html"
+	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/ztkent/go-nps"
 	"github.com/ztkent/parks-explorer/internal/database"
+	"golang.org/x/sync/errgroup"
 )
 
 // GetFeaturedParks returns the first 12 parks for the featured section
-func (ps *ParkService) GetFeaturedParks() ([]database.CachedPark, error) {
+func (ps *ParkService) GetFeaturedParks(ctx context.Context) ([]database.CachedPark, error) {
 	// Use pagination to get exactly 12 featured parks
-	return ps.GetParksWithPagination(0, 12)
+	return ps.GetParksWithPagination(ctx, 0, 12)
 }
 
 // ParkService handles park data with caching
 type ParkService struct {
-	npsApi nps.NpsApi
+	npsApi *instrumentedNPS
 	db     *database.DB
+	logger *slog.Logger
+
+	// Per-park data, cached in the park_activities, park_media, park_news and park_details tables
+	thingsToDo     *parkDataCache[nps.ThingsToDoResponse]
+	tours          *parkDataCache[nps.TourResponse]
+	activities     *parkDataCache[nps.ActivityResponse]
+	galleries      *parkDataCache[nps.MultimediaGalleriesResponse]
+	videos         *parkDataCache[nps.MultimediaVideosResponse]
+	audio          *parkDataCache[nps.MultimediaAudioResponse]
+	webcams        *parkDataCache[nps.WebcamResponse]
+	articles       *parkDataCache[nps.ArticleData]
+	alerts         *parkDataCache[nps.AlertResponse]
+	events         *parkDataCache[nps.EventResponse]
+	newsReleases   *parkDataCache[nps.NewsReleaseResponse]
+	visitorCenters *parkDataCache[nps.VisitorCenterResponse]
+	campgrounds    *parkDataCache[nps.CampgroundData]
+	fees           *parkDataCache[nps.FeePassResponse]
+	amenities      *parkDataCache[nps.AmenityResponse]
+	parkingLots    *parkDataCache[nps.ParkinglotResponse]
+	caches         []parkCache
+
+	// Each gallery's assets, cached in park_gallery_assets by gallery ID
+	galleryAssets *parkDataCache[nps.MultimediaGalleriesAssetsResponse]
 }
 
 // NewParkService creates a new park service
-func NewParkService(npsApi nps.NpsApi, db *database.DB) *ParkService {
-	return &ParkService{
-		npsApi: npsApi,
+func NewParkService(npsApi nps.NpsApi, db *database.DB, logger *slog.Logger) *ParkService {
+	api := instrumentNPS(npsApi, logger)
+	ps := &ParkService{
+		npsApi: api,
 		db:     db,
+		logger: logger,
+	}
+
+	// park_activities
+	ps.thingsToDo = newParkDataCache(db, logger, "park_activities", "things_to_do", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.ThingsToDoResponse, error) {
+		return api.withContext(ctx).GetThingsToDo("", parkCode, "", "", 20, 0, nil)
+	})
+	ps.tours = newParkDataCache(db, logger, "park_activities", "tours", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.TourResponse, error) {
+		return api.withContext(ctx).GetTours(nil, []string{parkCode}, nil, "", 20, 0, nil)
+	})
+	ps.activities = newParkDataCache(db, logger, "park_activities", "activities", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.ActivityResponse, error) {
+		return api.withContext(ctx).GetActivities("", "", 20, 0, "")
+	})
+
+	// park_media
+	ps.galleries = newParkDataCache(db, logger, "park_media", "galleries", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.MultimediaGalleriesResponse, error) {
+		return api.withContext(ctx).GetMultimediaGalleries([]string{parkCode}, nil, "", 0, 10)
+	})
+	ps.videos = newParkDataCache(db, logger, "park_media", "videos", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.MultimediaVideosResponse, error) {
+		return api.withContext(ctx).GetMultimediaVideos([]string{parkCode}, nil, "", 0, 10)
+	})
+	ps.audio = newParkDataCache(db, logger, "park_media", "audio", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.MultimediaAudioResponse, error) {
+		return api.withContext(ctx).GetMultimediaAudio([]string{parkCode}, nil, "", 0, 10)
+	})
+	ps.webcams = newParkDataCache(db, logger, "park_media", "webcams", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.WebcamResponse, error) {
+		return api.withContext(ctx).GetWebcams("", []string{parkCode}, nil, "", 10, 0)
+	})
+
+	// park_news
+	ps.articles = newParkDataCache(db, logger, "park_news", "articles", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.ArticleData, error) {
+		return api.withContext(ctx).GetArticles([]string{parkCode}, nil, "", 10, 0)
+	})
+	ps.alerts = newParkDataCache(db, logger, "park_news", "alerts", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.AlertResponse, error) {
+		return api.withContext(ctx).GetAlerts([]string{parkCode}, nil, "", 10, 0)
+	})
+	ps.events = newParkDataCache(db, logger, "park_news", "events", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.EventResponse, error) {
+		today := time.Now().Format("2006-01-02")
+		return api.withContext(ctx).GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
+	})
+	ps.newsReleases = newParkDataCache(db, logger, "park_news", "news_releases", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.NewsReleaseResponse, error) {
+		return api.withContext(ctx).GetNewsReleases([]string{parkCode}, nil, "", 10, 0, nil)
+	})
+
+	// park_details
+	ps.visitorCenters = newParkDataCache(db, logger, "park_details", "visitor_centers", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.VisitorCenterResponse, error) {
+		return api.withContext(ctx).GetVisitorCenters([]string{parkCode}, nil, "", 20, 0, nil)
+	})
+	ps.campgrounds = newParkDataCache(db, logger, "park_details", "campgrounds", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.CampgroundData, error) {
+		return api.withContext(ctx).GetCampgrounds([]string{parkCode}, nil, "", 10, 0, nil)
+	})
+	ps.fees = newParkDataCache(db, logger, "park_details", "fees", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.FeePassResponse, error) {
+		return api.withContext(ctx).GetFeesPasses([]string{parkCode}, nil, "", 0, 20, nil)
+	})
+	ps.amenities = newParkDataCache(db, logger, "park_details", "amenities", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.AmenityResponse, error) {
+		return api.withContext(ctx).GetAmenities([]string{parkCode}, "", 20, 0)
+	})
+	ps.parkingLots = newParkDataCache(db, logger, "park_details", "parking_lots", DefaultCacheTTL, func(ctx context.Context, parkCode string) (*nps.ParkinglotResponse, error) {
+		return api.withContext(ctx).GetParkinglots([]string{parkCode}, nil, "", 0, 20)
+	})
+
+	ps.galleryAssets = newGalleryAssetsCache(db, logger, DefaultCacheTTL, func(ctx context.Context, parkCode, galleryID string) (*nps.MultimediaGalleriesAssetsResponse, error) {
+		return api.withContext(ctx).GetMultimediaGalleriesAssets("", galleryID, []string{parkCode}, nil, "", 0, 500)
+	})
+
+	ps.caches = []parkCache{
+		ps.thingsToDo, ps.tours, ps.activities,
+		ps.galleries, ps.videos, ps.audio, ps.webcams,
+		ps.articles, ps.alerts, ps.events, ps.newsReleases,
+		ps.visitorCenters, ps.campgrounds, ps.fees, ps.amenities, ps.parkingLots,
+	}
+	return ps
+}
+
+// api returns the NPS API, tracing calls against ctx's request
+func (ps *ParkService) api(ctx context.Context) nps.NpsApi {
+	return ps.npsApi.withContext(ctx)
+}
+
+// SetNPSTimeouts changes how long NPS calls are waited on, timeout for any endpoint not in
+// byEndpoint. Call it before the service is used.
+func (ps *ParkService) SetNPSTimeouts(timeout time.Duration, byEndpoint map[string]time.Duration) {
+	ps.npsApi.setTimeouts(timeout, byEndpoint)
+}
+
+// SetCacheTTL overrides the freshness window for one type of per-park data, e.g. "alerts" or "campgrounds"
+func (ps *ParkService) SetCacheTTL(dataType string, ttl time.Duration) error {
+	for _, c := range ps.caches {
+		if c.DataType() == dataType {
+			c.SetTTL(ttl)
+			if c == parkCache(ps.galleries) {
+				// Gallery assets are kept as fresh as the galleries listing them
+				ps.galleryAssets.SetTTL(ttl)
+			}
+			return nil
+		}
 	}
+	return fmt.Errorf("unknown park data type: %s", dataType)
 }
 
 // GetEventsWithFilters fetches events with filtering options
-func (ps *ParkService) GetEventsWithFilters(parkCodes []string, stateCodes []string, dateStart, dateEnd string, eventTypes []string, query string, pageSize, pageNumber int) (*nps.EventResponse, error) {
+func (ps *ParkService) GetEventsWithFilters(ctx context.Context, parkCodes []string, stateCodes []string, dateStart, dateEnd string, eventTypes []string, query string, pageSize, pageNumber int) (*nps.EventResponse, error) {
 	// Ensure we always use today as the minimum start date to avoid showing past events
 	today := time.Now().Format("2006-01-02")
 	if dateStart == "" || dateStart < today {
 		dateStart = today
 	}
 
-	return ps.npsApi.GetEvents(parkCodes, stateCodes, nil, nil, nil, nil, nil, nil, dateStart, dateEnd, eventTypes, "", query, pageSize, pageNumber, false)
+	return ps.api(ctx).GetEvents(parkCodes, stateCodes, nil, nil, nil, nil, nil, nil, dateStart, dateEnd, eventTypes, "", query, pageSize, pageNumber, false)
 }
 
 // GetAllParks returns all parks, using cache when possible
-func (ps *ParkService) GetAllParks() ([]database.CachedPark, error) {
+func (ps *ParkService) GetAllParks(ctx context.Context) ([]database.CachedPark, error) {
 	// First try to get cached parks
-	parks, err := ps.db.GetAllParks()
+	parks, err := ps.db.GetAllParks(ctx)
 	if err != nil || len(parks) == 0 {
 		// If no cached parks or error, fetch from API
-		res, err := ps.npsApi.GetParks(nil, nil, 0, 500, "", nil)
+		if _, err := ps.SyncParks(ctx); err != nil {
+			return nil, err
+		}
+
+		// Get the cached parks after insertion
+		parks, err = ps.db.GetAllParks(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch parks from API: %w", err)
+			return nil, fmt.Errorf("failed to get cached parks after API fetch: %w", err)
 		}
+	}
 
-		// Cache the response
-		for _, park := range res.Data {
-			slug := createSlug(park.Name)
-
-			// Convert park to map for easier handling
-			parkMap := map[string]interface{}{
-				"parkCode":       park.ParkCode,
-				"name":           park.Name,
-				"fullName":       park.FullName,
-				"states":         park.States,
-				"designation":    park.Designation,
-				"description":    park.Description,
-				"weatherInfo":    park.WeatherInfo,
-				"directionsInfo": park.DirectionsInfo,
-				"url":            park.Url,
-				"directionsUrl":  park.DirectionsUrl,
-				"latitude":       park.Latitude,
-				"longitude":      park.Longitude,
-				"latLong":        park.LatLong,
-				"relevanceScore": park.RelevanceScore,
-				"images":         convertImagesToInterface(park.Images),
-			}
+	return parks, nil
+}
 
-			_, err := ps.db.UpsertPark(parkMap, slug)
-			if err != nil {
-				fmt.Printf("Warning: Failed to cache park %s: %v\n", park.Name, err)
-				continue
-			}
+// SyncParks fetches the full park list from the API and caches it, returning how many parks were stored
+func (ps *ParkService) SyncParks(ctx context.Context) (int, error) {
+	res, err := ps.api(ctx).GetParks(nil, nil, 0, 500, "", nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch parks from API: %w", err)
+	}
+
+	// Cache the response
+	synced := 0
+	for _, park := range res.Data {
+		slug := createSlug(park.Name)
+
+		// Convert park to map for easier handling
+		parkMap := map[string]interface{}{
+			"parkCode":       park.ParkCode,
+			"name":           park.Name,
+			"fullName":       park.FullName,
+			"states":         park.States,
+			"designation":    park.Designation,
+			"description":    park.Description,
+			"weatherInfo":    park.WeatherInfo,
+			"directionsInfo": park.DirectionsInfo,
+			"url":            park.Url,
+			"directionsUrl":  park.DirectionsUrl,
+			"latitude":       park.Latitude,
+			"longitude":      park.Longitude,
+			"latLong":        park.LatLong,
+			"relevanceScore": park.RelevanceScore,
+			"images":         convertImagesToInterface(park.Images),
 		}
 
-		// Get the cached parks after insertion
-		parks, err = ps.db.GetAllParks()
+		_, err := ps.db.UpsertPark(ctx, parkMap, slug)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get cached parks after API fetch: %w", err)
+			ps.logger.WarnContext(ctx, "Failed to cache park", "park", park.ParkCode, "error", err)
+			continue
 		}
+		synced++
 	}
 
-	return parks, nil
+	return synced, nil
 }
 
 func convertImagesToInterface(images []struct {
@@ -113,9 +249,9 @@ func convertImagesToInterface(images []struct {
 }
 
 // SearchParks searches for parks by name
-func (ps *ParkService) SearchParks(query string) ([]database.CachedPark, error) {
+func (ps *ParkService) SearchParks(ctx context.Context, query string) ([]database.CachedPark, error) {
 	// Always try cache first for search
-	results, err := ps.db.SearchParks(query)
+	results, err := ps.db.SearchParks(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search cached parks: %w", err)
 	}
@@ -127,490 +263,157 @@ func (ps *ParkService) SearchParks(query string) ([]database.CachedPark, error)
 	return results, nil
 }
 
-// GetParkBySlug returns a specific park by slug
-func (ps *ParkService) GetParkBySlug(slug string) (*database.CachedPark, error) {
-	park, err := ps.db.GetParkBySlug(slug)
-	if err == nil {
-		return park, nil
+// Search runs a full-text search across cached parks, things to do, events, news, campgrounds and
+// visitor centers. Without the full-text index only parks are searched, unranked.
+func (ps *ParkService) Search(ctx context.Context, query string, entityTypes []string, limit, offset int) ([]database.SearchResult, error) {
+	if ps.db.SearchEnabled() {
+		return ps.db.Search(ctx, query, entityTypes, limit, offset)
 	}
-	return nil, fmt.Errorf("park not found: %s", slug)
-}
 
-// GetParksWithPagination returns parks with pagination support
-func (ps *ParkService) GetParksWithPagination(offset, limit int) ([]database.CachedPark, error) {
-	return ps.db.GetParksWithPagination(offset, limit)
-}
-
-func (ps *ParkService) GetParkArticles(parkCode string) (*nps.ArticleData, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetArticles([]string{parkCode}, nil, "", 10, 0)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "articles", "park_news", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetArticles([]string{parkCode}, nil, "", 10, 0)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "articles", "park_news", response)
-		}
-		return response, err
+	results := []database.SearchResult{}
+	if len(entityTypes) > 0 && !slices.Contains(entityTypes, database.EntityPark) {
+		return results, nil
 	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "articles", "park_news")
+	parks, err := ps.db.SearchParks(ctx, query)
 	if err != nil {
-		return ps.npsApi.GetArticles([]string{parkCode}, nil, "", 10, 0)
-	}
-
-	var response nps.ArticleData
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetArticles([]string{parkCode}, nil, "", 10, 0)
+		return nil, fmt.Errorf("failed to search cached parks: %w", err)
 	}
-
-	return &response, nil
-}
-
-// GetParkAlerts fetches alerts for a specific park, preferring cache
-func (ps *ParkService) GetParkAlerts(parkCode string) (*nps.AlertResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetAlerts([]string{parkCode}, nil, "", 10, 0)
+	if offset >= len(parks) {
+		return results, nil
 	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "alerts", "park_news", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetAlerts([]string{parkCode}, nil, "", 10, 0)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "alerts", "park_news", response)
+	parks = parks[offset:min(offset+limit, len(parks))]
+	for _, park := range parks {
+		result := database.SearchResult{
+			EntityType: database.EntityPark,
+			EntityID:   park.ParkCode,
+			ParkCode:   park.ParkCode,
+			Title:      park.FullName,
+			URL:        "/parks/" + park.Slug,
+			Highlight:  html.EscapeString(park.FullName),
+			Snippet:    html.EscapeString(park.Description),
 		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "alerts", "park_news")
-	if err != nil {
-		return ps.npsApi.GetAlerts([]string{parkCode}, nil, "", 10, 0)
-	}
-
-	var response nps.AlertResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetAlerts([]string{parkCode}, nil, "", 10, 0)
-	}
-
-	return &response, nil
-}
-
-// GetParkEventsList fetches events for a specific park, preferring cache
-func (ps *ParkService) GetParkEventsList(parkCode string) (*nps.EventResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	today := time.Now().Format("2006-01-02")
-
-	if err != nil {
-		return ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "events", "park_news", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "events", "park_news", response)
+		if len(park.Images) > 0 {
+			result.ImageURL = park.Images[0].URL
 		}
-		return response, err
+		results = append(results, result)
 	}
+	return results, nil
+}
 
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "events", "park_news")
+// GetParksNear returns the parks within radiusMiles of a point, nearest first
+func (ps *ParkService) GetParksNear(ctx context.Context, lat, lng, radiusMiles float64, limit int) ([]database.NearbyPark, error) {
+	parks, err := ps.db.GetParksNear(ctx, lat, lng, radiusMiles, limit)
 	if err != nil {
-		return ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
+		return nil, fmt.Errorf("failed to find nearby parks: %w", err)
 	}
+	return parks, nil
+}
 
-	var response nps.EventResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
+// GetParkBySlug returns a specific park by slug
+func (ps *ParkService) GetParkBySlug(ctx context.Context, slug string) (*database.CachedPark, error) {
+	park, err := ps.db.GetParkBySlug(ctx, slug)
+	if err == nil {
+		return park, nil
 	}
-
-	return &response, nil
+	return nil, fmt.Errorf("park not found: %s", slug)
 }
 
-func (ps *ParkService) GetParkVisitorCenters(parkCode string) (*nps.VisitorCenterResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetVisitorCenters([]string{parkCode}, nil, "", 20, 0, nil)
-	}
+// GetParksWithPagination returns parks with pagination support
+func (ps *ParkService) GetParksWithPagination(ctx context.Context, offset, limit int) ([]database.CachedPark, error) {
+	return ps.db.GetParksWithPagination(ctx, offset, limit)
+}
 
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "visitor_centers", "park_details", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetVisitorCenters([]string{parkCode}, nil, "", 20, 0, nil)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "visitor_centers", "park_details", response)
-		}
-		return response, err
-	}
+// GetParkArticles fetches articles for a specific park, preferring cache
+func (ps *ParkService) GetParkArticles(ctx context.Context, parkCode string) (*nps.ArticleData, error) {
+	return ps.articles.Get(ctx, parkCode)
+}
 
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "visitor_centers", "park_details")
-	if err != nil {
-		return ps.npsApi.GetVisitorCenters([]string{parkCode}, nil, "", 20, 0, nil)
-	}
+// GetParkAlerts fetches alerts for a specific park, preferring cache
+func (ps *ParkService) GetParkAlerts(ctx context.Context, parkCode string) (*nps.AlertResponse, error) {
+	return ps.alerts.Get(ctx, parkCode)
+}
 
-	var response nps.VisitorCenterResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetVisitorCenters([]string{parkCode}, nil, "", 20, 0, nil)
-	}
+// GetParkEventsList fetches events for a specific park, preferring cache
+func (ps *ParkService) GetParkEventsList(ctx context.Context, parkCode string) (*nps.EventResponse, error) {
+	return ps.events.Get(ctx, parkCode)
+}
 
-	return &response, nil
+// GetParkVisitorCenters fetches visitor centers for a specific park, preferring cache
+func (ps *ParkService) GetParkVisitorCenters(ctx context.Context, parkCode string) (*nps.VisitorCenterResponse, error) {
+	return ps.visitorCenters.Get(ctx, parkCode)
 }
 
 // GetParkFees fetches fees and passes for a specific park, preferring cache
-func (ps *ParkService) GetParkFees(parkCode string) (*nps.FeePassResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetFeesPasses([]string{parkCode}, nil, "", 0, 20, nil)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "fees", "park_details", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetFeesPasses([]string{parkCode}, nil, "", 0, 20, nil)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "fees", "park_details", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "fees", "park_details")
-	if err != nil {
-		return ps.npsApi.GetFeesPasses([]string{parkCode}, nil, "", 0, 20, nil)
-	}
-
-	var response nps.FeePassResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetFeesPasses([]string{parkCode}, nil, "", 0, 20, nil)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkFees(ctx context.Context, parkCode string) (*nps.FeePassResponse, error) {
+	return ps.fees.Get(ctx, parkCode)
 }
 
 // GetParkParking fetches parking lots for a specific park, preferring cache
-func (ps *ParkService) GetParkParking(parkCode string) (*nps.ParkinglotResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetParkinglots([]string{parkCode}, nil, "", 0, 20)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "parking_lots", "park_details", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetParkinglots([]string{parkCode}, nil, "", 0, 20)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "parking_lots", "park_details", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "parking_lots", "park_details")
-	if err != nil {
-		return ps.npsApi.GetParkinglots([]string{parkCode}, nil, "", 0, 20)
-	}
-
-	var response nps.ParkinglotResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetParkinglots([]string{parkCode}, nil, "", 0, 20)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkParking(ctx context.Context, parkCode string) (*nps.ParkinglotResponse, error) {
+	return ps.parkingLots.Get(ctx, parkCode)
 }
 
 // GetParkThingsToDo fetches things to do for a specific park, preferring cache
-func (ps *ParkService) GetParkThingsToDo(parkCode string) (*nps.ThingsToDoResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetThingsToDo("", parkCode, "", "", 20, 0, nil)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "things_to_do", "park_activities", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetThingsToDo("", parkCode, "", "", 20, 0, nil)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "things_to_do", "park_activities", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "things_to_do", "park_activities")
-	if err != nil {
-		return ps.npsApi.GetThingsToDo("", parkCode, "", "", 20, 0, nil)
-	}
-
-	var response nps.ThingsToDoResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetThingsToDo("", parkCode, "", "", 20, 0, nil)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkThingsToDo(ctx context.Context, parkCode string) (*nps.ThingsToDoResponse, error) {
+	return ps.thingsToDo.Get(ctx, parkCode)
 }
 
 // GetParkTours fetches tours for a specific park, preferring cache
-func (ps *ParkService) GetParkTours(parkCode string) (*nps.TourResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetTours(nil, []string{parkCode}, nil, "", 20, 0, nil)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "tours", "park_activities", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetTours(nil, []string{parkCode}, nil, "", 20, 0, nil)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "tours", "park_activities", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "tours", "park_activities")
-	if err != nil {
-		return ps.npsApi.GetTours(nil, []string{parkCode}, nil, "", 20, 0, nil)
-	}
-
-	var response nps.TourResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetTours(nil, []string{parkCode}, nil, "", 20, 0, nil)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkTours(ctx context.Context, parkCode string) (*nps.TourResponse, error) {
+	return ps.tours.Get(ctx, parkCode)
 }
 
 // GetParkActivities fetches activities available for a specific park, preferring cache
-func (ps *ParkService) GetParkActivities(parkCode string) (*nps.ActivityResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetActivities("", "", 20, 0, "")
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "activities", "park_activities", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetActivities("", "", 20, 0, "")
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "activities", "park_activities", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "activities", "park_activities")
-	if err != nil {
-		return ps.npsApi.GetActivities("", "", 20, 0, "")
-	}
-
-	var response nps.ActivityResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetActivities("", "", 20, 0, "")
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkActivities(ctx context.Context, parkCode string) (*nps.ActivityResponse, error) {
+	return ps.activities.Get(ctx, parkCode)
 }
 
 // GetParkAmenities fetches amenities for a specific park, preferring cache
-func (ps *ParkService) GetParkAmenities(parkCode string) (*nps.AmenityResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetAmenities([]string{parkCode}, "", 20, 0)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "amenities", "park_details", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetAmenities([]string{parkCode}, "", 20, 0)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "amenities", "park_details", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "amenities", "park_details")
-	if err != nil {
-		return ps.npsApi.GetAmenities([]string{parkCode}, "", 20, 0)
-	}
-
-	var response nps.AmenityResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetAmenities([]string{parkCode}, "", 20, 0)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkAmenities(ctx context.Context, parkCode string) (*nps.AmenityResponse, error) {
+	return ps.amenities.Get(ctx, parkCode)
 }
 
 // GetParkNewsReleases fetches news releases for a specific park, preferring cache
-func (ps *ParkService) GetParkNewsReleases(parkCode string) (*nps.NewsReleaseResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetNewsReleases([]string{parkCode}, nil, "", 10, 0, nil)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "news_releases", "park_news", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetNewsReleases([]string{parkCode}, nil, "", 10, 0, nil)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "news_releases", "park_news", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "news_releases", "park_news")
-	if err != nil {
-		return ps.npsApi.GetNewsReleases([]string{parkCode}, nil, "", 10, 0, nil)
-	}
-
-	var response nps.NewsReleaseResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetNewsReleases([]string{parkCode}, nil, "", 10, 0, nil)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkNewsReleases(ctx context.Context, parkCode string) (*nps.NewsReleaseResponse, error) {
+	return ps.newsReleases.Get(ctx, parkCode)
 }
 
 // GetParkMultimediaAudio fetches audio content for a specific park, preferring cache
-func (ps *ParkService) GetParkMultimediaAudio(parkCode string) (*nps.MultimediaAudioResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetMultimediaAudio([]string{parkCode}, nil, "", 0, 10)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "audio", "park_media", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetMultimediaAudio([]string{parkCode}, nil, "", 0, 10)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "audio", "park_media", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "audio", "park_media")
-	if err != nil {
-		return ps.npsApi.GetMultimediaAudio([]string{parkCode}, nil, "", 0, 10)
-	}
-
-	var response nps.MultimediaAudioResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetMultimediaAudio([]string{parkCode}, nil, "", 0, 10)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkMultimediaAudio(ctx context.Context, parkCode string) (*nps.MultimediaAudioResponse, error) {
+	return ps.audio.Get(ctx, parkCode)
 }
 
 // GetParkMultimediaGalleries fetches multimedia galleries for a specific park, preferring cache
-func (ps *ParkService) GetParkMultimediaGalleries(parkCode string) (*nps.MultimediaGalleriesResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
+func (ps *ParkService) GetParkMultimediaGalleries(ctx context.Context, parkCode string) (*nps.MultimediaGalleriesResponse, error) {
+	cached, err := ps.galleries.Get(ctx, parkCode)
 	if err != nil {
-		return ps.npsApi.GetMultimediaGalleries([]string{parkCode}, nil, "", 0, 10)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "galleries", "park_media", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetMultimediaGalleries([]string{parkCode}, nil, "", 0, 10)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "galleries", "park_media", response)
-		}
-		// For each gallery, fetch assets if available
-		for i, gallery := range response.Data {
-			if gallery.ID != "" {
-				assets, err := ps.getParkMultimediaGalleriesAssets(gallery.ID, parkCode)
-				if err != nil {
-					return nil, fmt.Errorf("failed to fetch assets for gallery %s: %w", gallery.ID, err)
-				}
-				for _, asset := range assets.Data {
-					response.Data[i].Images = append(response.Data[i].Images, struct {
-						Url         string "json:\"url\""
-						AltText     string "json:\"altText\""
-						Title       string "json:\"title\""
-						Description string "json:\"description\""
-					}{
-						Url:         asset.FileInfo.Url,
-						AltText:     asset.AltText,
-						Title:       asset.Title,
-						Description: asset.Description,
-					})
-				}
-			}
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "galleries", "park_media")
-	if err != nil {
-		return ps.npsApi.GetMultimediaGalleries([]string{parkCode}, nil, "", 0, 10)
+		return nil, err
 	}
 
-	var response nps.MultimediaGalleriesResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetMultimediaGalleries([]string{parkCode}, nil, "", 0, 10)
-	}
+	// The cached response may be shared with other requests, so add assets to a copy
+	response := *cached
+	response.Data = append(response.Data[:0:0], cached.Data...)
 
-	// For each gallery, fetch assets if available
+	// Fetch each gallery's assets concurrently. A gallery whose assets can't be fetched is
+	// left out, rather than failing the whole section.
+	var group errgroup.Group
+	group.SetLimit(maxParallelFetches)
+	failed := make([]bool, len(response.Data))
 	for i, gallery := range response.Data {
-		if gallery.ID != "" {
-			assets, err := ps.getParkMultimediaGalleriesAssets(gallery.ID, parkCode)
+		if gallery.ID == "" {
+			continue
+		}
+		group.Go(func() error {
+			assets, err := ps.getParkMultimediaGalleriesAssets(ctx, gallery.ID, parkCode)
 			if err != nil {
-				return nil, fmt.Errorf("failed to fetch assets for gallery %s: %w", gallery.ID, err)
+				ps.logger.WarnContext(ctx, "Failed to fetch gallery assets, skipping the gallery", "park", parkCode, "gallery", gallery.ID, "error", err)
+				failed[i] = true
+				return nil
 			}
+			// Each goroutine only touches its own gallery
+			images := response.Data[i].Images[:len(gallery.Images):len(gallery.Images)]
 			for _, asset := range assets.Data {
-				response.Data[i].Images = append(response.Data[i].Images, struct {
+				images = append(images, struct {
 					Url         string "json:\"url\""
 					AltText     string "json:\"altText\""
 					Title       string "json:\"title\""
@@ -622,190 +425,57 @@ func (ps *ParkService) GetParkMultimediaGalleries(parkCode string) (*nps.Multime
 					Description: asset.Description,
 				})
 			}
-		}
+			response.Data[i].Images = images
+			return nil
+		})
 	}
-
-	return &response, nil
-}
-
-// getParkMultimediaGalleriesAssets fetches assets for a specific gallery, preferring cache
-func (ps *ParkService) getParkMultimediaGalleriesAssets(galleryId string, parkCode string) (*nps.MultimediaGalleriesAssetsResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetMultimediaGalleriesAssets("", galleryId, []string{parkCode}, nil, "", 0, 500)
+	group.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	// Check if cached data is fresh using the dedicated gallery assets table
-	stale, err := ps.db.IsGalleryAssetsStale(parkID, galleryId, 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetMultimediaGalleriesAssets("", galleryId, []string{parkCode}, nil, "", 0, 500)
-		if err == nil {
-			ps.db.UpsertGalleryAssets(parkID, galleryId, response)
+	galleries := response.Data[:0]
+	for i, gallery := range response.Data {
+		if !failed[i] {
+			galleries = append(galleries, gallery)
 		}
-		return response, err
-	}
-
-	// Return cached data from the dedicated table
-	cachedData, err := ps.db.GetCachedGalleryAssets(parkID, galleryId)
-	if err != nil {
-		return ps.npsApi.GetMultimediaGalleriesAssets("", galleryId, []string{parkCode}, nil, "", 0, 500)
-	}
-
-	var response nps.MultimediaGalleriesAssetsResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetMultimediaGalleriesAssets("", galleryId, []string{parkCode}, nil, "", 0, 500)
 	}
-
+	response.Data = galleries
 	return &response, nil
 }
 
-// GetParkMultimediaVideos fetches multimedia videos for a specific park, preferring cache
-func (ps *ParkService) GetParkMultimediaVideos(parkCode string) (*nps.MultimediaVideosResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetMultimediaVideos([]string{parkCode}, nil, "", 0, 10)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "videos", "park_media", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetMultimediaVideos([]string{parkCode}, nil, "", 0, 10)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "videos", "park_media", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "videos", "park_media")
-	if err != nil {
-		return ps.npsApi.GetMultimediaVideos([]string{parkCode}, nil, "", 0, 10)
-	}
-
-	var response nps.MultimediaVideosResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetMultimediaVideos([]string{parkCode}, nil, "", 0, 10)
-	}
+// getParkMultimediaGalleriesAssets fetches assets for a specific gallery, preferring cache
+func (ps *ParkService) getParkMultimediaGalleriesAssets(ctx context.Context, galleryId string, parkCode string) (*nps.MultimediaGalleriesAssetsResponse, error) {
+	return ps.galleryAssets.GetKey(ctx, parkCode, galleryId)
+}
 
-	return &response, nil
+// GetParkMultimediaVideos fetches multimedia videos for a specific park, preferring cache
+func (ps *ParkService) GetParkMultimediaVideos(ctx context.Context, parkCode string) (*nps.MultimediaVideosResponse, error) {
+	return ps.videos.Get(ctx, parkCode)
 }
 
 // GetParkWebcams fetches webcams for a specific park, preferring cache
-func (ps *ParkService) GetParkWebcams(parkCode string) (*nps.WebcamResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetWebcams("", []string{parkCode}, nil, "", 10, 0)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "webcams", "park_media", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetWebcams("", []string{parkCode}, nil, "", 10, 0)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "webcams", "park_media", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "webcams", "park_media")
-	if err != nil {
-		return ps.npsApi.GetWebcams("", []string{parkCode}, nil, "", 10, 0)
-	}
-
-	var response nps.WebcamResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetWebcams("", []string{parkCode}, nil, "", 10, 0)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkWebcams(ctx context.Context, parkCode string) (*nps.WebcamResponse, error) {
+	return ps.webcams.Get(ctx, parkCode)
 }
 
 // GetParkEvents fetches events for a specific park, preferring cache
-func (ps *ParkService) GetParkEvents(parkCode string) (*nps.EventResponse, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	today := time.Now().Format("2006-01-02")
-
-	if err != nil {
-		return ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "events", "park_news", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "events", "park_news", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "events", "park_news")
-	if err != nil {
-		return ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
-	}
-
-	var response nps.EventResponse
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetEvents([]string{parkCode}, nil, nil, nil, nil, nil, nil, nil, today, "", nil, "", "", 10, 0, false)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkEvents(ctx context.Context, parkCode string) (*nps.EventResponse, error) {
+	return ps.events.Get(ctx, parkCode)
 }
 
 // GetParkCampgrounds fetches campgrounds for a specific park, preferring cache
-func (ps *ParkService) GetParkCampgrounds(parkCode string) (*nps.CampgroundData, error) {
-	// Get park ID first
-	parkID, err := ps.db.GetParkIDByCode(parkCode)
-	if err != nil {
-		return ps.npsApi.GetCampgrounds([]string{parkCode}, nil, "", 10, 0, nil)
-	}
-
-	// Check if cached data is fresh
-	stale, err := ps.db.IsParkDataStale(parkID, "campgrounds", "park_details", 24*time.Hour)
-	if err != nil || stale {
-		// Fetch fresh data from API
-		response, err := ps.npsApi.GetCampgrounds([]string{parkCode}, nil, "", 10, 0, nil)
-		if err == nil {
-			// Cache the response
-			ps.db.UpsertParkData(parkID, "campgrounds", "park_details", response)
-		}
-		return response, err
-	}
-
-	// Return cached data
-	cachedData, err := ps.db.GetCachedParkData(parkID, "campgrounds", "park_details")
-	if err != nil {
-		return ps.npsApi.GetCampgrounds([]string{parkCode}, nil, "", 10, 0, nil)
-	}
-
-	var response nps.CampgroundData
-	if err := json.Unmarshal([]byte(cachedData.APIData), &response); err != nil {
-		return ps.npsApi.GetCampgrounds([]string{parkCode}, nil, "", 10, 0, nil)
-	}
-
-	return &response, nil
+func (ps *ParkService) GetParkCampgrounds(ctx context.Context, parkCode string) (*nps.CampgroundData, error) {
+	return ps.campgrounds.Get(ctx, parkCode)
 }
 
 // GetAllActivities fetches all available activities from the NPS API
-func (ps *ParkService) GetAllActivities() (*nps.ActivityResponse, error) {
-	return ps.npsApi.GetActivities("", "", 500, 0, "name")
+func (ps *ParkService) GetAllActivities(ctx context.Context) (*nps.ActivityResponse, error) {
+	return ps.api(ctx).GetActivities("", "", 500, 0, "name")
 }
 
 // GetEventsWithDateRange fetches events with optional date filtering
-func (ps *ParkService) GetEventsWithDateRange(parkCode, stateCode, dateStart, dateEnd string, pageSize, pageNumber int) (*nps.EventResponse, error) {
+func (ps *ParkService) GetEventsWithDateRange(ctx context.Context, parkCode, stateCode, dateStart, dateEnd string, pageSize, pageNumber int) (*nps.EventResponse, error) {
 	var parkCodes []string
 	var stateCodes []string
 
@@ -822,7 +492,7 @@ func (ps *ParkService) GetEventsWithDateRange(parkCode, stateCode, dateStart, da
 		dateStart = today
 	}
 
-	return ps.npsApi.GetEvents(
+	return ps.api(ctx).GetEvents(
 		parkCodes,  // parkCode
 		stateCodes, // stateCode
 		nil,        // organization
@@ -843,7 +513,7 @@ func (ps *ParkService) GetEventsWithDateRange(parkCode, stateCode, dateStart, da
 }
 
 // SearchEvents searches for events with filters
-func (ps *ParkService) SearchEvents(query, parkCode, stateCode, eventType, dateStart, dateEnd string, limit, start int) (*nps.EventResponse, error) {
+func (ps *ParkService) SearchEvents(ctx context.Context, query, parkCode, stateCode, eventType, dateStart, dateEnd string, limit, start int) (*nps.EventResponse, error) {
 	var parkCodes []string
 	var stateCodes []string
 	var eventTypes []string
@@ -865,37 +535,60 @@ func (ps *ParkService) SearchEvents(query, parkCode, stateCode, eventType, dateS
 	}
 
 	pageSize := limit
-	if pageSize == 0 {
+	if pageSize <= 0 {
 		pageSize = 50
 	}
 
-	pageNumber := start / pageSize
-
-	return ps.npsApi.GetEvents(
-		parkCodes,  // parkCode
-		stateCodes, // stateCode
-		nil,        // organization
-		nil,        // subject
-		nil,        // portal
-		nil,        // tagsAll
-		nil,        // tagsOne
-		nil,        // tagsNone
-		dateStart,  // dateStart
-		dateEnd,    // dateEnd
-		eventTypes, // eventType
-		"",         // id
-		query,      // q
-		pageSize,   // pageSize
-		pageNumber, // pageNumber
-		true,       // expandRecurring
-	)
+	fetchPage := func(pageNumber int) (*nps.EventResponse, error) {
+		return ps.api(ctx).GetEvents(
+			parkCodes,  // parkCode
+			stateCodes, // stateCode
+			nil,        // organization
+			nil,        // subject
+			nil,        // portal
+			nil,        // tagsAll
+			nil,        // tagsOne
+			nil,        // tagsNone
+			dateStart,  // dateStart
+			dateEnd,    // dateEnd
+			eventTypes, // eventType
+			"",         // id
+			query,      // q
+			pageSize,   // pageSize
+			pageNumber, // pageNumber
+			true,       // expandRecurring
+		)
+	}
+
+	// The NPS API numbers event pages from 1. An offset that isn't a multiple of the page size
+	// falls inside a page, so that page and the next are fetched and sliced to start exactly at it.
+	start = max(start, 0)
+	pageNumber := start/pageSize + 1
+	skip := start % pageSize
+	response, err := fetchPage(pageNumber)
+	if err != nil || skip == 0 {
+		return response, err
+	}
+	events := response.Data[min(skip, len(response.Data)):]
+	if len(response.Data) == pageSize {
+		next, err := fetchPage(pageNumber + 1)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events[:len(events):len(events)], next.Data...)
+	}
+	response.Data = events[:min(pageSize, len(events))]
+	return response, nil
 }
 
+// errEventNotFound is returned by GetEventByID when the NPS API has no event with the ID
+var errEventNotFound = errors.New("event not found")
+
 // GetEventByID searches for a specific event by ID
-func (ps *ParkService) GetEventByID(eventID string) (*nps.Event, error) {
+func (ps *ParkService) GetEventByID(ctx context.Context, eventID string) (*nps.Event, error) {
 	// Since NPS API doesn't have a direct endpoint for individual events,
 	// we search with the ID parameter
-	response, err := ps.npsApi.GetEvents(
+	response, err := ps.api(ctx).GetEvents(
 		nil,     // parkCode
 		nil,     // stateCode
 		nil,     // organization
@@ -910,7 +603,7 @@ func (ps *ParkService) GetEventByID(eventID string) (*nps.Event, error) {
 		eventID, // id
 		"",      // q
 		1,       // pageSize
-		0,       // pageNumber
+		1,       // pageNumber
 		true,    // expandRecurring
 	)
 
@@ -919,23 +612,23 @@ func (ps *ParkService) GetEventByID(eventID string) (*nps.Event, error) {
 	}
 
 	if len(response.Data) == 0 {
-		return nil, fmt.Errorf("event not found")
+		return nil, errEventNotFound
 	}
 
 	return &response.Data[0], nil
 }
 
 // SearchThingsToDo searches for things to do with filters
-func (ps *ParkService) SearchThingsToDo(activityId, parkCode, stateCode, query string, limit, start int) (*nps.ThingsToDoResponse, error) {
+func (ps *ParkService) SearchThingsToDo(ctx context.Context, activityId, parkCode, stateCode, query string, limit, start int) (*nps.ThingsToDoResponse, error) {
 	// If no specific filters are provided, get general things to do
 	if activityId == "" && parkCode == "" && stateCode == "" && query == "" {
 		// Get some popular things to do by default
-		return ps.npsApi.GetThingsToDo("", "", "", "", limit, start, nil)
+		return ps.api(ctx).GetThingsToDo("", "", "", "", limit, start, nil)
 	}
 
 	// Get things to do without activity filter first
 	// The NPS API doesn't support direct activity filtering, so we'll filter client-side
-	response, err := ps.npsApi.GetThingsToDo("", parkCode, stateCode, query, limit*2, start, nil)
+	response, err := ps.api(ctx).GetThingsToDo("", parkCode, stateCode, query, limit*2, start, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -973,7 +666,7 @@ func (ps *ParkService) SearchThingsToDo(activityId, parkCode, stateCode, query s
 }
 
 // SearchCampgrounds searches for campgrounds with filters
-func (ps *ParkService) SearchCampgrounds(query, parkCode, stateCode string, limit, start int) (*nps.CampgroundData, error) {
+func (ps *ParkService) SearchCampgrounds(ctx context.Context, query, parkCode, stateCode string, limit, start int) (*nps.CampgroundData, error) {
 	var parkCodes []string
 	var stateCodes []string
 
@@ -985,11 +678,11 @@ func (ps *ParkService) SearchCampgrounds(query, parkCode, stateCode string, limi
 	}
 
 	// Get campgrounds with optional filtering by park and state
-	return ps.npsApi.GetCampgrounds(parkCodes, stateCodes, query, limit, start, nil)
+	return ps.api(ctx).GetCampgrounds(parkCodes, stateCodes, query, limit, start, nil)
 }
 
 // SearchNews searches for news releases and articles with filters
-func (ps *ParkService) SearchNews(query, parkCode, stateCode, newsType string, limit, start int) (interface{}, error) {
+func (ps *ParkService) SearchNews(ctx context.Context, query, parkCode, stateCode, newsType string, limit, start int) (interface{}, error) {
 	var parkCodes []string
 	var stateCodes []string
 
@@ -1003,12 +696,12 @@ func (ps *ParkService) SearchNews(query, parkCode, stateCode, newsType string, l
 	// Based on newsType, return either news releases, articles, or alerts
 	switch newsType {
 	case "articles":
-		return ps.npsApi.GetArticles(parkCodes, stateCodes, query, limit, start)
+		return ps.api(ctx).GetArticles(parkCodes, stateCodes, query, limit, start)
 	case "alerts":
-		return ps.npsApi.GetAlerts(parkCodes, stateCodes, query, limit, start)
+		return ps.api(ctx).GetAlerts(parkCodes, stateCodes, query, limit, start)
 	default:
 		// Default to news releases
-		return ps.npsApi.GetNewsReleases(parkCodes, stateCodes, query, limit, start, nil)
+		return ps.api(ctx).GetNewsReleases(parkCodes, stateCodes, query, limit, start, nil)
 	}
 }
 
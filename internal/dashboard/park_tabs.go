@@ -0,0 +1,186 @@
+package dashboard
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/ztkent/go-nps"
+	"golang.org/x/sync/errgroup"
+)
+
+// Each park page tab is gathered by one of the loaders below, for both its HTML handler and the
+// v1 API. A tab's sections load concurrently and independently, a section that fails to load is
+// nil and listed in the tab's Unavailable sections so it can be shown as unavailable rather than
+// empty.
+
+// maxParallelFetches bounds how many sections of a tab, or galleries of the media tab, load at
+// once. It covers the largest tab, so a cold tab loads in about one NPS round trip.
+const maxParallelFetches = 6
+
+// UnavailableSections lists the sections of a park tab that failed to load, by JSON name
+type UnavailableSections []string
+
+// Has reports whether section failed to load
+func (u UnavailableSections) Has(section string) bool {
+	return slices.Contains(u, section)
+}
+
+// ParkOverview is the data behind the park overview tab
+type ParkOverview struct {
+	ParkCode       string                     `json:"park_code"`
+	ThingsToDo     *nps.ThingsToDoResponse    `json:"things_to_do"`
+	Activities     *nps.ActivityResponse      `json:"activities"`
+	VisitorCenters *nps.VisitorCenterResponse `json:"visitor_centers"`
+	Amenities      *nps.AmenityResponse       `json:"amenities"`
+	ParkTours      *nps.TourResponse          `json:"tours"`
+	ParkEvents     *nps.EventResponse         `json:"events"`
+	Unavailable    UnavailableSections        `json:"unavailable"`
+}
+
+// ParkActivities is the data behind the park activities tab
+type ParkActivities struct {
+	ParkCode    string                  `json:"park_code"`
+	ThingsToDo  *nps.ThingsToDoResponse `json:"things_to_do"`
+	Tours       *nps.TourResponse       `json:"tours"`
+	Events      *nps.EventResponse      `json:"events"`
+	Campgrounds *nps.CampgroundData     `json:"campgrounds"`
+	Activities  *nps.ActivityResponse   `json:"activities"`
+	Unavailable UnavailableSections     `json:"unavailable"`
+}
+
+// ParkMedia is the data behind the park media tab
+type ParkMedia struct {
+	ParkCode    string                           `json:"park_code"`
+	Galleries   *nps.MultimediaGalleriesResponse `json:"galleries"`
+	Videos      *nps.MultimediaVideosResponse    `json:"videos"`
+	Audio       *nps.MultimediaAudioResponse     `json:"audio"`
+	Webcams     *nps.WebcamResponse              `json:"webcams"`
+	Unavailable UnavailableSections              `json:"unavailable"`
+}
+
+// ParkNews is the data behind the park news tab
+type ParkNews struct {
+	ParkCode     string                   `json:"park_code"`
+	NewsReleases *nps.NewsReleaseResponse `json:"news_releases"`
+	Articles     *nps.ArticleData         `json:"articles"`
+	Alerts       *nps.AlertResponse       `json:"alerts"`
+	Events       *nps.EventResponse       `json:"events"`
+	Unavailable  UnavailableSections      `json:"unavailable"`
+}
+
+// ParkDetails is the data behind the park details tab
+type ParkDetails struct {
+	ParkCode       string                     `json:"park_code"`
+	VisitorCenters *nps.VisitorCenterResponse `json:"visitor_centers"`
+	Campgrounds    *nps.CampgroundData        `json:"campgrounds"`
+	Fees           *nps.FeePassResponse       `json:"fees"`
+	Parking        *nps.ParkinglotResponse    `json:"parking"`
+	Unavailable    UnavailableSections        `json:"unavailable"`
+}
+
+// tabLoader loads the sections of a park tab concurrently, recording those that fail
+type tabLoader struct {
+	ps       *ParkService
+	ctx      context.Context
+	parkCode string
+	group    errgroup.Group
+
+	mu          sync.Mutex
+	unavailable UnavailableSections
+}
+
+func (ps *ParkService) newTabLoader(ctx context.Context, parkCode string) *tabLoader {
+	l := &tabLoader{ps: ps, ctx: ctx, parkCode: parkCode}
+	l.group.SetLimit(maxParallelFetches)
+	return l
+}
+
+// loadSection starts loading a section into dst, marking it unavailable if get fails
+func loadSection[T any](l *tabLoader, section string, dst **T, get func(ctx context.Context, parkCode string) (*T, error)) {
+	l.group.Go(func() error {
+		data, err := get(l.ctx, l.parkCode)
+		if err != nil {
+			l.ps.logger.WarnContext(l.ctx, "Park tab section unavailable", "park", l.parkCode, "section", section, "error", err)
+			l.mu.Lock()
+			l.unavailable = append(l.unavailable, section)
+			l.mu.Unlock()
+			return nil
+		}
+		*dst = data
+		return nil
+	})
+}
+
+// wait blocks until every section has loaded, returning those that failed in a stable order
+func (l *tabLoader) wait() UnavailableSections {
+	l.group.Wait()
+	if l.unavailable == nil {
+		return UnavailableSections{}
+	}
+	slices.Sort(l.unavailable)
+	return l.unavailable
+}
+
+// GetParkOverview loads the park overview tab
+func (ps *ParkService) GetParkOverview(ctx context.Context, parkCode string) *ParkOverview {
+	overview := &ParkOverview{ParkCode: parkCode}
+	l := ps.newTabLoader(ctx, parkCode)
+	loadSection(l, "things_to_do", &overview.ThingsToDo, ps.GetParkThingsToDo)
+	loadSection(l, "activities", &overview.Activities, ps.GetParkActivities)
+	loadSection(l, "visitor_centers", &overview.VisitorCenters, ps.GetParkVisitorCenters)
+	loadSection(l, "amenities", &overview.Amenities, ps.GetParkAmenities)
+	loadSection(l, "tours", &overview.ParkTours, ps.GetParkTours)
+	loadSection(l, "events", &overview.ParkEvents, ps.GetParkEvents)
+	overview.Unavailable = l.wait()
+	return overview
+}
+
+// GetParkActivitiesTab loads the park activities tab
+func (ps *ParkService) GetParkActivitiesTab(ctx context.Context, parkCode string) *ParkActivities {
+	activities := &ParkActivities{ParkCode: parkCode}
+	l := ps.newTabLoader(ctx, parkCode)
+	loadSection(l, "things_to_do", &activities.ThingsToDo, ps.GetParkThingsToDo)
+	loadSection(l, "tours", &activities.Tours, ps.GetParkTours)
+	loadSection(l, "events", &activities.Events, ps.GetParkEvents)
+	loadSection(l, "campgrounds", &activities.Campgrounds, ps.GetParkCampgrounds)
+	loadSection(l, "activities", &activities.Activities, ps.GetParkActivities)
+	activities.Unavailable = l.wait()
+	return activities
+}
+
+// GetParkMedia loads the park media tab
+func (ps *ParkService) GetParkMedia(ctx context.Context, parkCode string) *ParkMedia {
+	media := &ParkMedia{ParkCode: parkCode}
+	l := ps.newTabLoader(ctx, parkCode)
+	loadSection(l, "galleries", &media.Galleries, ps.GetParkMultimediaGalleries)
+	loadSection(l, "videos", &media.Videos, ps.GetParkMultimediaVideos)
+	loadSection(l, "audio", &media.Audio, ps.GetParkMultimediaAudio)
+	loadSection(l, "webcams", &media.Webcams, ps.GetParkWebcams)
+	media.Unavailable = l.wait()
+	return media
+}
+
+// GetParkNews loads the park news tab
+func (ps *ParkService) GetParkNews(ctx context.Context, parkCode string) *ParkNews {
+	news := &ParkNews{ParkCode: parkCode}
+	l := ps.newTabLoader(ctx, parkCode)
+	loadSection(l, "news_releases", &news.NewsReleases, ps.GetParkNewsReleases)
+	loadSection(l, "articles", &news.Articles, ps.GetParkArticles)
+	loadSection(l, "alerts", &news.Alerts, ps.GetParkAlerts)
+	loadSection(l, "events", &news.Events, ps.GetParkEvents)
+	news.Unavailable = l.wait()
+	return news
+}
+
+// GetParkDetails loads the park details tab
+func (ps *ParkService) GetParkDetails(ctx context.Context, parkCode string) *ParkDetails {
+	details := &ParkDetails{ParkCode: parkCode}
+	l := ps.newTabLoader(ctx, parkCode)
+	loadSection(l, "visitor_centers", &details.VisitorCenters, ps.GetParkVisitorCenters)
+	loadSection(l, "campgrounds", &details.Campgrounds, ps.GetParkCampgrounds)
+	loadSection(l, "fees", &details.Fees, ps.GetParkFees)
+	loadSection(l, "parking", &details.Parking, ps.GetParkParking)
+	details.Unavailable = l.wait()
+	return details
+}
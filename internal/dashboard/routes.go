@@ -1,16 +1,16 @@
 package dashboard
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"html"
-	"html/template"
-	"io"
-	"log"
+	"image"
+	"io/fs"
+	"math"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,6 +20,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/ztkent/go-nps"
 	"github.com/ztkent/parks-explorer/internal/database"
+	"github.com/ztkent/parks-explorer/internal/metrics"
 )
 
 // UnifiedNewsItem represents a normalized news item that can handle NewsRelease, Article, and Alert types
@@ -31,7 +32,8 @@ type UnifiedNewsItem struct {
 	ReleaseDate  string               `json:"releaseDate,omitempty"`
 	Image        *UnifiedImageInfo    `json:"image,omitempty"`
 	RelatedParks []UnifiedRelatedPark `json:"relatedParks,omitempty"`
-	Category     string               `json:"category,omitempty"` // For alerts
+	Category     string               `json:"category,omitempty"`    // For alerts
+	UpdatedDate  string               `json:"updatedDate,omitempty"` // For alerts, when NPS last indexed them
 }
 
 // UnifiedImageInfo represents normalized image information
@@ -166,6 +168,7 @@ func normalizeAlertData(data *nps.AlertResponse) *UnifiedNewsData {
 			URL:         item.URL,
 			Description: item.Description,
 			Category:    item.Category,
+			UpdatedDate: item.LastIndexedDate,
 		}
 
 		// Alerts don't have images or related parks in the current structure
@@ -175,10 +178,32 @@ func normalizeAlertData(data *nps.AlertResponse) *UnifiedNewsData {
 	return unified
 }
 
+// normalizeNewsData converts a SearchNews response of the given news type to UnifiedNewsData,
+// returning nil when the response doesn't match the type
+func normalizeNewsData(newsType string, newsData interface{}) *UnifiedNewsData {
+	switch newsType {
+	case "articles":
+		if articleData, ok := newsData.(*nps.ArticleData); ok {
+			return normalizeArticleData(articleData)
+		}
+	case "alerts":
+		if alertData, ok := newsData.(*nps.AlertResponse); ok {
+			return normalizeAlertData(alertData)
+		}
+	default:
+		// Default to news releases
+		if newsReleaseData, ok := newsData.(*nps.NewsReleaseResponse); ok {
+			return normalizeNewsReleaseData(newsReleaseData)
+		}
+	}
+	return nil
+}
+
 // ParkPageData represents the data structure for the park page template
 type ParkPageData struct {
 	Name        string
 	Code        string
+	Slug        string
 	ImageURL    string
 	Description string
 	States      string
@@ -190,7 +215,7 @@ type ParkPageData struct {
 func (dm *Dashboard) HomeHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Serve the static HTML file
-		http.ServeFile(w, r, "web/static/index.html")
+		http.ServeFileFS(w, r, dm.web, "static/index.html")
 	}
 }
 
@@ -199,31 +224,35 @@ func (dm *Dashboard) StaticFileHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract the file path from URL
 		filePath := r.URL.Path[len("/static/"):]
-		fullPath := filepath.Join("web/static", filePath)
+		if !fs.ValidPath(filePath) {
+			http.NotFound(w, r)
+			return
+		}
+		fullPath := path.Join("static", filePath)
 
 		// Check if file exists
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		if _, err := fs.Stat(dm.web, fullPath); err != nil {
 			http.NotFound(w, r)
 			return
 		}
 
-		http.ServeFile(w, r, fullPath)
+		http.ServeFileFS(w, r, dm.web, fullPath)
 	}
 }
 
 // TopLevelStaticFileHandler serves specific static files at the top level
 func (dm *Dashboard) TopLevelStaticFileHandler(filename string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		fullPath := filepath.Join("web/static", filename)
+		fullPath := path.Join("static", filename)
 
 		// Check if file exists
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		if _, err := fs.Stat(dm.web, fullPath); err != nil {
 			http.NotFound(w, r)
 			return
 		}
 
 		// Set appropriate content type based on file extension
-		switch filepath.Ext(filename) {
+		switch path.Ext(filename) {
 		case ".xml":
 			w.Header().Set("Content-Type", "application/xml")
 		case ".txt":
@@ -232,7 +261,7 @@ func (dm *Dashboard) TopLevelStaticFileHandler(filename string) http.HandlerFunc
 			w.Header().Set("Content-Type", "application/manifest+json")
 		}
 
-		http.ServeFile(w, r, fullPath)
+		http.ServeFileFS(w, r, dm.web, fullPath)
 	}
 }
 
@@ -269,9 +298,9 @@ type ParkList struct {
 
 func (dm *Dashboard) ParkListHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		parks, err := dm.parkService.GetAllParks()
+		parks, err := dm.parkService.GetAllParks(r.Context())
 		if err != nil {
-			log.Printf("Failed to get all parks: %v", err)
+			dm.logger.ErrorContext(r.Context(), "Failed to get all parks", "error", err)
 			http.Error(w, fmt.Sprintf("Failed to get parks: %v", err), http.StatusInternalServerError)
 			return
 		}
@@ -300,7 +329,7 @@ func (dm *Dashboard) EnsureUUIDHandler() http.HandlerFunc {
 			})
 		} else if err != nil {
 			// Some other error occurred
-			log.Printf("Failed to read cookie: %v", err)
+			dm.logger.ErrorContext(r.Context(), "Failed to read cookie", "error", err)
 			http.Error(w, fmt.Sprintf("Failed to read cookie: %v", err), http.StatusInternalServerError)
 		}
 	}
@@ -320,20 +349,17 @@ func (dm *Dashboard) AvatarProxyHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Fetch the image from Google
-	resp, err := http.Get(user.AvatarURL)
+	data, contentType, err := dm.avatarFetcher.Fetch(r.Context(), user.AvatarURL)
 	if err != nil {
-		log.Printf("Failed to fetch avatar from %s: %v", user.AvatarURL, err)
-		http.Error(w, fmt.Sprintf("Failed to fetch avatar: %v", err), http.StatusInternalServerError)
+		dm.logger.WarnContext(r.Context(), "Failed to fetch avatar", "url", user.AvatarURL, "error", err)
+		writeFetchError(w, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	// Copy headers
-	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Cache-Control", "public, max-age=3600") // Cache for 1 hour
-
-	// Copy the image data
-	io.Copy(w, resp.Body)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
 }
 
 // ImageProxyHandler serves images through the server to prevent direct external access
@@ -345,93 +371,86 @@ func (dm *Dashboard) ImageProxyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate that this is an acceptable image source
-	if !dm.isValidImageSource(imageURL) {
-		log.Printf("Blocked potentially unsafe image URL: %s", imageURL)
-		http.Error(w, "Invalid image source", http.StatusForbidden)
+	if _, err := dm.imageFetcher.checkURL(imageURL); err != nil {
+		dm.logger.WarnContext(r.Context(), "Blocked potentially unsafe image URL", "url", imageURL, "error", err)
+		writeFetchError(w, err)
 		return
 	}
 
-	// Create HTTP client with timeout for security
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// Optional w, h and fit parameters ask for a resized copy
+	size, err := parseImageSize(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var cacheKey string
+	if !size.isZero() && dm.images != nil {
+		cacheKey = imageCacheKey(imageURL, size)
+		if data, contentType, ok := dm.images.Get(cacheKey); ok {
+			writeProxiedImage(w, "cache", contentType, data)
+			return
+		}
 	}
 
 	// Fetch the image from the external source
-	resp, err := client.Get(imageURL)
+	data, contentType, err := dm.imageFetcher.Fetch(r.Context(), imageURL)
 	if err != nil {
-		log.Printf("Failed to fetch image from %s: %v", imageURL, err)
-		http.Error(w, "Failed to fetch image", http.StatusInternalServerError)
+		dm.logger.WarnContext(r.Context(), "Failed to fetch image", "url", imageURL, "error", err)
+		writeFetchError(w, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	// Validate response status
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Image fetch returned status %d for URL: %s", resp.StatusCode, imageURL)
-		http.Error(w, "Image not found", http.StatusNotFound)
+	if !size.isZero() {
+		dm.serveResizedImage(w, r, data, imageURL, contentType, size, cacheKey)
 		return
 	}
+	writeProxiedImage(w, "original", contentType, data)
+}
 
-	// Validate content type is an image
-	contentType := resp.Header.Get("Content-Type")
-	if !dm.isValidImageContentType(contentType) {
-		log.Printf("Invalid content type %s for URL: %s", contentType, imageURL)
-		http.Error(w, "Invalid content type", http.StatusUnsupportedMediaType)
+// serveResizedImage resizes a fetched image and stores it in the image cache. Images the
+// proxy can't resize, like GIFs, are served unchanged, and images over maxSourceImagePixels
+// are refused.
+func (dm *Dashboard) serveResizedImage(w http.ResponseWriter, r *http.Request, original []byte, imageURL, contentType string, size imageSize, cacheKey string) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil || !resizableImageFormat(format) {
+		writeProxiedImage(w, "original", contentType, original)
 		return
 	}
-
-	// Set security headers
-	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Cache-Control", "public, max-age=3600") // Cache for 1 hour
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-
-	// Copy the image data
-	_, err = io.Copy(w, resp.Body)
+	// The header is enough to refuse a decompression bomb before decoding allocates for it
+	if config.Width*config.Height > maxSourceImagePixels {
+		dm.logger.WarnContext(r.Context(), "Refused to resize oversized image", "url", imageURL, "width", config.Width, "height", config.Height)
+		http.Error(w, "Image is too large to resize", http.StatusUnprocessableEntity)
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(original))
 	if err != nil {
-		log.Printf("Failed to copy image data for URL %s: %v", imageURL, err)
+		dm.logger.WarnContext(r.Context(), "Failed to decode image", "url", imageURL, "error", err)
+		writeProxiedImage(w, "original", contentType, original)
+		return
 	}
-}
 
-// isValidImageSource validates that the image URL is from an acceptable source
-func (dm *Dashboard) isValidImageSource(imageURL string) bool {
-	// Allow common image hosting services and NPS domains
-	allowedDomains := []string{
-		"www.nps.gov",
-		"nps.gov",
-		"images.unsplash.com",
-		"via.placeholder.com",
-		// Add other trusted domains as needed
+	resized, resizedType, err := encodeImage(resizeImage(img, size))
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to resize image", "url", imageURL, "error", err)
+		writeProxiedImage(w, "original", contentType, original)
+		return
 	}
-
-	for _, domain := range allowedDomains {
-		if strings.Contains(imageURL, domain) {
-			return true
+	if cacheKey != "" {
+		if err := dm.images.Put(cacheKey, resizedType, resized); err != nil {
+			dm.logger.WarnContext(r.Context(), "Failed to cache resized image", "url", imageURL, "error", err)
 		}
 	}
-
-	// Log potentially suspicious URLs for monitoring
-	log.Printf("Image URL from non-whitelisted domain: %s", imageURL)
-	return false
+	writeProxiedImage(w, "resized", resizedType, resized)
 }
 
-// isValidImageContentType validates that the content type is a supported image format
-func (dm *Dashboard) isValidImageContentType(contentType string) bool {
-	validTypes := []string{
-		"image/jpeg",
-		"image/jpg",
-		"image/png",
-		"image/gif",
-		"image/webp",
-		"image/svg+xml",
-	}
-
-	for _, validType := range validTypes {
-		if strings.Contains(strings.ToLower(contentType), validType) {
-			return true
-		}
-	}
-
-	return false
+// writeProxiedImage writes an image with the proxy's caching and security headers. source
+// is where the image came from, "original", "resized" or "cache", for metrics.
+func writeProxiedImage(w http.ResponseWriter, source, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400") // Cache for 1 day
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
+	metrics.AddImageProxyServedBytes(source, len(data))
 }
 
 // proxyImageURL converts a direct image URL to a proxied one for security
@@ -491,7 +510,7 @@ func (dm *Dashboard) AuthStatusHandler(w http.ResponseWriter, r *http.Request) {
 
 // FeaturedParksHandler returns HTML for featured parks
 func (dm *Dashboard) FeaturedParksHandler(w http.ResponseWriter, r *http.Request) {
-	parks, err := dm.parkService.GetFeaturedParks()
+	parks, err := dm.parkService.GetFeaturedParks(r.Context())
 	if err != nil {
 		w.Header().Set("Content-Type", "text/html")
 		w.Write([]byte(`<div class="loading">Error loading parks</div>`))
@@ -507,37 +526,7 @@ func (dm *Dashboard) FeaturedParksHandler(w http.ResponseWriter, r *http.Request
 
 	var html strings.Builder
 	for _, park := range parks {
-		// Get the first available image or use a placeholder
-		imageUrl := ""
-		imageAlt := park.Name
-		if len(park.Images) > 0 {
-			imageUrl = park.Images[0].URL
-			if park.Images[0].AltText != "" {
-				imageAlt = park.Images[0].AltText
-			}
-		}
-
-		// Build the image HTML
-		imageHTML := `<div class="park-image"></div>`
-		if imageUrl != "" {
-			proxiedImageURL := proxyImageURL(imageUrl)
-			imageHTML = fmt.Sprintf(`<div class="park-image" style="background-image: url('%s');" title="%s"></div>`, proxiedImageURL, imageAlt)
-		}
-
-		html.WriteString(fmt.Sprintf(`
-			<div class="park-card" data-park="%s">
-				<a href="/parks/%s" class="park-card-link">
-					%s
-					<div class="park-content">
-						<h3 class="park-title">%s</h3>
-						<p class="park-description">
-							Explore the natural beauty and unique features of %s.
-						</p>
-						<div class="park-location">%s</div>
-					</div>
-				</a>
-			</div>
-		`, park.Slug, park.Slug, imageHTML, park.Name, park.Name, formatStatesDisplay(park.States)))
+		html.WriteString(parkCardHTML(park, formatStatesDisplay(park.States)))
 	}
 
 	w.Write([]byte(html.String()))
@@ -549,64 +538,232 @@ func (dm *Dashboard) ParkSearchHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html")
 
-	// If no query, show featured parks
-	if strings.TrimSpace(query) == "" {
+	// With the visitor's location, results are sorted by distance from them
+	lat, lng, hasLocation := parseLocation(r)
+	radius, hasRadius := parseRadius(r)
+
+	// If no query, show nearby parks when we know where the visitor is, otherwise featured parks
+	if strings.TrimSpace(query) == "" && !hasLocation {
 		dm.FeaturedParksHandler(w, r)
 		return
 	}
 
-	// Search parks using the park service
-	parks, err := dm.parkService.SearchParks(query)
-	if err != nil {
-		w.Write([]byte(`<div >Error searching parks</div>`))
-		return
+	var parks []database.NearbyPark
+	if strings.TrimSpace(query) == "" {
+		if !hasRadius {
+			radius = defaultNearbyRadius
+		}
+		nearby, err := dm.parkService.GetParksNear(r.Context(), lat, lng, radius, maxNearbyParks)
+		if err != nil {
+			w.Write([]byte(`<div >Error searching parks</div>`))
+			return
+		}
+		parks = nearby
+	} else {
+		// Search parks using the park service
+		results, err := dm.parkService.SearchParks(r.Context(), query)
+		if err != nil {
+			w.Write([]byte(`<div >Error searching parks</div>`))
+			return
+		}
+		parks = make([]database.NearbyPark, 0, len(results))
+		for _, park := range results {
+			parks = append(parks, database.NearbyPark{CachedPark: park, DistanceMiles: -1})
+		}
+		if hasLocation {
+			parks = sortByDistance(parks, lat, lng, radius, hasRadius)
+		}
 	}
 
 	if len(parks) == 0 {
+		message := `We couldn't find any national parks matching "<strong>` + html.EscapeString(query) + `</strong>". Try adjusting your search terms.`
+		if strings.TrimSpace(query) == "" || hasRadius {
+			message = fmt.Sprintf("We couldn't find any national parks within %.0f miles of you. Try a larger radius.", radius)
+		}
 		w.Write([]byte(`<div class="no-results">
 			<div class="no-results-icon">🏔️</div>
 			<h3 class="no-results-title">No parks found</h3>
-			<p class="no-results-message">We couldn't find any national parks matching "<strong>` + html.EscapeString(query) + `</strong>". Try adjusting your search terms.</p>
+			<p class="no-results-message">` + message + `</p>
 		</div>`))
 		return
 	}
 
 	var html strings.Builder
 	for _, park := range parks {
-		// Get the first available image or use a placeholder
-		imageUrl := ""
-		imageAlt := park.Name
+		location := formatStatesDisplay(park.States)
+		if park.DistanceMiles >= 0 {
+			location += " · " + formatDistance(park.DistanceMiles)
+		}
+		html.WriteString(parkCardHTML(park.CachedPark, location))
+	}
+
+	w.Write([]byte(html.String()))
+}
+
+const (
+	// defaultNearbyRadius is the search radius in miles when none is given
+	defaultNearbyRadius = 100.0
+	// maxNearbyRadius covers the continental US coast to coast
+	maxNearbyRadius = 3000.0
+	// maxNearbyParks caps how many parks a nearby search returns
+	maxNearbyParks = 100
+)
+
+// NearbyParksHandler returns the parks within a radius of a point as JSON, nearest first
+func (dm *Dashboard) NearbyParksHandler(w http.ResponseWriter, r *http.Request) {
+	lat, lng, ok := parseLocation(r)
+	if !ok {
+		http.Error(w, "Missing or invalid lat and lng", http.StatusBadRequest)
+		return
+	}
+	radius, ok := parseRadius(r)
+	if !ok {
+		if r.URL.Query().Get("radius") != "" {
+			http.Error(w, fmt.Sprintf("Invalid radius, expected miles between 0 and %.0f", maxNearbyRadius), http.StatusBadRequest)
+			return
+		}
+		radius = defaultNearbyRadius
+	}
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= maxNearbyParks {
+		limit = l
+	}
+
+	parks, err := dm.parkService.GetParksNear(r.Context(), lat, lng, radius, limit)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error finding nearby parks", "lat", lat, "lng", lng, "error", err)
+		http.Error(w, "Error finding nearby parks", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]map[string]interface{}, 0, len(parks))
+	for _, park := range parks {
+		imageURL := ""
 		if len(park.Images) > 0 {
-			imageUrl = park.Images[0].URL
-			if park.Images[0].AltText != "" {
-				imageAlt = park.Images[0].AltText
-			}
+			imageURL = proxyImageURL(park.Images[0].URL)
 		}
+		results = append(results, map[string]interface{}{
+			"park_code":      park.ParkCode,
+			"name":           park.Name,
+			"full_name":      park.FullName,
+			"slug":           park.Slug,
+			"states":         park.States,
+			"designation":    park.Designation,
+			"latitude":       park.Latitude,
+			"longitude":      park.Longitude,
+			"url":            "/parks/" + park.Slug,
+			"image_url":      imageURL,
+			"distance_miles": math.Round(park.DistanceMiles*10) / 10,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"lat":    lat,
+		"lng":    lng,
+		"radius": radius,
+		"parks":  results,
+	})
+}
 
-		// Build the image HTML
-		imageHTML := `<div class="park-image"></div>`
-		if imageUrl != "" {
-			proxiedImageURL := proxyImageURL(imageUrl)
-			imageHTML = fmt.Sprintf(`<div class="park-image" style="background-image: url('%s');" title="%s"></div>`, proxiedImageURL, imageAlt)
+// parseLocation reads the lat and lng query parameters
+func parseLocation(r *http.Request) (float64, float64, bool) {
+	lat, latErr := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(r.URL.Query().Get("lng"), 64)
+	if latErr != nil || lngErr != nil || !database.ValidCoordinates(lat, lng) {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+// parseRadius reads the radius query parameter, in miles
+func parseRadius(r *http.Request) (float64, bool) {
+	radius, err := strconv.ParseFloat(r.URL.Query().Get("radius"), 64)
+	if err != nil || radius <= 0 || radius > maxNearbyRadius {
+		return 0, false
+	}
+	return radius, true
+}
+
+// sortByDistance orders parks nearest first, dropping parks outside the radius when one is given.
+// Parks without coordinates sort last, and are dropped when filtering by radius.
+func sortByDistance(parks []database.NearbyPark, lat, lng, radius float64, hasRadius bool) []database.NearbyPark {
+	sorted := parks[:0]
+	var unplaced []database.NearbyPark
+	for _, park := range parks {
+		parkLat, parkLng, ok := park.Coordinates()
+		if !ok {
+			unplaced = append(unplaced, park)
+			continue
+		}
+		park.DistanceMiles = database.DistanceMiles(lat, lng, parkLat, parkLng)
+		if hasRadius && park.DistanceMiles > radius {
+			continue
 		}
+		sorted = append(sorted, park)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].DistanceMiles < sorted[j].DistanceMiles
+	})
+	if !hasRadius {
+		sorted = append(sorted, unplaced...)
+	}
+	return sorted
+}
 
-		html.WriteString(fmt.Sprintf(`
-			<div class="park-card" data-park="%s">
-				<a href="/parks/%s" class="park-card-link">
-					%s
-					<div class="park-content">
-						<h3 class="park-title">%s</h3>
-						<p class="park-description">
-							Explore the natural beauty and unique features of %s.
-						</p>
-						<div class="park-location">%s</div>
-					</div>
-				</a>
-			</div>
-		`, park.Slug, park.Slug, imageHTML, park.Name, park.Name, formatStatesDisplay(park.States)))
+// formatDistance formats a distance in miles for display
+func formatDistance(miles float64) string {
+	if miles < 10 {
+		return fmt.Sprintf("%.1f mi away", miles)
 	}
+	return fmt.Sprintf("%.0f mi away", miles)
+}
 
-	w.Write([]byte(html.String()))
+// SearchHandler returns ranked search results across parks, things to do, events and news as JSON
+func (dm *Dashboard) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+
+	var entityTypes []string
+	if types := r.URL.Query().Get("type"); types != "" {
+		for _, entityType := range strings.Split(types, ",") {
+			if entityType = strings.TrimSpace(entityType); entityType != "" {
+				entityTypes = append(entityTypes, entityType)
+			}
+		}
+	}
+
+	limit := 20
+	offset := 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	results, err := dm.parkService.Search(r.Context(), query, entityTypes, limit, offset)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Error searching", "query", query, "error", err)
+		http.Error(w, "Error searching", http.StatusInternalServerError)
+		return
+	}
+
+	// Route images through the proxy, like every other page
+	for i := range results {
+		results[i].ImageURL = proxyImageURL(results[i].ImageURL)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":   query,
+		"results": results,
+		"limit":   limit,
+		"offset":  offset,
+	})
 }
 
 // ParkPageHandler returns a full HTML page for a specific park
@@ -614,7 +771,7 @@ func (dm *Dashboard) ParkPageHandler(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
 	// Get park from cache
-	park, err := dm.parkService.GetParkBySlug(slug)
+	park, err := dm.parkService.GetParkBySlug(r.Context(), slug)
 	if err != nil {
 		http.NotFound(w, r)
 		return
@@ -637,6 +794,7 @@ func (dm *Dashboard) ParkPageHandler(w http.ResponseWriter, r *http.Request) {
 	data := ParkPageData{
 		Name:        park.Name,
 		Code:        park.ParkCode,
+		Slug:        park.Slug,
 		ImageURL:    imageUrl,
 		Description: description,
 		States:      park.States,
@@ -644,17 +802,17 @@ func (dm *Dashboard) ParkPageHandler(w http.ResponseWriter, r *http.Request) {
 		URL:         park.URL,
 	}
 
-	// Parse and execute the template from file
-	tmpl, err := template.ParseFiles("web/templates/park.html")
+	// Render the park page template
+	tmpl, err := dm.templates.Lookup("park.html")
 	if err != nil {
-		log.Printf("Failed to load park page template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load park page template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load park page template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render park page for %s: %v", park.Name, err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render park page", "park", park.ParkCode, "error", err)
 		http.Error(w, fmt.Sprintf("Failed to render park page: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -675,28 +833,28 @@ func (dm *Dashboard) TemplateHandler(w http.ResponseWriter, r *http.Request) {
 			"CurrentPage": currentPage,
 		}
 
-		tmpl, err := template.ParseFiles("web/templates/header.html")
+		tmpl, err := dm.templates.Lookup("header.html")
 		if err != nil {
-			log.Printf("Failed to load header template: %v", err)
+			dm.logger.ErrorContext(r.Context(), "Failed to load header template", "error", err)
 			http.Error(w, fmt.Sprintf("Failed to load header template: %v", err), http.StatusInternalServerError)
 			return
 		}
 		err = tmpl.Execute(w, data)
 		if err != nil {
-			log.Printf("Failed to render header template: %v", err)
+			dm.logger.ErrorContext(r.Context(), "Failed to render header template", "error", err)
 			http.Error(w, fmt.Sprintf("Failed to render header template: %v", err), http.StatusInternalServerError)
 			return
 		}
 	case "footer":
-		tmpl, err := template.ParseFiles("web/templates/footer.html")
+		tmpl, err := dm.templates.Lookup("footer.html")
 		if err != nil {
-			log.Printf("Failed to load footer template: %v", err)
+			dm.logger.ErrorContext(r.Context(), "Failed to load footer template", "error", err)
 			http.Error(w, fmt.Sprintf("Failed to load footer template: %v", err), http.StatusInternalServerError)
 			return
 		}
 		err = tmpl.Execute(w, nil)
 		if err != nil {
-			log.Printf("Failed to render footer template: %v", err)
+			dm.logger.ErrorContext(r.Context(), "Failed to render footer template", "error", err)
 			http.Error(w, fmt.Sprintf("Failed to render footer template: %v", err), http.StatusInternalServerError)
 			return
 		}
@@ -760,7 +918,7 @@ func (dm *Dashboard) ParksHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	parks, err := dm.parkService.GetParksWithPagination(offset, limit)
+	parks, err := dm.parkService.GetParksWithPagination(r.Context(), offset, limit)
 	if err != nil {
 		w.Header().Set("Content-Type", "text/html")
 		w.Write([]byte(`<div class="loading">Error loading parks</div>`))
@@ -776,25 +934,35 @@ func (dm *Dashboard) ParksHandler(w http.ResponseWriter, r *http.Request) {
 
 	var html strings.Builder
 	for _, park := range parks {
-		// Get the first available image or use a placeholder
-		imageUrl := ""
-		imageAlt := park.Name
-		if len(park.Images) > 0 {
-			imageUrl = park.Images[0].URL
-			if park.Images[0].AltText != "" {
-				imageAlt = park.Images[0].AltText
-			}
-		}
+		html.WriteString(parkCardHTML(park, formatStatesDisplay(park.States)))
+	}
+
+	w.Write([]byte(html.String()))
+}
 
-		// Build the image HTML
-		imageHTML := `<div class="park-image"></div>`
-		if imageUrl != "" {
-			proxiedImageURL := proxyImageURL(imageUrl)
-			imageHTML = fmt.Sprintf(`<div class="park-image" style="background-image: url('%s');" title="%s"></div>`, proxiedImageURL, imageAlt)
+// parkCardHTML renders a park card for the parks grid. The favorite toggle stays hidden until
+// script.js knows the visitor is signed in, so the card is the same for everyone and safe to cache.
+func parkCardHTML(park database.CachedPark, location string) string {
+	// Get the first available image or use a placeholder
+	imageUrl := ""
+	imageAlt := park.Name
+	if len(park.Images) > 0 {
+		imageUrl = park.Images[0].URL
+		if park.Images[0].AltText != "" {
+			imageAlt = park.Images[0].AltText
 		}
+	}
+
+	// Build the image HTML, letting the browser pick a thumbnail size for the card
+	imageHTML := `<div class="park-image"></div>`
+	if imageUrl != "" {
+		imageHTML = fmt.Sprintf(`<div class="park-image"><img src="%s" srcset="%s" sizes="(max-width: 768px) 100vw, 400px" alt="%s" loading="lazy"></div>`,
+			html.EscapeString(proxyImageURLSized(imageUrl, 640, 0, "")), html.EscapeString(proxyImageSrcset(imageUrl)), html.EscapeString(imageAlt))
+	}
 
-		html.WriteString(fmt.Sprintf(`
+	return fmt.Sprintf(`
 			<div class="park-card" data-park="%s">
+				%s
 				<a href="/parks/%s" class="park-card-link">
 					%s
 					<div class="park-content">
@@ -806,10 +974,7 @@ func (dm *Dashboard) ParksHandler(w http.ResponseWriter, r *http.Request) {
 					</div>
 				</a>
 			</div>
-		`, park.Slug, park.Slug, imageHTML, park.Name, park.Name, formatStatesDisplay(park.States)))
-	}
-
-	w.Write([]byte(html.String()))
+		`, park.Slug, favoriteButtonHTML(park.ParkCode, park.Name), park.Slug, imageHTML, park.Name, park.Name, location)
 }
 
 // formatStatesDisplay formats the states string to show only first 5 states when there are more than 5
@@ -845,47 +1010,30 @@ func (dm *Dashboard) ParkOverviewHandler(w http.ResponseWriter, r *http.Request)
 
 	w.Header().Set("Content-Type", "text/html")
 
-	thingsToDo, _ := dm.parkService.GetParkThingsToDo(parkCode)
-	activities, _ := dm.parkService.GetParkActivities(parkCode)
-	visitorCenters, _ := dm.parkService.GetParkVisitorCenters(parkCode)
-	amenities, _ := dm.parkService.GetParkAmenities(parkCode)
-	tours, _ := dm.parkService.GetParkTours(parkCode)
-	events, _ := dm.parkService.GetParkEvents(parkCode)
+	overview := dm.parkService.GetParkOverview(r.Context(), parkCode)
 	// Prepare data for the template with proper structure
 	data := map[string]interface{}{
-		"ThingsToDo":     thingsToDo,
-		"Activities":     activities,
-		"VisitorCenters": visitorCenters,
-		"Amenities":      amenities,
-		"ParkTours":      tours,
-		"ParkEvents":     events,
+		"ThingsToDo":     overview.ThingsToDo,
+		"Activities":     overview.Activities,
+		"VisitorCenters": overview.VisitorCenters,
+		"Amenities":      overview.Amenities,
+		"ParkTours":      overview.ParkTours,
+		"ParkEvents":     overview.ParkEvents,
+		"Unavailable":    overview.Unavailable,
 		"ParkCode":       parkCode,
 	}
 
-	// Parse and execute the overview template
-	tmpl, err := template.New("park-overview.html").Funcs(template.FuncMap{
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-		"formatEventDate": func(dateStr string) string {
-			if t, err := time.Parse("2006-01-02", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			return dateStr
-		},
-	}).ParseFiles("web/templates/partials/park-overview.html")
+	// Render the overview template
+	tmpl, err := dm.templates.Lookup("partials/park-overview.html")
 	if err != nil {
-		log.Printf("Failed to load overview template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load overview template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load overview template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render overview content for park %s: %v", parkCode, err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render overview content", "park", parkCode, "error", err)
 		http.Error(w, fmt.Sprintf("Failed to render overview content: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -902,37 +1050,30 @@ func (dm *Dashboard) ParkActivitiesHandler(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "text/html")
 
 	// Fetch comprehensive activity data with all available details
-	thingsToDo, _ := dm.parkService.GetParkThingsToDo(parkCode)
-	tours, _ := dm.parkService.GetParkTours(parkCode)
-	events, _ := dm.parkService.GetParkEvents(parkCode)
-	campgrounds, _ := dm.parkService.GetParkCampgrounds(parkCode)
-	activities, _ := dm.parkService.GetParkActivities(parkCode)
+	activities := dm.parkService.GetParkActivitiesTab(r.Context(), parkCode)
 
 	// Comprehensive data structure leveraging all available NPS API fields
 	data := map[string]interface{}{
-		"ThingsToDo":  thingsToDo,
-		"Tours":       tours,
-		"Events":      events,
-		"Campgrounds": campgrounds,
-		"Activities":  activities,
+		"ThingsToDo":  activities.ThingsToDo,
+		"Tours":       activities.Tours,
+		"Events":      activities.Events,
+		"Campgrounds": activities.Campgrounds,
+		"Activities":  activities.Activities,
+		"Unavailable": activities.Unavailable,
 		"ParkCode":    parkCode,
 	}
 
-	// Parse and execute the activities template
-	tmpl, err := template.New("activities").Funcs(template.FuncMap{
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-	}).ParseFiles("web/templates/partials/park-activities.html")
+	// Render the activities template
+	tmpl, err := dm.templates.Lookup("partials/park-activities.html")
 	if err != nil {
-		log.Printf("Failed to load activities template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load activities template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load activities template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render activities content for park %s: %v", parkCode, err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render activities content", "park", parkCode, "error", err)
 		http.Error(w, fmt.Sprintf("Failed to render activities content: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -948,72 +1089,29 @@ func (dm *Dashboard) ParkMediaHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html")
 
-	// 	mediaTypes := []string{"galleries", "videos", "audio", "webcams"}
-	galleries, _ := dm.parkService.GetParkMultimediaGalleries(parkCode)
-	videos, _ := dm.parkService.GetParkMultimediaVideos(parkCode)
-	audio, _ := dm.parkService.GetParkMultimediaAudio(parkCode)
-	webcams, _ := dm.parkService.GetParkWebcams(parkCode)
+	media := dm.parkService.GetParkMedia(r.Context(), parkCode)
 
 	// Comprehensive media data structure
 	data := map[string]interface{}{
-		"Galleries": galleries, // Comprehensive gallery data with detailed metadata
-		"Videos":    videos,    // Comprehensive video data with detailed metadata
-		"Audio":     audio,     // Additional audio content with detailed metadata
-		"Webcams":   webcams,   // Live webcam feeds with detailed metadata
-		"ParkCode":  parkCode,
-	}
-
-	// Parse and execute the media template
-	tmpl, err := template.New("park-media.html").Funcs(template.FuncMap{
-		"formatDuration": func(durationMs interface{}) string {
-			var ms int64
-			switch v := durationMs.(type) {
-			case int:
-				ms = int64(v)
-			case int64:
-				ms = v
-			case float64:
-				ms = int64(v)
-			default:
-				return "Unknown"
-			}
-
-			if ms <= 0 {
-				return "Unknown"
-			}
+		"Galleries":   media.Galleries, // Comprehensive gallery data with detailed metadata
+		"Videos":      media.Videos,    // Comprehensive video data with detailed metadata
+		"Audio":       media.Audio,     // Additional audio content with detailed metadata
+		"Webcams":     media.Webcams,   // Live webcam feeds with detailed metadata
+		"Unavailable": media.Unavailable,
+		"ParkCode":    parkCode,
+	}
 
-			seconds := ms / 1000
-			minutes := seconds / 60
-			hours := minutes / 60
-
-			if hours > 0 {
-				remainingMinutes := minutes % 60
-				return fmt.Sprintf("%d:%02d", hours, remainingMinutes)
-			} else if minutes > 0 {
-				return fmt.Sprintf("%d min", minutes)
-			} else {
-				return fmt.Sprintf("%d sec", seconds)
-			}
-		},
-		"add": func(a, b int) int {
-			return a + b
-		},
-		"sub": func(a, b int) int {
-			return a - b
-		},
-		"fullImageURL": func(url string) string {
-			return proxyImageURL(url)
-		},
-	}).ParseFiles("web/templates/partials/park-media.html")
+	// Render the media template
+	tmpl, err := dm.templates.Lookup("partials/park-media.html")
 	if err != nil {
-		log.Printf("Failed to load media template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load media template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load media template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render media content for park %s: %v", parkCode, err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render media content", "park", parkCode, "error", err)
 		http.Error(w, "Failed to render media content: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1028,37 +1126,28 @@ func (dm *Dashboard) ParkNewsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	newsReleases, _ := dm.parkService.GetParkNewsReleases(parkCode)
-	articles, _ := dm.parkService.GetParkArticles(parkCode)
-	alerts, _ := dm.parkService.GetParkAlerts(parkCode)
-	events, _ := dm.parkService.GetParkEvents(parkCode)
+	news := dm.parkService.GetParkNews(r.Context(), parkCode)
 
 	data := map[string]interface{}{
-		"NewsReleases": newsReleases, // Official press releases with detailed content
-		"Articles":     articles,     // In-depth articles with full text, images, and metadata
-		"Alerts":       alerts,       // Critical alerts with full descriptions, categories, and
-		"Events":       events,       // Upcoming events with full details, including dates,
+		"NewsReleases": news.NewsReleases, // Official press releases with detailed content
+		"Articles":     news.Articles,     // In-depth articles with full text, images, and metadata
+		"Alerts":       news.Alerts,       // Critical alerts with full descriptions, categories, and
+		"Events":       news.Events,       // Upcoming events with full details, including dates,
+		"Unavailable":  news.Unavailable,
 		"ParkCode":     parkCode,
 	}
 
-	// Parse and execute the news template
-	tmpl, err := template.New("park-news.html").Funcs(template.FuncMap{
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-		"fullImageURL": func(url string) string {
-			return proxyImageURL(url)
-		},
-	}).ParseFiles("web/templates/partials/park-news.html")
+	// Render the news template
+	tmpl, err := dm.templates.Lookup("partials/park-news.html")
 	if err != nil {
-		log.Printf("Failed to load news template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load news template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load news template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render news content for park %s: %v", parkCode, err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render news content", "park", parkCode, "error", err)
 		http.Error(w, fmt.Sprintf("Failed to render news content: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -1074,31 +1163,29 @@ func (dm *Dashboard) ParkDetailsHandler(w http.ResponseWriter, r *http.Request)
 
 	w.Header().Set("Content-Type", "text/html")
 
-	visitors_centers, _ := dm.parkService.GetParkVisitorCenters(parkCode)
-	campgrounds, _ := dm.parkService.GetParkCampgrounds(parkCode)
-	fees, _ := dm.parkService.GetParkFees(parkCode)
-	parking, _ := dm.parkService.GetParkParking(parkCode)
+	details := dm.parkService.GetParkDetails(r.Context(), parkCode)
 
 	// Comprehensive details data structure with all available NPS API fields
 	data := map[string]interface{}{
-		"VisitorCenters": visitors_centers,
-		"Campgrounds":    campgrounds,
-		"Fees":           fees,
-		"Parking":        parking,
+		"VisitorCenters": details.VisitorCenters,
+		"Campgrounds":    details.Campgrounds,
+		"Fees":           details.Fees,
+		"Parking":        details.Parking,
+		"Unavailable":    details.Unavailable,
 		"ParkCode":       parkCode,
 	}
 
-	// Parse and execute the details template
-	tmpl, err := template.ParseFiles("web/templates/partials/park-details.html")
+	// Render the details template
+	tmpl, err := dm.templates.Lookup("partials/park-details.html")
 	if err != nil {
-		log.Printf("Failed to load details template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load details template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load details template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Template execution error for park %s: %v", parkCode, err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render details content", "park", parkCode, "error", err)
 		http.Error(w, "Failed to render park details", http.StatusInternalServerError)
 		return
 	}
@@ -1109,16 +1196,16 @@ func (dm *Dashboard) ThingsToDoPageHandler(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "text/html")
 
 	// Get available parks for filter dropdown
-	parks, err := dm.parkService.GetAllParks()
+	parks, err := dm.parkService.GetAllParks(r.Context())
 	if err != nil {
-		log.Printf("Failed to get parks for Things To Do page: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to get parks for Things To Do page", "error", err)
 		parks = []database.CachedPark{}
 	}
 
 	// Get available activities for filter dropdown
-	activities, err := dm.parkService.GetAllActivities()
+	activities, err := dm.parkService.GetAllActivities(r.Context())
 	if err != nil {
-		log.Printf("Failed to get activities for Things To Do page: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to get activities for Things To Do page", "error", err)
 		activities = &nps.ActivityResponse{Data: []nps.Activity{}}
 	}
 
@@ -1148,16 +1235,16 @@ func (dm *Dashboard) ThingsToDoPageHandler(w http.ResponseWriter, r *http.Reques
 		"States":     states,
 	}
 
-	tmpl, err := template.ParseFiles("web/templates/things-to-do.html")
+	tmpl, err := dm.templates.Lookup("things-to-do.html")
 	if err != nil {
-		log.Printf("Failed to load Things To Do template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load Things To Do template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load Things To Do template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render Things To Do page: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render Things To Do page", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to render Things To Do page: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -1197,9 +1284,9 @@ func (dm *Dashboard) ThingsToDoSearchHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Call NPS API with filters
-	thingsToDoResponse, err := dm.parkService.SearchThingsToDo(activityId, parkCode, stateCode, query, limit, start)
+	thingsToDoResponse, err := dm.parkService.SearchThingsToDo(r.Context(), activityId, parkCode, stateCode, query, limit, start)
 	if err != nil {
-		log.Printf("Failed to search things to do: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to search things to do", "error", err)
 		thingsToDoResponse = &nps.ThingsToDoResponse{
 			Total: "0",
 			Data:  []nps.ThingsToDo{},
@@ -1223,40 +1310,16 @@ func (dm *Dashboard) ThingsToDoSearchHandler(w http.ResponseWriter, r *http.Requ
 		"ThingsToDoData": thingsToDoResponse,
 	}
 
-	// Create template with function map and parse the file
-	tmpl := template.New("things-to-do-results.html").Funcs(template.FuncMap{
-		"truncate": func(s string, length int) string {
-			if len(s) <= length {
-				return s
-			}
-			return s[:length] + "..."
-		},
-		"slugify": func(s string) string {
-			return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", "-"))
-		},
-		"atoi": func(s string) int {
-			i, _ := strconv.Atoi(s)
-			return i
-		},
-		"add": func(a, b int) int {
-			return a + b
-		},
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-	})
-
-	tmpl, err = tmpl.ParseFiles("web/templates/partials/things-to-do-results.html")
-
+	tmpl, err := dm.templates.Lookup("partials/things-to-do-results.html")
 	if err != nil {
-		log.Printf("Failed to load Things To Do results template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load Things To Do results template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load Things To Do results template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render Things To Do results: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render Things To Do results", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to render Things To Do results: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -1291,9 +1354,9 @@ func (dm *Dashboard) EventsPageHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
 	// Get available parks for filter dropdown
-	parks, err := dm.parkService.GetAllParks()
+	parks, err := dm.parkService.GetAllParks(r.Context())
 	if err != nil {
-		log.Printf("Failed to get parks for Events page: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to get parks for Events page", "error", err)
 		parks = []database.CachedPark{}
 	}
 
@@ -1325,15 +1388,15 @@ func (dm *Dashboard) EventsPageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Load and parse the events template
-	tmpl, err := template.ParseFiles("web/templates/events.html")
+	tmpl, err := dm.templates.Lookup("events.html")
 	if err != nil {
-		log.Printf("Error parsing events template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error parsing events template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing events template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error executing events template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -1380,9 +1443,9 @@ func (dm *Dashboard) EventsSearchHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Search events using the park service
-	eventsData, err := dm.parkService.SearchEvents(query, parkCode, stateCode, eventType, dateStart, dateEnd, limit, start)
+	eventsData, err := dm.parkService.SearchEvents(r.Context(), query, parkCode, stateCode, eventType, dateStart, dateEnd, limit, start)
 	if err != nil {
-		log.Printf("Error searching events: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error searching events", "error", err)
 		http.Error(w, "Error searching events", http.StatusInternalServerError)
 		return
 	}
@@ -1473,43 +1536,17 @@ func (dm *Dashboard) EventsSearchHandler(w http.ResponseWriter, r *http.Request)
 	})
 
 	// Load and parse the events results template
-	tmpl, err := template.New("events-results.html").Funcs(template.FuncMap{
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(html.UnescapeString(s))
-		},
-		"formatEventDate": func(dateStr string) string {
-			if t, err := time.Parse("2006-01-02", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			return dateStr
-		},
-		"fullImageURL": func(url string) string {
-			return proxyImageURL(url)
-		},
-		"lower": strings.ToLower,
-		"atoi": func(s string) int {
-			if i, err := strconv.Atoi(s); err == nil {
-				return i
-			}
-			return 0
-		},
-		"add": func(a, b int) int {
-			return a + b
-		},
-	}).ParseFiles("web/templates/partials/events-results.html")
+	tmpl, err := dm.templates.Lookup("partials/events-results.html")
 
 	if err != nil {
-		log.Printf("Error parsing events results template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error parsing events results template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "text/html")
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing events results template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error executing events results template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -1519,9 +1556,9 @@ func (dm *Dashboard) CampingPageHandler(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "text/html")
 
 	// Get available parks for filter dropdown
-	parks, err := dm.parkService.GetAllParks()
+	parks, err := dm.parkService.GetAllParks(r.Context())
 	if err != nil {
-		log.Printf("Failed to get parks for Camping page: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to get parks for Camping page", "error", err)
 		parks = []database.CachedPark{}
 	}
 
@@ -1553,15 +1590,15 @@ func (dm *Dashboard) CampingPageHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Load and parse the camping template
-	tmpl, err := template.ParseFiles("web/templates/camping.html")
+	tmpl, err := dm.templates.Lookup("camping.html")
 	if err != nil {
-		log.Printf("Error parsing camping template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error parsing camping template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing camping template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error executing camping template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -1592,9 +1629,9 @@ func (dm *Dashboard) CampingSearchHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	// Search campgrounds using the park service
-	campgroundsData, err := dm.parkService.SearchCampgrounds(query, parkCode, stateCode, limit, start)
+	campgroundsData, err := dm.parkService.SearchCampgrounds(r.Context(), query, parkCode, stateCode, limit, start)
 	if err != nil {
-		log.Printf("Error searching campgrounds: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error searching campgrounds", "error", err)
 		http.Error(w, "Error searching campgrounds", http.StatusInternalServerError)
 		return
 	}
@@ -1619,86 +1656,17 @@ func (dm *Dashboard) CampingSearchHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	// Load and parse the camping results template
-	tmpl, err := template.New("camping-results.html").Funcs(template.FuncMap{
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-		"formatCampgroundFee": func(fees []struct {
-			Cost        string `json:"cost"`
-			Description string `json:"description"`
-			Title       string `json:"title"`
-		}) string {
-			if len(fees) == 0 {
-				return "Contact campground for fee information"
-			}
-			return fees[0].Cost
-		},
-		"formatCampgroundAmenities": func(amenities struct {
-			TrashRecyclingCollection   string   `json:"trashRecyclingCollection"`
-			Toilets                    []string `json:"toilets"`
-			InternetConnectivity       string   `json:"internetConnectivity"`
-			Showers                    []string `json:"showers"`
-			CellPhoneReception         string   `json:"cellPhoneReception"`
-			Laundry                    string   `json:"laundry"`
-			Amphitheater               string   `json:"amphitheater"`
-			DumpStation                string   `json:"dumpStation"`
-			CampStore                  string   `json:"campStore"`
-			StaffOrVolunteerHostOnsite string   `json:"staffOrVolunteerHostOnsite"`
-			PotableWater               []string `json:"potableWater"`
-			IceAvailableForSale        string   `json:"iceAvailableForSale"`
-			FirewoodForSale            string   `json:"firewoodForSale"`
-			FoodStorageLockers         string   `json:"foodStorageLockers"`
-		}) []string {
-			var result []string
-			if len(amenities.Toilets) > 0 {
-				result = append(result, "Toilets")
-			}
-			if len(amenities.Showers) > 0 {
-				result = append(result, "Showers")
-			}
-			if amenities.DumpStation == "true" {
-				result = append(result, "Dump Station")
-			}
-			if amenities.CampStore == "true" {
-				result = append(result, "Camp Store")
-			}
-			if amenities.Laundry == "true" {
-				result = append(result, "Laundry")
-			}
-			if amenities.Amphitheater == "true" {
-				result = append(result, "Amphitheater")
-			}
-			return result
-		},
-		"fullImageURL": func(url string) string {
-			return proxyImageURL(url)
-		},
-		"truncate": func(s string, length int) string {
-			if len(s) <= length {
-				return s
-			}
-			return s[:length] + "..."
-		},
-		"atoi": func(s string) int {
-			if i, err := strconv.Atoi(s); err == nil {
-				return i
-			}
-			return 0
-		},
-		"add": func(a, b int) int {
-			return a + b
-		},
-	}).ParseFiles("web/templates/partials/camping-results.html")
+	tmpl, err := dm.templates.Lookup("partials/camping-results.html")
 
 	if err != nil {
-		log.Printf("Error parsing camping results template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error parsing camping results template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "text/html")
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing camping results template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error executing camping results template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -1708,9 +1676,9 @@ func (dm *Dashboard) NewsPageHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
 	// Get available parks for filter dropdown
-	parks, err := dm.parkService.GetAllParks()
+	parks, err := dm.parkService.GetAllParks(r.Context())
 	if err != nil {
-		log.Printf("Failed to get parks for News page: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to get parks for News page", "error", err)
 		parks = []database.CachedPark{}
 	}
 
@@ -1742,15 +1710,15 @@ func (dm *Dashboard) NewsPageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Load and parse the news template
-	tmpl, err := template.ParseFiles("web/templates/news.html")
+	tmpl, err := dm.templates.Lookup("news.html")
 	if err != nil {
-		log.Printf("Error parsing news template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error parsing news template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing news template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error executing news template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -1781,34 +1749,19 @@ func (dm *Dashboard) NewsSearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Search news using the park service
-	newsData, err := dm.parkService.SearchNews(query, parkCode, stateCode, newsType, limit, start)
+	newsData, err := dm.parkService.SearchNews(r.Context(), query, parkCode, stateCode, newsType, limit, start)
 	if err != nil {
-		log.Printf("Error searching news: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error searching news", "error", err)
 		http.Error(w, "Error searching news", http.StatusInternalServerError)
 		return
 	}
 
 	// Normalize the different data types into a unified structure
-	var unifiedData *UnifiedNewsData
-	switch newsType {
-	case "articles":
-		if articleData, ok := newsData.(*nps.ArticleData); ok {
-			unifiedData = normalizeArticleData(articleData)
-		}
-	case "alerts":
-		if alertData, ok := newsData.(*nps.AlertResponse); ok {
-			unifiedData = normalizeAlertData(alertData)
-		}
-	default:
-		// Default to news releases
-		if newsReleaseData, ok := newsData.(*nps.NewsReleaseResponse); ok {
-			unifiedData = normalizeNewsReleaseData(newsReleaseData)
-		}
-	}
+	unifiedData := normalizeNewsData(newsType, newsData)
 
 	// Handle case where normalization failed
 	if unifiedData == nil {
-		log.Printf("Failed to normalize news data for type: %s", newsType)
+		dm.logger.WarnContext(r.Context(), "Failed to normalize news data", "type", newsType)
 		unifiedData = &UnifiedNewsData{
 			Total: "0",
 			Data:  []UnifiedNewsItem{},
@@ -1825,48 +1778,17 @@ func (dm *Dashboard) NewsSearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Load and parse the news results template
-	tmpl, err := template.New("news-results.html").Funcs(template.FuncMap{
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-		"formatNewsDate": func(dateStr string) string {
-			if t, err := time.Parse("2006-01-02", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			return dateStr
-		},
-		"fullImageURL": func(url string) string {
-			return proxyImageURL(url)
-		},
-		"truncate": func(s string, length int) string {
-			if len(s) <= length {
-				return s
-			}
-			return s[:length] + "..."
-		},
-		"atoi": func(s string) int {
-			if i, err := strconv.Atoi(s); err == nil {
-				return i
-			}
-			return 0
-		},
-		"add": func(a, b int) int {
-			return a + b
-		},
-	}).ParseFiles("web/templates/partials/news-results.html")
+	tmpl, err := dm.templates.Lookup("partials/news-results.html")
 
 	if err != nil {
-		log.Printf("Error parsing news results template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error parsing news results template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "text/html")
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing news results template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Error executing news results template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -1901,12 +1823,12 @@ func (dm *Dashboard) EventDetailsHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Try to get the event by ID first
-	targetEvent, err := dm.parkService.GetEventByID(eventID)
+	targetEvent, err := dm.parkService.GetEventByID(r.Context(), eventID)
 	if err != nil {
 		// Fallback: search for the event in recent events
-		eventsData, searchErr := dm.parkService.SearchEvents("", "", "", "", "", "", 100, 0)
+		eventsData, searchErr := dm.parkService.SearchEvents(r.Context(), "", "", "", "", "", "", 100, 0)
 		if searchErr != nil {
-			log.Printf("Error searching for event %s: %v", eventID, searchErr)
+			dm.logger.ErrorContext(r.Context(), "Error searching for event", "event", eventID, "error", searchErr)
 			http.Error(w, "Error fetching event details", http.StatusInternalServerError)
 			return
 		}
@@ -1932,42 +1854,17 @@ func (dm *Dashboard) EventDetailsHandler(w http.ResponseWriter, r *http.Request)
 		"Event": targetEvent,
 	}
 
-	// Parse and execute the event details template
-	tmpl, err := template.New("event-details.html").Funcs(template.FuncMap{
-		"unescapeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-		"formatEventDate": func(dateStr string) string {
-			if t, err := time.Parse("2006-01-02", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			return dateStr
-		},
-		"fullImageURL": func(url string) string {
-			return proxyImageURL(url)
-		},
-		"formatDateTime": func(dateStr string) string {
-			if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
-				return t.Format("January 2, 2006 at 3:04 PM")
-			}
-			if t, err := time.Parse("2006-01-02", dateStr); err == nil {
-				return t.Format("January 2, 2006")
-			}
-			return dateStr
-		},
-	}).ParseFiles("web/templates/partials/event-details.html")
+	// Render the event details template
+	tmpl, err := dm.templates.Lookup("partials/event-details.html")
 	if err != nil {
-		log.Printf("Failed to load event details template: %v", err)
+		dm.logger.ErrorContext(r.Context(), "Failed to load event details template", "error", err)
 		http.Error(w, fmt.Sprintf("Failed to load event details template: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	err = tmpl.Execute(w, data)
 	if err != nil {
-		log.Printf("Failed to render event details for event %s: %v", eventID, err)
+		dm.logger.ErrorContext(r.Context(), "Failed to render event details", "event", eventID, "error", err)
 		http.Error(w, fmt.Sprintf("Failed to render event details: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,222 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/metrics"
+)
+
+// Limits for images fetched by the image and avatar proxies
+const (
+	maxProxyRedirects   = 3
+	maxAvatarImageBytes = 5 << 20
+	proxyFetchTimeout   = 30 * time.Second
+)
+
+// imageProxyHosts are the hosts ImageProxyHandler fetches from, "*." also allows subdomains
+var imageProxyHosts = []string{
+	"nps.gov",
+	"*.nps.gov",
+	"images.unsplash.com",
+	"via.placeholder.com",
+}
+
+// avatarProxyHosts serve Google profile pictures
+var avatarProxyHosts = []string{
+	"*.googleusercontent.com",
+}
+
+// proxyImageTypes are the sniffed content types the proxies will serve. SVG is left out
+// since it can carry scripts.
+var proxyImageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+var (
+	// errBlockedURL is returned for URLs, redirects and addresses the fetcher won't visit
+	errBlockedURL = errors.New("blocked url")
+	// errUpstreamStatus is returned when the upstream answers with anything but 200
+	errUpstreamStatus = errors.New("unexpected upstream status")
+	// errImageTooLarge is returned for bodies over the fetcher's size limit
+	errImageTooLarge = errors.New("image too large")
+	// errNotImage is returned when the body isn't one of proxyImageTypes
+	errNotImage = errors.New("not a supported image")
+)
+
+// blockedPrefixes are non-public ranges not covered by the netip.Addr predicates
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, can reach IPv4 private ranges
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("2002::/16"),      // 6to4, embeds IPv4 addresses
+	netip.MustParsePrefix("2001::/32"),      // Teredo, embeds IPv4 addresses
+}
+
+// publicAddr reports whether addr is a public unicast address, and so safe to fetch from
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// safeFetcher downloads images for the proxies. Only allowlisted hosts are fetched, every
+// redirect is checked again, connections to non-public addresses are refused after DNS
+// resolution, and bodies are size-limited and sniffed rather than trusting Content-Type.
+type safeFetcher struct {
+	name      string // "image" or "avatar", labels the parks_image_proxy_bytes_total metric
+	hosts     []string
+	maxBytes  int64
+	allowAddr func(netip.Addr) bool
+	client    *http.Client
+}
+
+// newSafeFetcher creates a fetcher for hosts, e.g. "nps.gov" or "*.nps.gov"
+func newSafeFetcher(name string, hosts []string, maxBytes int64) *safeFetcher {
+	f := &safeFetcher{name: name, hosts: hosts, maxBytes: maxBytes, allowAddr: publicAddr}
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// Control runs with the resolved address, so DNS can't point an allowed host inward
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", errBlockedURL, address)
+			}
+			if !f.allowAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s is not a public address", errBlockedURL, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	f.client = &http.Client{
+		Timeout: proxyFetchTimeout,
+		// No Proxy, a proxy would make the dial check meaningless
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 15 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxProxyRedirects {
+				return fmt.Errorf("%w: more than %d redirects", errBlockedURL, maxProxyRedirects)
+			}
+			_, err := f.checkURL(req.URL.String())
+			return err
+		},
+	}
+	return f
+}
+
+// checkURL parses rawURL, requiring http(s), no credentials and an allowlisted host
+func (f *safeFetcher) checkURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBlockedURL, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("%w: scheme %q", errBlockedURL, u.Scheme)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: credentials in url", errBlockedURL)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if !allowedHost(host, f.hosts) {
+		return nil, fmt.Errorf("%w: host %q is not allowed", errBlockedURL, host)
+	}
+	return u, nil
+}
+
+// allowedHost matches host exactly, or as a subdomain of a "*." entry
+func allowedHost(host string, hosts []string) bool {
+	if host == "" {
+		return false
+	}
+	for _, allowed := range hosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch downloads an image, returning its body and sniffed content type
+func (f *safeFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := f.checkURL(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create image request: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%w: %d", errUpstreamStatus, resp.StatusCode)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return nil, "", fmt.Errorf("%w: %d bytes", errImageTooLarge, resp.ContentLength)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	metrics.AddImageProxyBytes(f.name, len(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, "", fmt.Errorf("%w: over %d bytes", errImageTooLarge, f.maxBytes)
+	}
+
+	contentType := http.DetectContentType(data)
+	for _, allowed := range proxyImageTypes {
+		if contentType == allowed {
+			return data, contentType, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %s", errNotImage, contentType)
+}
+
+// writeFetchError answers a proxy request whose fetch failed
+func writeFetchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errBlockedURL):
+		http.Error(w, "Invalid image source", http.StatusForbidden)
+	case errors.Is(err, errUpstreamStatus):
+		http.Error(w, "Image not found", http.StatusNotFound)
+	case errors.Is(err, errNotImage):
+		http.Error(w, "Invalid content type", http.StatusUnsupportedMediaType)
+	case errors.Is(err, errImageTooLarge):
+		http.Error(w, "Image too large", http.StatusBadGateway)
+	default:
+		http.Error(w, "Failed to fetch image", http.StatusBadGateway)
+	}
+}
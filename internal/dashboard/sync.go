@@ -0,0 +1,214 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/config"
+	"github.com/ztkent/parks-explorer/internal/database"
+)
+
+const (
+	SyncKindParks    = "parks"
+	SyncKindParkData = "park_data"
+
+	// maxSyncRunErrors caps how many error messages are stored with each sync run
+	maxSyncRunErrors = 20
+)
+
+// SyncScheduler periodically re-syncs the park list and each park's cached data from the NPS API,
+// recording every pass in the sync_runs table
+type SyncScheduler struct {
+	parkService *ParkService
+	db          *database.DB
+	config      config.Sync
+	logger      *slog.Logger
+
+	// Paces background NPS requests to stay within the configured budget
+	budget *time.Ticker
+
+	// ctx is canceled by Stop, ending any NPS call or query in progress
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewSyncScheduler creates a scheduler, call Start to begin syncing
+func NewSyncScheduler(parkService *ParkService, db *database.DB, syncConfig config.Sync, logger *slog.Logger) *SyncScheduler {
+	if syncConfig.RequestsPerHour <= 0 {
+		syncConfig.RequestsPerHour = config.Default().Sync.RequestsPerHour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SyncScheduler{
+		parkService: parkService,
+		db:          db,
+		config:      syncConfig,
+		logger:      logger,
+		budget:      time.NewTicker(time.Hour / time.Duration(syncConfig.RequestsPerHour)),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start syncs the park list immediately, then keeps both sync loops running until Stop is called
+func (s *SyncScheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		// The park list is needed before anything else can be served, so it always syncs on boot
+		// unless the cache is already populated and scheduled syncing is disabled
+		parks, err := s.db.GetAllParks(s.ctx)
+		if err != nil || len(parks) == 0 || s.config.ParksInterval > 0 {
+			s.SyncParks()
+		}
+		if s.config.ParksInterval > 0 {
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.loop(s.config.ParksInterval, s.SyncParks)
+			}()
+		}
+		if s.config.ParkDataInterval > 0 {
+			s.SyncParkData()
+			s.loop(s.config.ParkDataInterval, s.SyncParkData)
+		}
+	}()
+}
+
+// Stop ends the sync loops, canceling any API call or query in progress and waiting for the pass to stop
+func (s *SyncScheduler) Stop() {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		s.budget.Stop()
+	})
+	s.wg.Wait()
+}
+
+// loop runs fn every interval until the scheduler is stopped
+func (s *SyncScheduler) loop(interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// wait blocks until the request budget allows another NPS call, returning false if the scheduler stopped
+func (s *SyncScheduler) wait() bool {
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-s.budget.C:
+		return true
+	}
+}
+
+// SyncParks re-fetches the full park list and records the run. The list is a single
+// request, so it isn't paced by the request budget.
+func (s *SyncScheduler) SyncParks() {
+	run := s.startRun(SyncKindParks)
+	if run == nil {
+		return
+	}
+
+	run.APICalls++
+	synced, err := s.parkService.SyncParks(s.ctx)
+	run.ParksSynced = synced
+	run.ItemsSynced = synced
+	s.finishRun(run, err)
+}
+
+// SyncParkData refreshes every park's cached data that is older than its cache TTL and records the run
+func (s *SyncScheduler) SyncParkData() {
+	run := s.startRun(SyncKindParkData)
+	if run == nil {
+		return
+	}
+
+	parks, err := s.db.GetAllParks(s.ctx)
+	if err != nil {
+		s.finishRun(run, err)
+		return
+	}
+
+	var errs []string
+	var stopErr error
+parks:
+	for _, park := range parks {
+		refreshed := false
+		for _, cache := range s.parkService.caches {
+			parkID, dataType, table := park.ID, cache.DataType(), cache.Table()
+			if stale, err := s.db.IsParkDataStale(s.ctx, parkID, dataType, table, cache.TTL()); err == nil && !stale {
+				continue
+			}
+			if !s.wait() {
+				stopErr = fmt.Errorf("sync stopped after refreshing %d parks", run.ParksSynced)
+				break parks
+			}
+
+			run.APICalls++
+			if err := cache.Refresh(s.ctx, park.ParkCode); err != nil {
+				run.ErrorCount++
+				errs = append(errs, fmt.Sprintf("%s/%s: %v", park.ParkCode, dataType, err))
+				continue
+			}
+			run.ItemsSynced++
+			refreshed = true
+		}
+		if refreshed {
+			run.ParksSynced++
+		}
+	}
+
+	if len(errs) > maxSyncRunErrors {
+		errs = append(errs[:maxSyncRunErrors], fmt.Sprintf("... and %d more", len(errs)-maxSyncRunErrors))
+	}
+	run.Errors = strings.Join(errs, "\n")
+	s.finishRun(run, stopErr)
+}
+
+// startRun records the start of a sync pass
+func (s *SyncScheduler) startRun(kind string) *database.SyncRun {
+	id, err := s.db.StartSyncRun(s.ctx, kind)
+	if err != nil {
+		s.logger.Error("Failed to record sync run", "kind", kind, "error", err)
+		return nil
+	}
+	s.logger.Info("Starting sync", "kind", kind)
+	return &database.SyncRun{ID: id, Kind: kind, StartedAt: time.Now()}
+}
+
+// finishRun records the outcome of a sync pass, with err being a failure of the pass as a whole
+func (s *SyncScheduler) finishRun(run *database.SyncRun, err error) {
+	if run == nil {
+		return
+	}
+
+	switch {
+	case err != nil:
+		run.Status = "failed"
+		run.ErrorCount++
+		run.Errors = strings.TrimSpace(err.Error() + "\n" + run.Errors)
+	case run.ErrorCount > 0:
+		run.Status = "partial"
+	default:
+		run.Status = "success"
+	}
+	// A pass cut short by Stop is still recorded
+	if err := s.db.FinishSyncRun(context.WithoutCancel(s.ctx), run); err != nil {
+		s.logger.Error("Failed to record sync result", "kind", run.Kind, "error", err)
+	}
+	s.logger.Info("Finished sync", "kind", run.Kind, "status", run.Status,
+		"items_synced", run.ItemsSynced, "api_calls", run.APICalls, "errors", run.ErrorCount)
+}
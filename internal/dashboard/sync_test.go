@@ -0,0 +1,119 @@
+package dashboard
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/config"
+	"github.com/ztkent/parks-explorer/internal/database"
+	"github.com/ztkent/parks-explorer/internal/npsfake"
+)
+
+// newTestScheduler creates a scheduler syncing from fake into a new database, without starting it
+func newTestScheduler(t *testing.T, fake *npsfake.Client, syncConfig config.Sync) (*SyncScheduler, *database.DB) {
+	t.Helper()
+	logger := slog.New(slog.DiscardHandler)
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "sync.db"), logger)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	s := NewSyncScheduler(NewParkService(fake, db, logger), db, syncConfig, logger)
+	t.Cleanup(func() {
+		s.Stop()
+		db.Close()
+	})
+	return s, db
+}
+
+// lastRun returns the most recent sync run of a kind
+func lastRun(t *testing.T, db *database.DB, kind string) *database.SyncRun {
+	t.Helper()
+	run, err := db.GetLastSyncRun(t.Context(), kind)
+	if err != nil {
+		t.Fatalf("failed to get the last %s sync run: %v", kind, err)
+	}
+	return run
+}
+
+func TestSyncParksRecordsRuns(t *testing.T) {
+	fake := npsfake.New(npsfake.Fixtures())
+	s, db := newTestScheduler(t, fake, config.Sync{})
+
+	s.SyncParks()
+	run := lastRun(t, db, SyncKindParks)
+	if run.Status != "success" || run.ParksSynced != 3 || run.APICalls != 1 || run.FinishedAt == nil {
+		t.Errorf("got run %+v, want 3 parks synced successfully with 1 call", run)
+	}
+
+	fake.Fail("parks", errors.New("status code: 503"))
+	s.SyncParks()
+	run = lastRun(t, db, SyncKindParks)
+	if run.Status != "failed" || run.ErrorCount != 1 || !strings.Contains(run.Errors, "503") {
+		t.Errorf("got run %+v, want a failure recording the 503", run)
+	}
+}
+
+func TestSyncParkDataPacedByBudget(t *testing.T) {
+	fake := npsfake.New(npsfake.Fixtures())
+	// One request every 5ms
+	s, db := newTestScheduler(t, fake, config.Sync{RequestsPerHour: int(time.Hour / (5 * time.Millisecond))})
+	s.SyncParks()
+	fake.Fail("webcams", errors.New("status code: 500"))
+
+	start := time.Now()
+	s.SyncParkData()
+	elapsed := time.Since(start)
+
+	run := lastRun(t, db, SyncKindParkData)
+	refreshes := 3 * len(s.parkService.caches)
+	if run.APICalls != refreshes || run.ItemsSynced != refreshes-3 || run.ParksSynced != 3 {
+		t.Errorf("got run %+v, want %d calls refreshing every park", run, refreshes)
+	}
+	if run.Status != "partial" || run.ErrorCount != 3 || !strings.Contains(run.Errors, "yose/webcams") {
+		t.Errorf("got run %+v, want the webcam failures recorded", run)
+	}
+	if least := time.Duration(refreshes-1) * 5 * time.Millisecond; elapsed < least {
+		t.Errorf("%d calls took %s, want at least %s within the budget", refreshes, elapsed, least)
+	}
+
+	// Only the data that failed is stale on the next pass
+	fake.Fail("webcams", nil)
+	s.SyncParkData()
+	if run := lastRun(t, db, SyncKindParkData); run.Status != "success" || run.APICalls != 3 {
+		t.Errorf("got run %+v, want only the 3 failed refreshes retried", run)
+	}
+}
+
+func TestSyncStopCancelsRun(t *testing.T) {
+	fake := npsfake.New(npsfake.Fixtures())
+	s, db := newTestScheduler(t, fake, config.Sync{ParkDataInterval: time.Hour, RequestsPerHour: int(time.Hour / time.Millisecond)})
+
+	// Hold the first refresh of the park data pass, so Stop lands in the middle of it
+	release := fake.Hold("thingstodo")
+	defer release()
+	s.Start()
+	waitFor(t, "the park data sync to call the NPS API", func() bool { return fake.Calls("thingstodo") > 0 })
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop didn't cancel the held NPS call")
+	}
+
+	run := lastRun(t, db, SyncKindParkData)
+	if run.Status != "failed" || !strings.Contains(run.Errors, "sync stopped") || run.FinishedAt == nil {
+		t.Errorf("got run %+v, want a failed run recording the stop", run)
+	}
+	if calls := fake.Calls("thingstodo"); calls != 1 {
+		t.Errorf("got %d thingstodo calls, want the sync to stop after the held one", calls)
+	}
+}
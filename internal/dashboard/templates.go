@@ -0,0 +1,236 @@
+package dashboard
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// templateRegistry parses every page and partial under templates/ once, with the shared
+// templateFuncs. With reload set, templates are parsed again on every lookup so edits to
+// files on disk show up without a restart.
+type templateRegistry struct {
+	fsys      fs.FS
+	reload    bool
+	templates map[string]*template.Template
+}
+
+// newTemplateRegistry parses all templates in fsys, failing if any of them is invalid
+func newTemplateRegistry(fsys fs.FS, reload bool) (*templateRegistry, error) {
+	tr := &templateRegistry{fsys: fsys, reload: reload}
+	templates, err := tr.parseAll()
+	if err != nil {
+		return nil, err
+	}
+	tr.templates = templates
+	return tr, nil
+}
+
+// Lookup returns a template by its path under templates/, e.g. "partials/park-news.html"
+func (tr *templateRegistry) Lookup(name string) (*template.Template, error) {
+	if tr.reload {
+		return tr.parse(name)
+	}
+
+	tmpl, ok := tr.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s does not exist", name)
+	}
+	return tmpl, nil
+}
+
+// parseAll parses every .html file under templates/
+func (tr *templateRegistry) parseAll() (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template)
+	err := fs.WalkDir(tr.fsys, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".html" {
+			return nil
+		}
+		name := strings.TrimPrefix(p, "templates/")
+		tmpl, err := tr.parse(name)
+		if err != nil {
+			return err
+		}
+		templates[name] = tmpl
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (tr *templateRegistry) parse(name string) (*template.Template, error) {
+	tmpl, err := template.New(path.Base(name)).Funcs(templateFuncs).ParseFS(tr.fsys, path.Join("templates", name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// templateFuncs are the helpers available to every template
+var templateFuncs = template.FuncMap{
+	"unescapeHTML":              unescapeHTML,
+	"truncate":                  truncate,
+	"slugify":                   slugify,
+	"lower":                     strings.ToLower,
+	"atoi":                      atoi,
+	"add":                       func(a, b int) int { return a + b },
+	"sub":                       func(a, b int) int { return a - b },
+	"fullImageURL":              proxyImageURL,
+	"imageURL":                  proxyImageURLSized,
+	"imageSrcset":               proxyImageSrcset,
+	"formatEventDate":           formatEventDate,
+	"formatDateTime":            formatDateTime,
+	"formatNewsDate":            formatNewsDate,
+	"formatDuration":            formatDuration,
+	"formatCampgroundFee":       formatCampgroundFee,
+	"formatCampgroundAmenities": formatCampgroundAmenities,
+	"tripItemLabel":             tripItemLabel,
+}
+
+// unescapeHTML marks NPS descriptions as HTML. Some endpoints escape their markup,
+// so entities are decoded first.
+func unescapeHTML(s string) template.HTML {
+	return template.HTML(html.UnescapeString(s))
+}
+
+// truncate shortens s to length characters, adding an ellipsis when it was cut
+func truncate(s string, length int) string {
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	return string([]rune(s)[:length]) + "..."
+}
+
+func slugify(s string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", "-"))
+}
+
+// atoi converts NPS numeric strings, treating anything unparseable as 0
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+// formatEventDate formats a YYYY-MM-DD or UTC timestamp date, e.g. "January 2, 2006"
+func formatEventDate(dateStr string) string {
+	if t, err := time.Parse("2006-01-02", dateStr); err == nil {
+		return t.Format("January 2, 2006")
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
+		return t.Format("January 2, 2006")
+	}
+	return dateStr
+}
+
+// formatDateTime is formatEventDate including the time of day when there is one
+func formatDateTime(dateStr string) string {
+	if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
+		return t.Format("January 2, 2006 at 3:04 PM")
+	}
+	if t, err := time.Parse("2006-01-02", dateStr); err == nil {
+		return t.Format("January 2, 2006")
+	}
+	return dateStr
+}
+
+// formatNewsDate formats the dates of news releases, articles and alerts
+func formatNewsDate(dateStr string) string {
+	if t, ok := parseNewsDate(dateStr); ok {
+		return t.Format("January 2, 2006")
+	}
+	return dateStr
+}
+
+// formatDuration formats a media duration in milliseconds
+func formatDuration(durationMs interface{}) string {
+	var ms int64
+	switch v := durationMs.(type) {
+	case int:
+		ms = int64(v)
+	case int64:
+		ms = v
+	case float64:
+		ms = int64(v)
+	default:
+		return "Unknown"
+	}
+
+	if ms <= 0 {
+		return "Unknown"
+	}
+
+	seconds := ms / 1000
+	minutes := seconds / 60
+	hours := minutes / 60
+
+	if hours > 0 {
+		remainingMinutes := minutes % 60
+		return fmt.Sprintf("%d:%02d", hours, remainingMinutes)
+	} else if minutes > 0 {
+		return fmt.Sprintf("%d min", minutes)
+	} else {
+		return fmt.Sprintf("%d sec", seconds)
+	}
+}
+
+// formatCampgroundFee returns the first fee of a campground
+func formatCampgroundFee(fees []struct {
+	Cost        string `json:"cost"`
+	Description string `json:"description"`
+	Title       string `json:"title"`
+}) string {
+	if len(fees) == 0 {
+		return "Contact campground for fee information"
+	}
+	return fees[0].Cost
+}
+
+// formatCampgroundAmenities lists the notable amenities of a campground
+func formatCampgroundAmenities(amenities struct {
+	TrashRecyclingCollection   string   `json:"trashRecyclingCollection"`
+	Toilets                    []string `json:"toilets"`
+	InternetConnectivity       string   `json:"internetConnectivity"`
+	Showers                    []string `json:"showers"`
+	CellPhoneReception         string   `json:"cellPhoneReception"`
+	Laundry                    string   `json:"laundry"`
+	Amphitheater               string   `json:"amphitheater"`
+	DumpStation                string   `json:"dumpStation"`
+	CampStore                  string   `json:"campStore"`
+	StaffOrVolunteerHostOnsite string   `json:"staffOrVolunteerHostOnsite"`
+	PotableWater               []string `json:"potableWater"`
+	IceAvailableForSale        string   `json:"iceAvailableForSale"`
+	FirewoodForSale            string   `json:"firewoodForSale"`
+	FoodStorageLockers         string   `json:"foodStorageLockers"`
+}) []string {
+	var result []string
+	if len(amenities.Toilets) > 0 {
+		result = append(result, "Toilets")
+	}
+	if len(amenities.Showers) > 0 {
+		result = append(result, "Showers")
+	}
+	if amenities.DumpStation == "true" {
+		result = append(result, "Dump Station")
+	}
+	if amenities.CampStore == "true" {
+		result = append(result, "Camp Store")
+	}
+	if amenities.Laundry == "true" {
+		result = append(result, "Laundry")
+	}
+	if amenities.Amphitheater == "true" {
+		result = append(result, "Amphitheater")
+	}
+	return result
+}
@@ -0,0 +1,162 @@
+package dashboard
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ztkent/parks-explorer/internal/database"
+	"github.com/ztkent/parks-explorer/web"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"unescapeHTML decodes escaped markup", string(unescapeHTML("&lt;p&gt;Half Dome &amp; Clouds Rest&lt;/p&gt;")), "<p>Half Dome & Clouds Rest</p>"},
+		{"unescapeHTML keeps markup", string(unescapeHTML("<p>Valley floor</p>")), "<p>Valley floor</p>"},
+		{"truncate short", truncate("Mist Trail", 20), "Mist Trail"},
+		{"truncate long", truncate("Mist Trail to Vernal Fall", 10), "Mist Trail..."},
+		{"truncate multibyte", truncate("Haleakalā Crater", 9), "Haleakalā..."},
+		{"slugify", slugify(" Hiking Trails "), "hiking-trails"},
+		{"atoi", atoi("42"), 42},
+		{"atoi invalid", atoi("n/a"), 0},
+		{"formatEventDate date", formatEventDate("2026-11-21"), "November 21, 2026"},
+		{"formatEventDate timestamp", formatEventDate("2026-11-21T15:30:00Z"), "November 21, 2026"},
+		{"formatEventDate invalid", formatEventDate("Saturdays"), "Saturdays"},
+		{"formatDateTime timestamp", formatDateTime("2026-11-21T15:30:00Z"), "November 21, 2026 at 3:30 PM"},
+		{"formatDateTime date", formatDateTime("2026-11-21"), "November 21, 2026"},
+		{"formatNewsDate", formatNewsDate("2026-09-30 14:00:00.0"), "September 30, 2026"},
+		{"formatNewsDate invalid", formatNewsDate("soon"), "soon"},
+		{"formatDuration seconds", formatDuration(45000), "45 sec"},
+		{"formatDuration minutes", formatDuration(float64(150000)), "2 min"},
+		{"formatDuration hours", formatDuration(int64(3720000)), "1:02"},
+		{"formatDuration zero", formatDuration(0), "Unknown"},
+		{"formatDuration missing", formatDuration(nil), "Unknown"},
+		{"fullImageURL relative", templateFuncs["fullImageURL"].(func(string) string)("/common/uploads/yose.jpg"),
+			"/api/image-proxy?url=https%3A%2F%2Fwww.nps.gov%2Fcommon%2Fuploads%2Fyose.jpg"},
+		{"tripItemLabel", tripItemLabel(database.EntityCampground), "🏕️ Campground"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCampgroundHelpers(t *testing.T) {
+	if got := formatCampgroundFee(nil); got != "Contact campground for fee information" {
+		t.Errorf("formatCampgroundFee(nil) = %q", got)
+	}
+
+	var campground struct {
+		Fees []struct {
+			Cost        string `json:"cost"`
+			Description string `json:"description"`
+			Title       string `json:"title"`
+		}
+		Amenities struct {
+			TrashRecyclingCollection   string   `json:"trashRecyclingCollection"`
+			Toilets                    []string `json:"toilets"`
+			InternetConnectivity       string   `json:"internetConnectivity"`
+			Showers                    []string `json:"showers"`
+			CellPhoneReception         string   `json:"cellPhoneReception"`
+			Laundry                    string   `json:"laundry"`
+			Amphitheater               string   `json:"amphitheater"`
+			DumpStation                string   `json:"dumpStation"`
+			CampStore                  string   `json:"campStore"`
+			StaffOrVolunteerHostOnsite string   `json:"staffOrVolunteerHostOnsite"`
+			PotableWater               []string `json:"potableWater"`
+			IceAvailableForSale        string   `json:"iceAvailableForSale"`
+			FirewoodForSale            string   `json:"firewoodForSale"`
+			FoodStorageLockers         string   `json:"foodStorageLockers"`
+		}
+	}
+	campground.Fees = append(campground.Fees, struct {
+		Cost        string `json:"cost"`
+		Description string `json:"description"`
+		Title       string `json:"title"`
+	}{Cost: "36.00", Title: "Campsite"})
+	campground.Amenities.Toilets = []string{"Flush Toilets - year round"}
+	campground.Amenities.DumpStation = "true"
+	campground.Amenities.Laundry = "false"
+
+	if got := formatCampgroundFee(campground.Fees); got != "36.00" {
+		t.Errorf("formatCampgroundFee = %q, want 36.00", got)
+	}
+	want := []string{"Toilets", "Dump Station"}
+	if got := formatCampgroundAmenities(campground.Amenities); !reflect.DeepEqual(got, want) {
+		t.Errorf("formatCampgroundAmenities = %v, want %v", got, want)
+	}
+}
+
+func TestTemplateRegistry(t *testing.T) {
+	tr, err := newTemplateRegistry(web.Files(), false)
+	if err != nil {
+		t.Fatalf("embedded templates are invalid: %v", err)
+	}
+	for _, name := range []string{"park.html", "header.html", "trip.html", "partials/park-activities.html", "partials/camping-results.html"} {
+		if _, err := tr.Lookup(name); err != nil {
+			t.Errorf("Lookup(%s): %v", name, err)
+		}
+	}
+	if _, err := tr.Lookup("missing.html"); err == nil {
+		t.Error("Lookup of a missing template should fail")
+	}
+}
+
+func TestTemplateRegistryRejectsInvalidTemplates(t *testing.T) {
+	for name, body := range map[string]string{
+		"syntax error":     `{{if .Parks}}unclosed`,
+		"unknown function": `{{formatParkDate .Date}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"templates/ok.html":           {Data: []byte(`<p>{{truncate .Name 5}}</p>`)},
+				"templates/partials/bad.html": {Data: []byte(body)},
+			}
+			_, err := newTemplateRegistry(fsys, false)
+			if err == nil || !strings.Contains(err.Error(), "partials/bad.html") {
+				t.Errorf("got err %v, want a parse error naming partials/bad.html", err)
+			}
+		})
+	}
+}
+
+func TestTemplateRegistryReload(t *testing.T) {
+	fsys := fstest.MapFS{"templates/park.html": {Data: []byte(`<h1>{{.}}</h1>`)}}
+	cached, err := newTemplateRegistry(fsys, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloading, err := newTemplateRegistry(fsys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["templates/park.html"] = &fstest.MapFile{Data: []byte(`<h2>{{.}}</h2>`)}
+
+	render := func(tr *templateRegistry) string {
+		tmpl, err := tr.Lookup("park.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, "Yosemite"); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if got := render(cached); got != "<h1>Yosemite</h1>" {
+		t.Errorf("cached registry rendered %q, want the template parsed at startup", got)
+	}
+	if got := render(reloading); got != "<h2>Yosemite</h2>" {
+		t.Errorf("reloading registry rendered %q, want the edited template", got)
+	}
+}
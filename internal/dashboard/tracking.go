@@ -4,7 +4,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"net/http"
-	"os"
 
 	"github.com/google/uuid"
 )
@@ -41,10 +40,7 @@ func (s *Dashboard) getOrCreateVisitorID(w http.ResponseWriter, r *http.Request)
 
 // setVisitorCookie sets the visitor tracking cookie
 func (s *Dashboard) setVisitorCookie(w http.ResponseWriter, visitorID string) {
-	secure := true
-	if os.Getenv("ENV") == "dev" {
-		secure = false
-	}
+	secure := !s.cfg.IsDev()
 
 	cookie := &http.Cookie{
 		Name:     VisitorCookieName,
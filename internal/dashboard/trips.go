@@ -0,0 +1,419 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ztkent/parks-explorer/internal/database"
+)
+
+// Trips are multi-park itineraries: signed-in users add parks, things to do, campgrounds and
+// events from search results to days of a trip, reorder them and take notes.
+//
+// The JSON API under /api/trips is behind AuthMiddleware. The trip pages check the signed-in
+// user themselves so they can prompt visitors to sign in. Trips belonging to other users are
+// reported as not found.
+
+// tripDay is one day of a trip, as rendered by the trip templates
+type tripDay struct {
+	Number int
+	Date   string
+	Items  []database.TripItem
+}
+
+// tripDays groups a trip's items by day, including empty days
+func tripDays(trip *database.Trip) []tripDay {
+	days := make([]tripDay, 0, trip.Days)
+	for day := 1; day <= trip.Days; day++ {
+		d := tripDay{Number: day, Items: trip.DayItems(day)}
+		if date, ok := trip.Date(day); ok {
+			d.Date = date.Format("Monday, January 2, 2006")
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// ownedTrip loads the trip in the URL, writing a 404 unless it belongs to user
+func (dm *Dashboard) ownedTrip(w http.ResponseWriter, r *http.Request, user *User) (*database.Trip, bool) {
+	tripID, err := strconv.Atoi(chi.URLParam(r, "tripID"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	trip, err := dm.db.GetTrip(r.Context(), tripID)
+	if errors.Is(err, database.ErrTripNotFound) || (err == nil && trip.UserID != user.ID) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to get trip", "trip", tripID, "error", err)
+		http.Error(w, "Failed to get trip", http.StatusInternalServerError)
+		return nil, false
+	}
+	return trip, true
+}
+
+// writeTripError maps trip errors to HTTP responses
+func (dm *Dashboard) writeTripError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, database.ErrInvalidTrip):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, database.ErrTripNotFound):
+		http.NotFound(w, r)
+	default:
+		dm.logger.ErrorContext(r.Context(), "Failed to "+action, "error", err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+func writeTripJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// tripRequest is the body for creating and updating trips, omitted fields are left unchanged
+type tripRequest struct {
+	Name      *string `json:"name"`
+	StartDate *string `json:"start_date"`
+	Days      *int    `json:"days"`
+	Notes     *string `json:"notes"`
+}
+
+func (req tripRequest) apply(trip *database.Trip) {
+	if req.Name != nil {
+		trip.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.StartDate != nil {
+		trip.StartDate = strings.TrimSpace(*req.StartDate)
+	}
+	if req.Days != nil {
+		trip.Days = *req.Days
+	}
+	if req.Notes != nil {
+		trip.Notes = *req.Notes
+	}
+}
+
+// tripItemRequest is the body for adding and updating trip items
+type tripItemRequest struct {
+	ItemType string  `json:"item_type"`
+	ItemID   string  `json:"item_id"`
+	ParkCode string  `json:"park_code"`
+	Title    string  `json:"title"`
+	URL      string  `json:"url"`
+	Day      *int    `json:"day"`
+	Position *int    `json:"position"`
+	Notes    *string `json:"notes"`
+}
+
+// TripsHandler lists the signed-in user's trips as JSON
+func (dm *Dashboard) TripsHandler(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r)
+	trips, err := dm.db.GetUserTrips(r.Context(), user.ID)
+	if err != nil {
+		dm.writeTripError(w, r, "get trips", err)
+		return
+	}
+	writeTripJSON(w, http.StatusOK, map[string]interface{}{"trips": trips})
+}
+
+// CreateTripHandler creates a trip for the signed-in user
+func (dm *Dashboard) CreateTripHandler(w http.ResponseWriter, r *http.Request) {
+	var req tripRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	trip := &database.Trip{UserID: userFromContext(r).ID, Days: 1}
+	req.apply(trip)
+	if err := dm.db.CreateTrip(r.Context(), trip); err != nil {
+		dm.writeTripError(w, r, "create trip", err)
+		return
+	}
+	writeTripJSON(w, http.StatusCreated, trip)
+}
+
+// TripHandler returns one of the signed-in user's trips with its items as JSON
+func (dm *Dashboard) TripHandler(w http.ResponseWriter, r *http.Request) {
+	trip, ok := dm.ownedTrip(w, r, userFromContext(r))
+	if !ok {
+		return
+	}
+	writeTripJSON(w, http.StatusOK, trip)
+}
+
+// UpdateTripHandler updates a trip's name, start date, length or notes
+func (dm *Dashboard) UpdateTripHandler(w http.ResponseWriter, r *http.Request) {
+	trip, ok := dm.ownedTrip(w, r, userFromContext(r))
+	if !ok {
+		return
+	}
+
+	var req tripRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.apply(trip)
+	if err := dm.db.UpdateTrip(r.Context(), trip); err != nil {
+		dm.writeTripError(w, r, "update trip", err)
+		return
+	}
+	writeTripJSON(w, http.StatusOK, trip)
+}
+
+// DeleteTripHandler deletes a trip
+func (dm *Dashboard) DeleteTripHandler(w http.ResponseWriter, r *http.Request) {
+	trip, ok := dm.ownedTrip(w, r, userFromContext(r))
+	if !ok {
+		return
+	}
+	if err := dm.db.DeleteTrip(r.Context(), trip.ID); err != nil {
+		dm.writeTripError(w, r, "delete trip", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddTripItemHandler adds a park, thing to do, campground or event to a day of a trip.
+// Parks are looked up by code, other items are saved as described by the search result they came from.
+func (dm *Dashboard) AddTripItemHandler(w http.ResponseWriter, r *http.Request) {
+	trip, ok := dm.ownedTrip(w, r, userFromContext(r))
+	if !ok {
+		return
+	}
+
+	var req tripItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item := &database.TripItem{
+		TripID:   trip.ID,
+		Day:      1,
+		ItemType: req.ItemType,
+		ItemID:   strings.TrimSpace(req.ItemID),
+		ParkCode: strings.TrimSpace(req.ParkCode),
+		Title:    strings.TrimSpace(req.Title),
+		URL:      safeTripURL(req.URL),
+	}
+	if req.Day != nil {
+		item.Day = *req.Day
+	}
+	if req.Notes != nil {
+		item.Notes = *req.Notes
+	}
+
+	if item.ItemType == database.EntityPark {
+		parkCode := item.ItemID
+		if parkCode == "" {
+			parkCode = item.ParkCode
+		}
+		parkID, err := dm.db.GetParkIDByCode(r.Context(), parkCode)
+		if err != nil {
+			http.Error(w, "Park not found", http.StatusBadRequest)
+			return
+		}
+		park, err := dm.db.GetParkByID(r.Context(), parkID)
+		if err != nil {
+			dm.writeTripError(w, r, "add trip item", err)
+			return
+		}
+		item.ItemID = park.ParkCode
+		item.ParkCode = park.ParkCode
+		item.Title = park.FullName
+		if item.Title == "" {
+			item.Title = park.Name
+		}
+		item.URL = "/parks/" + park.Slug
+	}
+
+	if err := dm.db.AddTripItem(r.Context(), item); err != nil {
+		dm.writeTripError(w, r, "add trip item", err)
+		return
+	}
+	writeTripJSON(w, http.StatusCreated, item)
+}
+
+// UpdateTripItemHandler moves a trip item to another day or position, or updates its notes
+func (dm *Dashboard) UpdateTripItemHandler(w http.ResponseWriter, r *http.Request) {
+	trip, ok := dm.ownedTrip(w, r, userFromContext(r))
+	if !ok {
+		return
+	}
+	itemID, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req tripItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Notes != nil {
+		if err := dm.db.UpdateTripItemNotes(r.Context(), trip.ID, itemID, *req.Notes); err != nil {
+			dm.writeTripError(w, r, "update trip item", err)
+			return
+		}
+	}
+	if req.Day != nil || req.Position != nil {
+		var current *database.TripItem
+		for i := range trip.Items {
+			if trip.Items[i].ID == itemID {
+				current = &trip.Items[i]
+			}
+		}
+		if current == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		day, position := current.Day, current.Position
+		if req.Day != nil {
+			day = *req.Day
+			// Moving to another day without a position appends to it
+			if req.Position == nil && day != current.Day {
+				position = math.MaxInt
+			}
+		}
+		if req.Position != nil {
+			position = *req.Position
+		}
+		if err := dm.db.MoveTripItem(r.Context(), trip.ID, itemID, day, position); err != nil {
+			dm.writeTripError(w, r, "move trip item", err)
+			return
+		}
+	}
+
+	trip, err = dm.db.GetTrip(r.Context(), trip.ID)
+	if err != nil {
+		dm.writeTripError(w, r, "get trip", err)
+		return
+	}
+	writeTripJSON(w, http.StatusOK, trip)
+}
+
+// DeleteTripItemHandler removes an item from a trip
+func (dm *Dashboard) DeleteTripItemHandler(w http.ResponseWriter, r *http.Request) {
+	trip, ok := dm.ownedTrip(w, r, userFromContext(r))
+	if !ok {
+		return
+	}
+	itemID, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := dm.db.DeleteTripItem(r.Context(), trip.ID, itemID); err != nil {
+		dm.writeTripError(w, r, "delete trip item", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TripsPageHandler returns the signed-in user's list of trips, or a prompt to sign in
+func (dm *Dashboard) TripsPageHandler(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"SignedIn": false,
+		"MaxDays":  database.MaxTripDays,
+	}
+	if user, err := dm.GetCurrentUser(r); err == nil {
+		trips, err := dm.db.GetUserTrips(r.Context(), user.ID)
+		if err != nil {
+			dm.logger.ErrorContext(r.Context(), "Failed to get trips", "user", user.ID, "error", err)
+		}
+		data["SignedIn"] = true
+		data["Trips"] = trips
+	}
+	dm.renderTripTemplate(w, r, "trips.html", data)
+}
+
+// TripPageHandler returns the itinerary editor for one of the signed-in user's trips
+func (dm *Dashboard) TripPageHandler(w http.ResponseWriter, r *http.Request) {
+	dm.renderTripPage(w, r, "trip.html")
+}
+
+// TripPrintHandler returns a printable itinerary for one of the signed-in user's trips
+func (dm *Dashboard) TripPrintHandler(w http.ResponseWriter, r *http.Request) {
+	dm.renderTripPage(w, r, "trip-print.html")
+}
+
+func (dm *Dashboard) renderTripPage(w http.ResponseWriter, r *http.Request, name string) {
+	user, err := dm.GetCurrentUser(r)
+	if err != nil {
+		http.Redirect(w, r, "/trips", http.StatusSeeOther)
+		return
+	}
+	trip, ok := dm.ownedTrip(w, r, user)
+	if !ok {
+		return
+	}
+	dm.renderTripTemplate(w, r, name, map[string]interface{}{
+		"Trip":    trip,
+		"Days":    tripDays(trip),
+		"MaxDays": database.MaxTripDays,
+	})
+}
+
+func (dm *Dashboard) renderTripTemplate(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-store")
+
+	tmpl, err := dm.templates.Lookup(name)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to load template", "template", name, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to load %s template: %v", name, err), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		dm.logger.ErrorContext(r.Context(), "Failed to render template", "template", name, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to render %s: %v", name, err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// tripItemLabel describes a trip item type for display
+func tripItemLabel(itemType string) string {
+	switch itemType {
+	case database.EntityPark:
+		return "🏞️ Park"
+	case database.EntityThingToDo:
+		return "🥾 Thing to do"
+	case database.EntityCampground:
+		return "🏕️ Campground"
+	case database.EntityEvent:
+		return "📅 Event"
+	default:
+		return itemType
+	}
+}
+
+// safeTripURL keeps links from search results to http(s) URLs and site paths
+func safeTripURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	if u.Scheme == "http" || u.Scheme == "https" || (u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/")) {
+		return u.String()
+	}
+	return ""
+}
@@ -1,25 +1,26 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	_ "embed"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed schema.sql
-var schemaSQL string
-
 type DB struct {
 	*sql.DB
+	logger *slog.Logger
+
+	// searchEnabled is set when SQLite supports FTS5 and the search index exists
+	searchEnabled bool
 }
 
-// NewDatabase creates a new database connection and initializes the schema
-func NewDatabase(dbPath string) (*DB, error) {
+// NewDatabase creates a new database connection and applies any pending migrations
+func NewDatabase(dbPath string, logger *slog.Logger) (*DB, error) {
 	dbExists := true
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		dbExists = false
@@ -30,35 +31,31 @@ func NewDatabase(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 	if dbExists {
-		fmt.Printf("Using existing database at: %s\n", dbPath)
+		logger.Info("Using existing database", "path", dbPath)
 	} else {
-		fmt.Printf("Creating new database at: %s\n", dbPath)
+		logger.Info("Creating new database", "path", dbPath)
 	}
 
-	// Open database connection
-	sqlDB, err := sql.Open("sqlite3", dbPath)
+	// Open database connection, waiting on locks held by concurrent cache writers rather than failing.
+	// SQLite only enforces foreign keys, and their ON DELETE CASCADE clauses, when asked to on each connection.
+	sqlDB, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=5000&_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 	if err := sqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-	db := &DB{sqlDB}
+	db := &DB{DB: sqlDB, logger: logger}
 
-	// Initialize the database schema, unless it already exists
-	if err := db.initializeSchema(); err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			return db, nil
-		}
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	// Bring the schema up to date, startup work isn't tied to any request
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
-	return db, nil
-}
-
-// initializeSchema reads and executes the schema.sql file
-func (db *DB) initializeSchema() error {
-	if _, err := db.Exec(schemaSQL); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
+	if version, err := db.SchemaVersion(ctx); err == nil {
+		logger.Info("Database schema up to date", "version", version)
 	}
-	return nil
+
+	db.initSearchIndex(ctx)
+	return db, nil
 }
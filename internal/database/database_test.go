@@ -0,0 +1,18 @@
+package database
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+)
+
+// newTestDB opens a migrated database in a temp dir
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "test.db"), slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
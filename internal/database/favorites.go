@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// AddFavorite saves a park to a user's favorites, returning an error if the park doesn't exist
+func (db *DB) AddFavorite(ctx context.Context, userID int, parkCode string) error {
+	parkID, err := db.GetParkIDByCode(ctx, parkCode)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "INSERT OR IGNORE INTO user_favorites (user_id, park_id) VALUES (?, ?)", userID, parkID)
+	if err != nil {
+		return fmt.Errorf("failed to add favorite: %w", err)
+	}
+	return nil
+}
+
+// RemoveFavorite removes a park from a user's favorites
+func (db *DB) RemoveFavorite(ctx context.Context, userID int, parkCode string) error {
+	query := `
+		DELETE FROM user_favorites
+		WHERE user_id = ? AND park_id = (SELECT id FROM parks WHERE park_code = ?)
+	`
+	if _, err := db.ExecContext(ctx, query, userID, parkCode); err != nil {
+		return fmt.Errorf("failed to remove favorite: %w", err)
+	}
+	return nil
+}
+
+// GetFavoriteParkCodes retrieves the park codes a user has favorited
+func (db *DB) GetFavoriteParkCodes(ctx context.Context, userID int) ([]string, error) {
+	query := `
+		SELECT p.park_code FROM user_favorites f
+		JOIN parks p ON p.id = f.park_id
+		WHERE f.user_id = ? ORDER BY f.created_at DESC, f.rowid DESC
+	`
+
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query favorites: %w", err)
+	}
+	defer rows.Close()
+
+	codes := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("failed to scan favorite: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+// GetFavoriteParks retrieves a user's favorite parks with their images, most recently saved first
+func (db *DB) GetFavoriteParks(ctx context.Context, userID int) ([]CachedPark, error) {
+	query := `
+		SELECT p.id, p.park_code, p.name, p.full_name, p.slug, p.states, p.designation, p.description,
+			   p.weather_info, p.directions_info, p.url, p.directions_url, p.latitude, p.longitude,
+			   p.lat_long, p.relevance_score, p.api_data, p.created_at, p.updated_at, p.last_fetched_at
+		FROM user_favorites f
+		JOIN parks p ON p.id = f.park_id
+		WHERE f.user_id = ? ORDER BY f.created_at DESC, f.rowid DESC
+	`
+
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query favorite parks: %w", err)
+	}
+	defer rows.Close()
+
+	var parks []CachedPark
+	for rows.Next() {
+		var park CachedPark
+		err := rows.Scan(
+			&park.ID, &park.ParkCode, &park.Name, &park.FullName, &park.Slug,
+			&park.States, &park.Designation, &park.Description, &park.WeatherInfo,
+			&park.DirectionsInfo, &park.URL, &park.DirectionsURL, &park.Latitude,
+			&park.Longitude, &park.LatLong, &park.RelevanceScore, &park.APIData,
+			&park.CreatedAt, &park.UpdatedAt, &park.LastFetchedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan park: %w", err)
+		}
+		parks = append(parks, park)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read favorite parks: %w", err)
+	}
+
+	// Load images for each park
+	for i := range parks {
+		images, err := db.GetParkImages(ctx, parks[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get park images: %w", err)
+		}
+		parks[i].Images = images
+	}
+
+	return parks, nil
+}
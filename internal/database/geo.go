@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusMiles is the mean radius of the Earth, distances are in miles
+const earthRadiusMiles = 3958.8
+
+// NearbyPark is a cached park with its distance from a search point
+type NearbyPark struct {
+	CachedPark
+	DistanceMiles float64 `json:"distance_miles"`
+}
+
+// ParseCoordinates parses the NPS latitude and longitude strings, falling back to the
+// latLong string ("lat:44.59824417, long:-110.5471695") when they are missing
+func ParseCoordinates(latitude, longitude, latLong string) (lat, lng float64, ok bool) {
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+	if latErr == nil && lngErr == nil && ValidCoordinates(lat, lng) {
+		return lat, lng, true
+	}
+
+	var haveLat, haveLng bool
+	for _, part := range strings.Split(latLong, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), ":")
+		if !found {
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "lat":
+			lat, haveLat = f, true
+		case "long", "lng":
+			lng, haveLng = f, true
+		}
+	}
+	if haveLat && haveLng && ValidCoordinates(lat, lng) {
+		return lat, lng, true
+	}
+	return 0, 0, false
+}
+
+// ValidCoordinates reports whether lat and lng are a point on the globe
+func ValidCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// Coordinates returns the park's parsed coordinates
+func (p *CachedPark) Coordinates() (lat, lng float64, ok bool) {
+	return ParseCoordinates(p.Latitude, p.Longitude, p.LatLong)
+}
+
+// DistanceMiles returns the great-circle distance between two points using the haversine formula
+func DistanceMiles(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// GetParksNear retrieves the parks within radiusMiles of a point, nearest first.
+// A limit of 0 or less returns every park in range.
+func (db *DB) GetParksNear(ctx context.Context, lat, lng, radiusMiles float64, limit int) ([]NearbyPark, error) {
+	if !ValidCoordinates(lat, lng) {
+		return nil, fmt.Errorf("invalid coordinates %f,%f", lat, lng)
+	}
+	if radiusMiles <= 0 {
+		return nil, fmt.Errorf("invalid radius %f", radiusMiles)
+	}
+
+	// Prefilter with a bounding box on the lat/lng index, then compute exact distances
+	where, args := boundingBox(lat, lng, radiusMiles)
+	query := `
+		SELECT id, park_code, name, full_name, slug, states, designation, description,
+			   weather_info, directions_info, url, directions_url, latitude, longitude,
+			   lat_long, relevance_score, api_data, created_at, updated_at, last_fetched_at,
+			   lat, lng
+		FROM parks WHERE ` + where
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearby parks: %w", err)
+	}
+	defer rows.Close()
+
+	var parks []NearbyPark
+	for rows.Next() {
+		var park NearbyPark
+		var parkLat, parkLng float64
+		err := rows.Scan(
+			&park.ID, &park.ParkCode, &park.Name, &park.FullName, &park.Slug,
+			&park.States, &park.Designation, &park.Description, &park.WeatherInfo,
+			&park.DirectionsInfo, &park.URL, &park.DirectionsURL, &park.Latitude,
+			&park.Longitude, &park.LatLong, &park.RelevanceScore, &park.APIData,
+			&park.CreatedAt, &park.UpdatedAt, &park.LastFetchedAt,
+			&parkLat, &parkLng,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan park: %w", err)
+		}
+
+		park.DistanceMiles = DistanceMiles(lat, lng, parkLat, parkLng)
+		if park.DistanceMiles <= radiusMiles {
+			parks = append(parks, park)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read nearby parks: %w", err)
+	}
+
+	sort.SliceStable(parks, func(i, j int) bool {
+		return parks[i].DistanceMiles < parks[j].DistanceMiles
+	})
+	if limit > 0 && len(parks) > limit {
+		parks = parks[:limit]
+	}
+
+	// Only load images for the parks being returned
+	for i := range parks {
+		images, err := db.GetParkImages(ctx, parks[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get park images: %w", err)
+		}
+		parks[i].Images = images
+	}
+
+	return parks, nil
+}
+
+// boundingBox returns a WHERE clause matching the lat/lng box around a circle of radiusMiles,
+// split in two where it crosses the antimeridian
+func boundingBox(lat, lng, radiusMiles float64) (string, []interface{}) {
+	angular := radiusMiles / earthRadiusMiles
+	latDelta := angular * 180 / math.Pi
+	minLat, maxLat := lat-latDelta, lat+latDelta
+
+	// The circle covers a pole, or is wide enough to wrap the globe, so every longitude is in range
+	sinRatio := math.Sin(angular) / math.Cos(lat*math.Pi/180)
+	if minLat <= -90 || maxLat >= 90 || angular >= math.Pi/2 || sinRatio >= 1 {
+		return "lat BETWEEN ? AND ? AND lng IS NOT NULL", []interface{}{math.Max(minLat, -90), math.Min(maxLat, 90)}
+	}
+
+	lngDelta := math.Asin(sinRatio) * 180 / math.Pi
+	minLng, maxLng := lng-lngDelta, lng+lngDelta
+	switch {
+	case minLng < -180:
+		return "lat BETWEEN ? AND ? AND (lng >= ? OR lng <= ?)", []interface{}{minLat, maxLat, minLng + 360, maxLng}
+	case maxLng > 180:
+		return "lat BETWEEN ? AND ? AND (lng >= ? OR lng <= ?)", []interface{}{minLat, maxLat, minLng, maxLng - 360}
+	default:
+		return "lat BETWEEN ? AND ? AND lng BETWEEN ? AND ?", []interface{}{minLat, maxLat, minLng, maxLng}
+	}
+}
+
+// coordinateColumns converts parsed coordinates to nullable column values
+func coordinateColumns(latitude, longitude, latLong string) (sql.NullFloat64, sql.NullFloat64) {
+	lat, lng, ok := ParseCoordinates(latitude, longitude, latLong)
+	if !ok {
+		return sql.NullFloat64{}, sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: lat, Valid: true}, sql.NullFloat64{Float64: lng, Valid: true}
+}
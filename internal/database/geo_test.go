@@ -0,0 +1,134 @@
+package database
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestDistanceMiles(t *testing.T) {
+	// A degree of arc on a great circle
+	degree := 2 * math.Pi * earthRadiusMiles / 360
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want                   float64
+	}{
+		{"same point", 37.7, -119.6, 37.7, -119.6, 0},
+		{"a degree of latitude", 37, -119, 38, -119, degree},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, degree},
+		{"over the pole", 89.5, 0, 89.5, 180, degree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DistanceMiles(tt.lat1, tt.lng1, tt.lat2, tt.lng2); math.Abs(got-tt.want) > 1 {
+				t.Errorf("got %.2f miles, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		radius   float64
+		where    string
+		check    func(args []float64) bool
+	}{
+		{
+			name: "inside the globe", lat: 37.7, lng: -119.6, radius: 100,
+			where: "lat BETWEEN ? AND ? AND lng BETWEEN ? AND ?",
+			check: func(a []float64) bool { return a[0] < 37.7 && a[1] > 37.7 && a[2] < -119.6 && a[3] > -119.6 },
+		},
+		{
+			name: "east edge over the antimeridian", lat: 0, lng: 179.9, radius: 50,
+			where: "lat BETWEEN ? AND ? AND (lng >= ? OR lng <= ?)",
+			check: func(a []float64) bool { return a[2] < 179.9 && a[3] > -180 && a[3] < -179 },
+		},
+		{
+			name: "west edge over the antimeridian", lat: 0, lng: -179.9, radius: 50,
+			where: "lat BETWEEN ? AND ? AND (lng >= ? OR lng <= ?)",
+			check: func(a []float64) bool { return a[2] > 179 && a[2] < 180 && a[3] > -179.9 },
+		},
+		{
+			name: "covering a pole", lat: 89.5, lng: 0, radius: 100,
+			where: "lat BETWEEN ? AND ? AND lng IS NOT NULL",
+			check: func(a []float64) bool { return a[0] < 89.5 && a[1] == 90 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := boundingBox(tt.lat, tt.lng, tt.radius)
+			if where != tt.where {
+				t.Fatalf("got %q, want %q", where, tt.where)
+			}
+			var values []float64
+			for _, arg := range args {
+				values = append(values, arg.(float64))
+			}
+			if !tt.check(values) {
+				t.Errorf("got bounds %v", values)
+			}
+		})
+	}
+}
+
+func TestGetParksNear(t *testing.T) {
+	db := newTestDB(t)
+	ctx := t.Context()
+
+	parks := []struct {
+		code     string
+		lat, lng float64
+	}{
+		{"east", 0, 179.9},
+		{"west", 0, -179.9},
+		{"far", 0, 170},
+		{"pole", 89.5, 180},
+		{"none", 0, 0}, // No coordinates, never near anything
+	}
+	for _, p := range parks {
+		park := map[string]interface{}{"parkCode": p.code, "name": p.code}
+		if p.code != "none" {
+			park["latitude"] = strconv.FormatFloat(p.lat, 'f', -1, 64)
+			park["longitude"] = strconv.FormatFloat(p.lng, 'f', -1, 64)
+		}
+		if _, err := db.UpsertPark(ctx, park, p.code); err != nil {
+			t.Fatalf("failed to add park %s: %v", p.code, err)
+		}
+	}
+
+	codes := func(lat, lng, radius float64, limit int) []string {
+		t.Helper()
+		near, err := db.GetParksNear(ctx, lat, lng, radius, limit)
+		if err != nil {
+			t.Fatalf("failed to get parks near %f,%f: %v", lat, lng, err)
+		}
+		var codes []string
+		for i, park := range near {
+			if i > 0 && park.DistanceMiles < near[i-1].DistanceMiles {
+				t.Errorf("parks aren't nearest first: %v", near)
+			}
+			codes = append(codes, park.ParkCode)
+		}
+		return codes
+	}
+
+	if got := codes(0, 179.95, 50, 0); !slices.Equal(got, []string{"east", "west"}) {
+		t.Errorf("got %v across the antimeridian, want [east west]", got)
+	}
+	if got := codes(0, -179.99, 50, 1); !slices.Equal(got, []string{"west"}) {
+		t.Errorf("got %v with a limit of 1, want the nearest", got)
+	}
+	if got := codes(89.5, 0, 100, 0); !slices.Equal(got, []string{"pole"}) {
+		t.Errorf("got %v over the pole, want [pole]", got)
+	}
+	if _, err := db.GetParksNear(ctx, 91, 0, 10, 0); err == nil {
+		t.Error("searched around invalid coordinates")
+	}
+	if _, err := db.GetParksNear(ctx, 0, 0, 0, 0); err == nil {
+		t.Error("searched with a zero radius")
+	}
+}
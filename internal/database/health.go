@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CacheFreshness summarizes how up to date one type of cached per-park data is
+type CacheFreshness struct {
+	Table         string     `json:"table"`
+	DataType      string     `json:"data_type"`
+	Rows          int        `json:"rows"`
+	Stale         int        `json:"stale"`
+	OldestFetched *time.Time `json:"oldest_fetched_at,omitempty"`
+	NewestFetched *time.Time `json:"newest_fetched_at,omitempty"`
+}
+
+// Ready reports why the database can't serve requests yet: it's unreachable, its schema isn't
+// fully migrated, or the park list hasn't been synced
+func (db *DB) Ready(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version < latest {
+		return fmt.Errorf("schema at version %d, want %d", version, latest)
+	}
+	count, err := db.CountParks(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("parks have not been synced yet")
+	}
+	return nil
+}
+
+// CountParks returns the number of parks in the local catalog
+func (db *DB) CountParks(ctx context.Context) (int, error) {
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM parks").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count parks: %w", err)
+	}
+	return count, nil
+}
+
+// GetCacheFreshness counts the cached rows of dataType in tableName, how many are older than
+// maxAge, and when the oldest and newest were fetched
+func (db *DB) GetCacheFreshness(ctx context.Context, tableName, dataType string, maxAge time.Duration) (*CacheFreshness, error) {
+	freshness := &CacheFreshness{Table: tableName, DataType: dataType}
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*), COUNT(CASE WHEN last_fetched_at < datetime('now', ?) THEN 1 END)
+		FROM %s WHERE data_type = ?
+	`, tableName)
+	maxAgeModifier := fmt.Sprintf("-%d seconds", int(maxAge.Seconds()))
+	if err := db.QueryRowContext(ctx, query, maxAgeModifier, dataType).Scan(&freshness.Rows, &freshness.Stale); err != nil {
+		return nil, fmt.Errorf("failed to count %s %s: %w", tableName, dataType, err)
+	}
+	if freshness.Rows == 0 {
+		return freshness, nil
+	}
+
+	// MIN and MAX would lose the column type, so the oldest and newest rows are read by ordering
+	for _, order := range []string{"ASC", "DESC"} {
+		query := fmt.Sprintf(`
+			SELECT last_fetched_at FROM %s WHERE data_type = ? ORDER BY last_fetched_at %s LIMIT 1
+		`, tableName, order)
+		var fetched sql.NullTime
+		if err := db.QueryRowContext(ctx, query, dataType).Scan(&fetched); err != nil {
+			return nil, fmt.Errorf("failed to get %s %s freshness: %w", tableName, dataType, err)
+		}
+		if !fetched.Valid {
+			continue
+		}
+		if order == "ASC" {
+			freshness.OldestFetched = &fetched.Time
+		} else {
+			freshness.NewestFetched = &fetched.Time
+		}
+	}
+	return freshness, nil
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ztkent/parks-explorer/internal/metrics"
+)
+
+// ExecContext, QueryContext and QueryRowContext shadow the *sql.DB methods to time queries by
+// the function running them, e.g. GetParkBySlug. Statements inside transactions aren't timed.
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer metrics.ObserveDBQuery(callerName(), time.Now())
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer metrics.ObserveDBQuery(callerName(), time.Now())
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer metrics.ObserveDBQuery(callerName(), time.Now())
+	return db.DB.QueryRowContext(ctx, query, args...)
+}
+
+// callerName returns the name of the function running the query, without its package,
+// receiver or closure suffixes
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	parts := strings.Split(name, ".")
+	// Skip the package and receiver, and stop at closures like func1
+	for _, part := range parts[1:] {
+		if strings.HasPrefix(part, "(") || part == "" {
+			continue
+		}
+		return part
+	}
+	return parts[len(parts)-1]
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// withMigrations loads migrations from the embedded files plus extra, until the test ends
+func withMigrations(t *testing.T, extra fstest.MapFS) {
+	t.Helper()
+	files := fstest.MapFS{}
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		data, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["migrations/"+entry.Name()] = &fstest.MapFile{Data: data}
+	}
+	for name, file := range extra {
+		files[name] = file
+	}
+
+	migrationsFS = files
+	t.Cleanup(func() { migrationsFS = migrationFiles })
+}
+
+// requireFullyMigrated fails the test unless every embedded migration is applied
+func requireFullyMigrated(t *testing.T, db *DB) []Migration {
+	t.Helper()
+	migrations, err := db.MigrationStatus(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get migration status: %v", err)
+	}
+	for _, m := range migrations {
+		if m.AppliedAt == nil {
+			t.Errorf("migration %s wasn't applied", m.Name)
+		}
+	}
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := db.SchemaVersion(t.Context()); err != nil || version != latest {
+		t.Errorf("got schema version %d, err %v, want %d", version, err, latest)
+	}
+	return migrations
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	db := newTestDB(t)
+	migrations := requireFullyMigrated(t, db)
+
+	// Running again is a no-op
+	if err := db.Migrate(t.Context()); err != nil {
+		t.Fatalf("failed to migrate again: %v", err)
+	}
+	again := requireFullyMigrated(t, db)
+	for i := range migrations {
+		if !again[i].AppliedAt.Equal(*migrations[i].AppliedAt) {
+			t.Errorf("migration %s was applied again", migrations[i].Name)
+		}
+	}
+}
+
+func TestMigrateBaselinesLegacyDatabase(t *testing.T) {
+	// A database created by the old schema.sql has the initial tables but no schema_migrations
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial, err := fs.ReadFile(migrationFiles, "migrations/0001_initial_schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := legacy.Exec(string(initial)); err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+	if _, err := legacy.Exec("INSERT INTO parks (park_code, name, full_name, slug, api_data) VALUES ('yose', 'Yosemite', 'Yosemite National Park', 'yosemite', '{}')"); err != nil {
+		t.Fatalf("failed to insert legacy park: %v", err)
+	}
+	legacy.Close()
+
+	db, err := NewDatabase(path, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("failed to open legacy database: %v", err)
+	}
+	defer db.Close()
+	requireFullyMigrated(t, db)
+	if _, err := db.GetParkIDByCode(t.Context(), "yose"); err != nil {
+		t.Errorf("legacy park was lost: %v", err)
+	}
+}
+
+func TestMigrateRollsBackFailedMigration(t *testing.T) {
+	db := newTestDB(t)
+	before, err := db.SchemaVersion(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withMigrations(t, fstest.MapFS{
+		"migrations/9999_broken.sql": &fstest.MapFile{Data: []byte(`
+CREATE TABLE half_applied (id INTEGER PRIMARY KEY);
+INSERT INTO missing_table VALUES (1);
+`)},
+	})
+	err = db.Migrate(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "9999_broken") {
+		t.Fatalf("got %v, want the broken migration to fail", err)
+	}
+
+	var name string
+	if err := db.QueryRow("SELECT name FROM sqlite_master WHERE name = 'half_applied'").Scan(&name); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("the failed migration's table was kept: %v", err)
+	}
+	if version, err := db.SchemaVersion(t.Context()); err != nil || version != before {
+		t.Errorf("got schema version %d, err %v, want %d", version, err, before)
+	}
+}
+
+func TestForeignKeysEnforced(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.Exec("INSERT INTO user_favorites (user_id, park_id) VALUES (1, 1)"); err == nil {
+		t.Error("inserted a favorite for a missing user and park")
+	}
+
+	result, err := db.Exec("INSERT INTO users (email, username, google_id, avatar_url) VALUES ('hiker@example.com', 'hiker', 'g-1', '')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, _ := result.LastInsertId()
+	trip := &Trip{UserID: int(userID), Name: "Sierra loop", Days: 2}
+	if err := db.CreateTrip(t.Context(), trip); err != nil {
+		t.Fatalf("failed to create trip: %v", err)
+	}
+	if err := db.AddTripItem(t.Context(), &TripItem{TripID: trip.ID, Day: 1, ItemType: "park", ItemID: "yose", Title: "Yosemite"}); err != nil {
+		t.Fatalf("failed to add trip item: %v", err)
+	}
+
+	// Deleting the user cascades to their trips and the trips' items
+	if _, err := db.Exec("DELETE FROM users WHERE id = ?", userID); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	var items int
+	if err := db.QueryRow("SELECT COUNT(*) FROM trip_items").Scan(&items); err != nil || items != 0 {
+		t.Errorf("got %d trip items, err %v, want the cascade to delete them", items, err)
+	}
+}
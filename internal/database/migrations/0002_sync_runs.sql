@@ -0,0 +1,16 @@
+-- Sync Runs Table recording each background NPS sync pass
+-- IF NOT EXISTS: databases created before migrations may already have this table
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL, -- 'parks', 'park_data'
+    status TEXT NOT NULL DEFAULT 'running', -- 'running', 'success', 'partial', 'failed'
+    started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME,
+    parks_synced INTEGER DEFAULT 0,
+    items_synced INTEGER DEFAULT 0,
+    api_calls INTEGER DEFAULT 0,
+    error_count INTEGER DEFAULT 0,
+    errors TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_kind_started ON sync_runs(kind, started_at);
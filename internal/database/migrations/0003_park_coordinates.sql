@@ -0,0 +1,9 @@
+-- Numeric park coordinates for distance queries, parsed from the NPS latitude/longitude strings
+ALTER TABLE parks ADD COLUMN lat REAL;
+ALTER TABLE parks ADD COLUMN lng REAL;
+
+UPDATE parks SET lat = CAST(TRIM(latitude) AS REAL), lng = CAST(TRIM(longitude) AS REAL)
+WHERE TRIM(latitude) GLOB '*[0-9]*' AND TRIM(longitude) GLOB '*[0-9]*';
+
+-- Bounding-box prefilter for nearby searches
+CREATE INDEX idx_parks_lat_lng ON parks(lat, lng);
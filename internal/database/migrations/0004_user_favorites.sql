@@ -0,0 +1,11 @@
+-- User Favorites Table for parks saved by signed-in users
+CREATE TABLE user_favorites (
+    user_id INTEGER NOT NULL,
+    park_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, park_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (park_id) REFERENCES parks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_favorites_park ON user_favorites(park_id);
@@ -0,0 +1,32 @@
+-- Trips Table for multi-park itineraries planned by signed-in users
+CREATE TABLE trips (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    start_date TEXT, -- YYYY-MM-DD, optional
+    days INTEGER NOT NULL DEFAULT 1,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_trips_user ON trips(user_id);
+
+-- Trip Items Table for the parks, things to do, campgrounds and events on each day of a trip
+CREATE TABLE trip_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    trip_id INTEGER NOT NULL,
+    day INTEGER NOT NULL, -- 1-based day of the trip
+    position INTEGER NOT NULL, -- 0-based order within the day
+    item_type TEXT NOT NULL, -- 'park', 'things_to_do', 'campground', 'event'
+    item_id TEXT NOT NULL, -- park code or NPS ID
+    park_code TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL,
+    url TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (trip_id) REFERENCES trips(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_trip_items_trip_day ON trip_items(trip_id, day, position);
@@ -1,7 +1,7 @@
 package database
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -60,7 +60,7 @@ type CachedParkData struct {
 }
 
 // UpsertPark inserts or updates a park in the database
-func (db *DB) UpsertPark(parkData interface{}, slug string) (*CachedPark, error) {
+func (db *DB) UpsertPark(ctx context.Context, parkData interface{}, slug string) (*CachedPark, error) {
 	// Convert park data to JSON for storage
 	apiDataJSON, err := json.Marshal(parkData)
 	if err != nil {
@@ -93,20 +93,29 @@ func (db *DB) UpsertPark(parkData interface{}, slug string) (*CachedPark, error)
 	longitude := getString(parkMap, "longitude")
 	latLong := getString(parkMap, "latLong")
 	relevanceScore := getFloat64(parkMap, "relevanceScore")
+	lat, lng := coordinateColumns(latitude, longitude, latLong)
 
-	// Upsert park using SQLite syntax
+	// Upsert park in place, so re-syncs keep the park ID that cached data is keyed by
 	query := `
-		INSERT OR REPLACE INTO parks (
+		INSERT INTO parks (
 			park_code, name, full_name, slug, states, designation, description,
 			weather_info, directions_info, url, directions_url, latitude, longitude,
-			lat_long, relevance_score, api_data, updated_at, last_fetched_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+			lat_long, lat, lng, relevance_score, api_data, updated_at, last_fetched_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT(park_code) DO UPDATE SET
+			name = excluded.name, full_name = excluded.full_name, slug = excluded.slug,
+			states = excluded.states, designation = excluded.designation, description = excluded.description,
+			weather_info = excluded.weather_info, directions_info = excluded.directions_info,
+			url = excluded.url, directions_url = excluded.directions_url, latitude = excluded.latitude,
+			longitude = excluded.longitude, lat_long = excluded.lat_long, lat = excluded.lat, lng = excluded.lng,
+			relevance_score = excluded.relevance_score, api_data = excluded.api_data,
+			updated_at = CURRENT_TIMESTAMP, last_fetched_at = CURRENT_TIMESTAMP
 	`
 
-	result, err := db.Exec(query,
+	result, err := db.ExecContext(ctx, query,
 		parkCode, name, fullName, slug, states, designation, description,
 		weatherInfo, directionsInfo, url, directionsURL, latitude, longitude,
-		latLong, relevanceScore, string(apiDataJSON))
+		latLong, lat, lng, relevanceScore, string(apiDataJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert park: %w", err)
 	}
@@ -114,7 +123,7 @@ func (db *DB) UpsertPark(parkData interface{}, slug string) (*CachedPark, error)
 	// Get the park ID
 	var parkID int64
 	if parkCode != "" {
-		row := db.QueryRow("SELECT id FROM parks WHERE park_code = ?", parkCode)
+		row := db.QueryRowContext(ctx, "SELECT id FROM parks WHERE park_code = ?", parkCode)
 		if err := row.Scan(&parkID); err != nil {
 			return nil, fmt.Errorf("failed to get park ID: %w", err)
 		}
@@ -127,19 +136,26 @@ func (db *DB) UpsertPark(parkData interface{}, slug string) (*CachedPark, error)
 
 	// Handle images
 	if images, ok := parkMap["images"].([]interface{}); ok {
-		if err := db.UpsertParkImages(int(parkID), images); err != nil {
+		if err := db.UpsertParkImages(ctx, int(parkID), images); err != nil {
 			return nil, fmt.Errorf("failed to upsert park images: %w", err)
 		}
 	}
 
 	// Return the cached park
-	return db.GetParkByID(int(parkID))
+	park, err := db.GetParkByID(ctx, int(parkID))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.indexPark(ctx, park); err != nil {
+		db.logger.Warn("Failed to index park for search", "park", parkCode, "error", err)
+	}
+	return park, nil
 }
 
 // UpsertParkImages inserts or updates park images
-func (db *DB) UpsertParkImages(parkID int, images []interface{}) error {
+func (db *DB) UpsertParkImages(ctx context.Context, parkID int, images []interface{}) error {
 	// Delete existing images for this park
-	_, err := db.Exec("DELETE FROM park_images WHERE park_id = ?", parkID)
+	_, err := db.ExecContext(ctx, "DELETE FROM park_images WHERE park_id = ?", parkID)
 	if err != nil {
 		return fmt.Errorf("failed to delete existing images: %w", err)
 	}
@@ -158,7 +174,7 @@ func (db *DB) UpsertParkImages(parkID int, images []interface{}) error {
 			caption := getString(imgMap, "caption")
 			credit := getString(imgMap, "credit")
 
-			_, err := db.Exec(query, parkID, url, title, altText, caption, credit, i)
+			_, err := db.ExecContext(ctx, query, parkID, url, title, altText, caption, credit, i)
 			if err != nil {
 				return fmt.Errorf("failed to insert image: %w", err)
 			}
@@ -169,7 +185,7 @@ func (db *DB) UpsertParkImages(parkID int, images []interface{}) error {
 }
 
 // GetParkByID retrieves a park by ID with its images
-func (db *DB) GetParkByID(id int) (*CachedPark, error) {
+func (db *DB) GetParkByID(ctx context.Context, id int) (*CachedPark, error) {
 	query := `
 		SELECT id, park_code, name, full_name, slug, states, designation, description,
 			   weather_info, directions_info, url, directions_url, latitude, longitude,
@@ -178,7 +194,7 @@ func (db *DB) GetParkByID(id int) (*CachedPark, error) {
 	`
 
 	var park CachedPark
-	row := db.QueryRow(query, id)
+	row := db.QueryRowContext(ctx, query, id)
 	err := row.Scan(
 		&park.ID, &park.ParkCode, &park.Name, &park.FullName, &park.Slug,
 		&park.States, &park.Designation, &park.Description, &park.WeatherInfo,
@@ -191,7 +207,7 @@ func (db *DB) GetParkByID(id int) (*CachedPark, error) {
 	}
 
 	// Load images
-	images, err := db.GetParkImages(id)
+	images, err := db.GetParkImages(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get park images: %w", err)
 	}
@@ -201,7 +217,7 @@ func (db *DB) GetParkByID(id int) (*CachedPark, error) {
 }
 
 // GetParkBySlug retrieves a park by slug with its images
-func (db *DB) GetParkBySlug(slug string) (*CachedPark, error) {
+func (db *DB) GetParkBySlug(ctx context.Context, slug string) (*CachedPark, error) {
 	query := `
 		SELECT id, park_code, name, full_name, slug, states, designation, description,
 			   weather_info, directions_info, url, directions_url, latitude, longitude,
@@ -210,7 +226,7 @@ func (db *DB) GetParkBySlug(slug string) (*CachedPark, error) {
 	`
 
 	var park CachedPark
-	row := db.QueryRow(query, slug)
+	row := db.QueryRowContext(ctx, query, slug)
 	err := row.Scan(
 		&park.ID, &park.ParkCode, &park.Name, &park.FullName, &park.Slug,
 		&park.States, &park.Designation, &park.Description, &park.WeatherInfo,
@@ -223,7 +239,7 @@ func (db *DB) GetParkBySlug(slug string) (*CachedPark, error) {
 	}
 
 	// Load images
-	images, err := db.GetParkImages(park.ID)
+	images, err := db.GetParkImages(ctx, park.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get park images: %w", err)
 	}
@@ -233,13 +249,13 @@ func (db *DB) GetParkBySlug(slug string) (*CachedPark, error) {
 }
 
 // GetParkImages retrieves images for a park
-func (db *DB) GetParkImages(parkID int) ([]ParkImage, error) {
+func (db *DB) GetParkImages(ctx context.Context, parkID int) ([]ParkImage, error) {
 	query := `
 		SELECT id, park_id, url, title, alt_text, caption, credit, image_order, created_at
 		FROM park_images WHERE park_id = ? ORDER BY image_order
 	`
 
-	rows, err := db.Query(query, parkID)
+	rows, err := db.QueryContext(ctx, query, parkID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query park images: %w", err)
 	}
@@ -260,12 +276,12 @@ func (db *DB) GetParkImages(parkID int) ([]ParkImage, error) {
 }
 
 // GetAllParks retrieves all cached parks
-func (db *DB) GetAllParks() ([]CachedPark, error) {
-	return db.GetParksWithPagination(0, -1) // -1 means no limit for backward compatibility
+func (db *DB) GetAllParks(ctx context.Context) ([]CachedPark, error) {
+	return db.GetParksWithPagination(ctx, 0, -1) // -1 means no limit for backward compatibility
 }
 
 // GetParksWithPagination retrieves cached parks with pagination support
-func (db *DB) GetParksWithPagination(offset, limit int) ([]CachedPark, error) {
+func (db *DB) GetParksWithPagination(ctx context.Context, offset, limit int) ([]CachedPark, error) {
 	query := `
 		SELECT id, park_code, name, full_name, slug, states, designation, description,
 			   weather_info, directions_info, url, directions_url, latitude, longitude,
@@ -279,7 +295,7 @@ func (db *DB) GetParksWithPagination(offset, limit int) ([]CachedPark, error) {
 		args = append(args, limit, offset)
 	}
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query parks: %w", err)
 	}
@@ -298,22 +314,36 @@ func (db *DB) GetParksWithPagination(offset, limit int) ([]CachedPark, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan park: %w", err)
 		}
+		parks = append(parks, park)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read parks: %w", err)
+	}
+	// Close before the image queries, a read held open while a writer waits would block them
+	rows.Close()
 
-		// Load images for each park
-		images, err := db.GetParkImages(park.ID)
+	// Load images for each park
+	for i := range parks {
+		images, err := db.GetParkImages(ctx, parks[i].ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get park images: %w", err)
 		}
-		park.Images = images
-
-		parks = append(parks, park)
+		parks[i].Images = images
 	}
 
 	return parks, nil
 }
 
-// SearchParks searches cached parks by name
-func (db *DB) SearchParks(query string) ([]CachedPark, error) {
+// SearchParks searches cached parks, ranked by the full-text index when available
+func (db *DB) SearchParks(ctx context.Context, query string) ([]CachedPark, error) {
+	if db.searchEnabled {
+		parks, err := db.searchParksFullText(ctx, query)
+		if err == nil {
+			return parks, nil
+		}
+		db.logger.Warn("Full-text park search failed, falling back to LIKE", "error", err)
+	}
+
 	searchQuery := `
 		SELECT id, park_code, name, full_name, slug, states, designation, description,
 			   weather_info, directions_info, url, directions_url, latitude, longitude,
@@ -332,7 +362,7 @@ func (db *DB) SearchParks(query string) ([]CachedPark, error) {
 	searchTerm := "%" + strings.ToLower(query) + "%"
 	exactTerm := strings.ToLower(query) + "%"
 
-	rows, err := db.Query(searchQuery, searchTerm, searchTerm, searchTerm, exactTerm, exactTerm)
+	rows, err := db.QueryContext(ctx, searchQuery, searchTerm, searchTerm, searchTerm, exactTerm, exactTerm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search parks: %w", err)
 	}
@@ -351,17 +381,45 @@ func (db *DB) SearchParks(query string) ([]CachedPark, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan park: %w", err)
 		}
+		parks = append(parks, park)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read park search results: %w", err)
+	}
+	// Close before the image queries, a read held open while a writer waits would block them
+	rows.Close()
 
-		// Load images for each park
-		images, err := db.GetParkImages(park.ID)
+	// Load images for each park
+	for i := range parks {
+		images, err := db.GetParkImages(ctx, parks[i].ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get park images: %w", err)
 		}
-		park.Images = images
+		parks[i].Images = images
+	}
 
-		parks = append(parks, park)
+	return parks, nil
+}
+
+// searchParksFullText returns the parks matching query, in BM25 rank order
+func (db *DB) searchParksFullText(ctx context.Context, query string) ([]CachedPark, error) {
+	results, err := db.Search(ctx, query, []string{EntityPark}, 100, 0)
+	if err != nil {
+		return nil, err
 	}
 
+	parks := make([]CachedPark, 0, len(results))
+	for _, result := range results {
+		parkID, err := db.GetParkIDByCode(ctx, result.ParkCode)
+		if err != nil {
+			continue
+		}
+		park, err := db.GetParkByID(ctx, parkID)
+		if err != nil {
+			return nil, err
+		}
+		parks = append(parks, *park)
+	}
 	return parks, nil
 }
 
@@ -392,10 +450,10 @@ func getFloat64(m map[string]interface{}, key string) float64 {
 }
 
 // GetParkIDByCode retrieves park ID by park code
-func (db *DB) GetParkIDByCode(parkCode string) (int, error) {
+func (db *DB) GetParkIDByCode(ctx context.Context, parkCode string) (int, error) {
 	var parkID int
 	query := "SELECT id FROM parks WHERE park_code = ?"
-	err := db.QueryRow(query, parkCode).Scan(&parkID)
+	err := db.QueryRowContext(ctx, query, parkCode).Scan(&parkID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get park ID for code %s: %w", parkCode, err)
 	}
@@ -403,7 +461,7 @@ func (db *DB) GetParkIDByCode(parkCode string) (int, error) {
 }
 
 // UpsertParkData inserts or updates cached park data
-func (db *DB) UpsertParkData(parkID int, dataType, tableName string, data interface{}) error {
+func (db *DB) UpsertParkData(ctx context.Context, parkID int, dataType, tableName string, data interface{}) error {
 	apiDataJSON, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
@@ -415,22 +473,32 @@ func (db *DB) UpsertParkData(parkID int, dataType, tableName string, data interf
 		VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`, tableName)
 
-	_, err = db.Exec(query, parkID, dataType, string(apiDataJSON))
+	_, err = db.ExecContext(ctx, query, parkID, dataType, string(apiDataJSON))
 	if err != nil {
 		return fmt.Errorf("failed to upsert park data: %w", err)
 	}
+
+	// Keep the search index in step with the cache
+	if _, ok := indexedParkData[dataType]; ok && db.searchEnabled {
+		var parkCode string
+		if err := db.QueryRowContext(ctx, "SELECT park_code FROM parks WHERE id = ?", parkID).Scan(&parkCode); err == nil {
+			if err := db.indexParkData(ctx, parkID, parkCode, dataType, tableName, apiDataJSON); err != nil {
+				db.logger.Warn("Failed to index park data for search", "data_type", dataType, "park", parkCode, "error", err)
+			}
+		}
+	}
 	return nil
 }
 
 // GetCachedParkData retrieves cached park data
-func (db *DB) GetCachedParkData(parkID int, dataType, tableName string) (*CachedParkData, error) {
+func (db *DB) GetCachedParkData(ctx context.Context, parkID int, dataType, tableName string) (*CachedParkData, error) {
 	query := fmt.Sprintf(`
 		SELECT id, park_id, data_type, api_data, created_at, updated_at, last_fetched_at
 		FROM %s WHERE park_id = ? AND data_type = ?
 	`, tableName)
 
 	var data CachedParkData
-	row := db.QueryRow(query, parkID, dataType)
+	row := db.QueryRowContext(ctx, query, parkID, dataType)
 	err := row.Scan(
 		&data.ID, &data.ParkID, &data.DataType, &data.APIData,
 		&data.CreatedAt, &data.UpdatedAt, &data.LastFetchedAt,
@@ -442,7 +510,7 @@ func (db *DB) GetCachedParkData(parkID int, dataType, tableName string) (*Cached
 }
 
 // IsParkDataStale checks if specific park data needs refreshing
-func (db *DB) IsParkDataStale(parkID int, dataType, tableName string, maxAge time.Duration) (bool, error) {
+func (db *DB) IsParkDataStale(ctx context.Context, parkID int, dataType, tableName string, maxAge time.Duration) (bool, error) {
 	query := fmt.Sprintf(`
 		SELECT last_fetched_at FROM %s 
 	`, tableName)
@@ -455,7 +523,7 @@ func (db *DB) IsParkDataStale(parkID int, dataType, tableName string, maxAge tim
 	}
 
 	var lastFetched time.Time
-	err := db.QueryRow(query, parkID, dataType).Scan(&lastFetched)
+	err := db.QueryRowContext(ctx, query, parkID, dataType).Scan(&lastFetched)
 	if err != nil {
 		// If no data exists, it's stale
 		return true, nil
@@ -484,34 +552,17 @@ type CachedGalleryAsset struct {
 	LastFetchedAt time.Time `json:"last_fetched_at"`
 }
 
-func (db *DB) IsGalleryAssetsStale(parkID int, galleryID string, maxAge time.Duration) (bool, error) {
-	var lastFetched time.Time
-	query := `SELECT last_fetched_at FROM park_gallery_assets 
-              WHERE park_id = ? AND gallery_id = ? 
-              ORDER BY last_fetched_at DESC LIMIT 1`
-
-	err := db.QueryRow(query, parkID, galleryID).Scan(&lastFetched)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return true, nil // No cached data, so it's stale
-		}
-		return true, err
-	}
-
-	return time.Since(lastFetched) > maxAge, nil
-}
-
-func (db *DB) GetCachedGalleryAssets(parkID int, galleryID string) (*CachedGalleryAsset, error) {
+func (db *DB) GetCachedGalleryAssets(ctx context.Context, parkID int, galleryID string) (*CachedGalleryAsset, error) {
 	var asset CachedGalleryAsset
 	query := `SELECT api_data, last_fetched_at FROM park_gallery_assets 
               WHERE park_id = ? AND gallery_id = ? 
               ORDER BY last_fetched_at DESC LIMIT 1`
 
-	err := db.QueryRow(query, parkID, galleryID).Scan(&asset.APIData, &asset.LastFetchedAt)
+	err := db.QueryRowContext(ctx, query, parkID, galleryID).Scan(&asset.APIData, &asset.LastFetchedAt)
 	return &asset, err
 }
 
-func (db *DB) UpsertGalleryAssets(parkID int, galleryID string, response *nps.MultimediaGalleriesAssetsResponse) error {
+func (db *DB) UpsertGalleryAssets(ctx context.Context, parkID int, galleryID string, response *nps.MultimediaGalleriesAssetsResponse) error {
 	// Marshal the entire response to JSON
 	apiDataJSON, err := json.Marshal(response)
 	if err != nil {
@@ -523,7 +574,7 @@ func (db *DB) UpsertGalleryAssets(parkID int, galleryID string, response *nps.Mu
         ) VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
     `
 
-	_, err = db.Exec(query, parkID, galleryID, string(apiDataJSON))
+	_, err = db.ExecContext(ctx, query, parkID, galleryID, string(apiDataJSON))
 	if err != nil {
 		return fmt.Errorf("failed to upsert gallery assets: %w", err)
 	}
@@ -0,0 +1,420 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Full-text search over cached parks and per-park NPS data, using an SQLite FTS5 index.
+//
+// FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build tag, so the index is created at
+// startup rather than by a migration. Builds without the tag fall back to LIKE matching on parks.
+
+// Search entity types
+const (
+	EntityPark          = "park"
+	EntityThingToDo     = "things_to_do"
+	EntityEvent         = "event"
+	EntityNews          = "news"
+	EntityCampground    = "campground"
+	EntityVisitorCenter = "visitor_center"
+)
+
+// Markers wrapped around matches by FTS5, replaced with <mark> tags once the snippet is escaped
+const (
+	matchStart = "\x02"
+	matchEnd   = "\x03"
+)
+
+const searchIndexSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+    title,
+    body,
+    entity_type UNINDEXED,
+    entity_id UNINDEXED,
+    park_code UNINDEXED,
+    url UNINDEXED,
+    image_url UNINDEXED,
+    source UNINDEXED, -- cache row the document came from, e.g. 'parks/12' or 'park_news/12/articles'
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+`
+
+// SearchResult is a ranked match from the search index
+type SearchResult struct {
+	EntityType string  `json:"entity_type"`
+	EntityID   string  `json:"entity_id"`
+	ParkCode   string  `json:"park_code"`
+	Title      string  `json:"title"`
+	URL        string  `json:"url"`
+	ImageURL   string  `json:"image_url,omitempty"`
+	Highlight  string  `json:"highlight"` // Title with matches wrapped in <mark>, HTML-escaped
+	Snippet    string  `json:"snippet"`   // Excerpt of the body with matches wrapped in <mark>, HTML-escaped
+	Rank       float64 `json:"rank"`      // BM25 score, lower is better
+}
+
+// searchDocument is a row of the search index
+type searchDocument struct {
+	entityType string
+	entityID   string
+	parkCode   string
+	title      string
+	body       string
+	url        string
+	imageURL   string
+}
+
+// searchField describes where to find indexed fields in one type of cached NPS response item
+type searchField struct {
+	entityType string
+	idKeys     []string
+	titleKeys  []string
+	bodyKeys   []string
+	urlKeys    []string
+	imageKeys  [][]string // Paths to an image URL, e.g. {"images", "0", "url"}
+}
+
+// indexedParkData lists which cached park data types are searchable, keyed by data_type
+var indexedParkData = map[string]searchField{
+	"things_to_do": {
+		entityType: EntityThingToDo,
+		idKeys:     []string{"id"},
+		titleKeys:  []string{"title"},
+		bodyKeys:   []string{"shortDescription", "longDescription", "location"},
+		urlKeys:    []string{"url"},
+		imageKeys:  [][]string{{"images", "0", "url"}},
+	},
+	"events": {
+		entityType: EntityEvent,
+		idKeys:     []string{"id", "eventid"},
+		titleKeys:  []string{"title"},
+		bodyKeys:   []string{"description", "location"},
+		urlKeys:    []string{"infourl"},
+		imageKeys:  [][]string{{"images", "0", "url"}},
+	},
+	"news_releases": {
+		entityType: EntityNews,
+		idKeys:     []string{"id"},
+		titleKeys:  []string{"title"},
+		bodyKeys:   []string{"abstract"},
+		urlKeys:    []string{"url"},
+		imageKeys:  [][]string{{"image", "url"}},
+	},
+	"articles": {
+		entityType: EntityNews,
+		idKeys:     []string{"id"},
+		titleKeys:  []string{"title"},
+		bodyKeys:   []string{"listingDescription"},
+		urlKeys:    []string{"url"},
+		imageKeys:  [][]string{{"listingImage", "url"}},
+	},
+	"alerts": {
+		entityType: EntityNews,
+		idKeys:     []string{"id"},
+		titleKeys:  []string{"title"},
+		bodyKeys:   []string{"description", "category"},
+		urlKeys:    []string{"url"},
+	},
+	"campgrounds": {
+		entityType: EntityCampground,
+		idKeys:     []string{"id"},
+		titleKeys:  []string{"name"},
+		bodyKeys:   []string{"description"},
+		urlKeys:    []string{"url"},
+		imageKeys:  [][]string{{"images", "0", "url"}},
+	},
+	"visitor_centers": {
+		entityType: EntityVisitorCenter,
+		idKeys:     []string{"id"},
+		titleKeys:  []string{"name"},
+		bodyKeys:   []string{"description"},
+		urlKeys:    []string{"url"},
+		imageKeys:  [][]string{{"images", "0", "url"}},
+	},
+}
+
+// initSearchIndex creates the search index if this build of SQLite supports FTS5, and
+// populates it from the cache tables when it's empty
+func (db *DB) initSearchIndex(ctx context.Context) {
+	if _, err := db.ExecContext(ctx, searchIndexSchema); err != nil {
+		db.logger.Info("Full-text search unavailable, falling back to LIKE search", "error", err)
+		return
+	}
+	db.searchEnabled = true
+
+	var indexed int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM search_index").Scan(&indexed); err == nil && indexed == 0 {
+		if err := db.RebuildSearchIndex(ctx); err != nil {
+			db.logger.Error("Failed to build search index", "error", err)
+		}
+	}
+}
+
+// SearchEnabled reports whether the full-text search index is available
+func (db *DB) SearchEnabled() bool {
+	return db.searchEnabled
+}
+
+// RebuildSearchIndex re-indexes every cached park and all searchable cached park data
+func (db *DB) RebuildSearchIndex(ctx context.Context) error {
+	if !db.searchEnabled {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM search_index"); err != nil {
+		return fmt.Errorf("failed to clear search index: %w", err)
+	}
+
+	parks, err := db.GetAllParks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load parks for indexing: %w", err)
+	}
+	for _, park := range parks {
+		if err := db.indexPark(ctx, &park); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range []string{"park_activities", "park_news", "park_details"} {
+		query := fmt.Sprintf(`
+			SELECT d.park_id, p.park_code, d.data_type, d.api_data
+			FROM %s d JOIN parks p ON p.id = d.park_id
+		`, table)
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to load %s for indexing: %w", table, err)
+		}
+
+		type cachedRow struct {
+			parkID   int
+			parkCode string
+			dataType string
+			apiData  string
+		}
+		var cached []cachedRow
+		for rows.Next() {
+			var row cachedRow
+			if err := rows.Scan(&row.parkID, &row.parkCode, &row.dataType, &row.apiData); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s for indexing: %w", table, err)
+			}
+			cached = append(cached, row)
+		}
+		rows.Close()
+
+		for _, row := range cached {
+			if err := db.indexParkData(ctx, row.parkID, row.parkCode, row.dataType, table, []byte(row.apiData)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// indexPark replaces the search document for a park
+func (db *DB) indexPark(ctx context.Context, park *CachedPark) error {
+	if !db.searchEnabled {
+		return nil
+	}
+	imageURL := ""
+	if len(park.Images) > 0 {
+		imageURL = park.Images[0].URL
+	}
+	title := park.FullName
+	if title == "" {
+		title = park.Name
+	}
+	doc := searchDocument{
+		entityType: EntityPark,
+		entityID:   park.ParkCode,
+		parkCode:   park.ParkCode,
+		title:      title,
+		body:       strings.Join([]string{park.Description, park.Designation, park.States}, " "),
+		url:        "/parks/" + park.Slug,
+		imageURL:   imageURL,
+	}
+	return db.replaceSearchDocuments(ctx, fmt.Sprintf("parks/%d", park.ID), []searchDocument{doc})
+}
+
+// indexParkData replaces the search documents for one cached park data row, if its type is searchable
+func (db *DB) indexParkData(ctx context.Context, parkID int, parkCode, dataType, tableName string, apiData []byte) error {
+	field, ok := indexedParkData[dataType]
+	if !db.searchEnabled || !ok {
+		return nil
+	}
+
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(apiData, &response); err != nil {
+		return fmt.Errorf("failed to decode %s for indexing: %w", dataType, err)
+	}
+
+	docs := make([]searchDocument, 0, len(response.Data))
+	for _, item := range response.Data {
+		doc := searchDocument{
+			entityType: field.entityType,
+			entityID:   firstString(item, field.idKeys),
+			parkCode:   parkCode,
+			title:      StripHTML(firstString(item, field.titleKeys)),
+			url:        firstString(item, field.urlKeys),
+		}
+		var body []string
+		for _, key := range field.bodyKeys {
+			body = append(body, StripHTML(getString(item, key)))
+		}
+		doc.body = strings.Join(body, " ")
+		for _, path := range field.imageKeys {
+			if url := lookupString(item, path); url != "" {
+				doc.imageURL = url
+				break
+			}
+		}
+		if doc.title != "" {
+			docs = append(docs, doc)
+		}
+	}
+
+	return db.replaceSearchDocuments(ctx, fmt.Sprintf("%s/%d/%s", tableName, parkID, dataType), docs)
+}
+
+// replaceSearchDocuments swaps the documents indexed from one source row
+func (db *DB) replaceSearchDocuments(ctx context.Context, source string, docs []searchDocument) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin search index update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM search_index WHERE source = ?", source); err != nil {
+		return fmt.Errorf("failed to clear search documents for %s: %w", source, err)
+	}
+	for _, doc := range docs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO search_index (title, body, entity_type, entity_id, park_code, url, image_url, source)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, doc.title, doc.body, doc.entityType, doc.entityID, doc.parkCode, doc.url, doc.imageURL, source)
+		if err != nil {
+			return fmt.Errorf("failed to index %s: %w", source, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Search runs a ranked full-text query, optionally restricted to some entity types
+func (db *DB) Search(ctx context.Context, query string, entityTypes []string, limit, offset int) ([]SearchResult, error) {
+	if !db.searchEnabled {
+		return nil, fmt.Errorf("full-text search is not available")
+	}
+	match := buildMatchQuery(query)
+	if match == "" {
+		return []SearchResult{}, nil
+	}
+
+	// Title matches weigh ten times as much as body matches
+	searchQuery := fmt.Sprintf(`
+		SELECT entity_type, entity_id, park_code, title, url, image_url,
+			   highlight(search_index, 0, '%[1]s', '%[2]s'),
+			   snippet(search_index, 1, '%[1]s', '%[2]s', '…', 24),
+			   bm25(search_index, 10.0, 1.0) AS rank
+		FROM search_index
+		WHERE search_index MATCH ?
+	`, matchStart, matchEnd)
+	args := []interface{}{match}
+	if len(entityTypes) > 0 {
+		searchQuery += " AND entity_type IN (?" + strings.Repeat(", ?", len(entityTypes)-1) + ")"
+		for _, entityType := range entityTypes {
+			args = append(args, entityType)
+		}
+	}
+	searchQuery += " ORDER BY rank LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := db.QueryContext(ctx, searchQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		err := rows.Scan(&result.EntityType, &result.EntityID, &result.ParkCode, &result.Title,
+			&result.URL, &result.ImageURL, &result.Highlight, &result.Snippet, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Highlight = markMatches(result.Highlight)
+		result.Snippet = markMatches(result.Snippet)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// buildMatchQuery turns free text into an FTS5 query matching every word, with the last word
+// treated as a prefix so results update while typing. Words are quoted, so FTS5 operators in
+// user input are matched literally rather than interpreted.
+func buildMatchQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + word + `"`
+	}
+	terms[len(terms)-1] += "*"
+	return strings.Join(terms, " ")
+}
+
+// markMatches HTML-escapes text from the index and wraps matches in <mark> tags
+func markMatches(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, matchStart, "<mark>")
+	return strings.ReplaceAll(s, matchEnd, "</mark>")
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// StripHTML reduces NPS HTML descriptions to plain text
+func StripHTML(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// firstString returns the first non-empty string value among keys
+func firstString(m map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if s := getString(m, key); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// lookupString follows a path of object keys and array indexes to a string value
+func lookupString(v interface{}, path []string) string {
+	for _, key := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			var i int
+			if _, err := fmt.Sscanf(key, "%d", &i); err != nil || i < 0 || i >= len(node) {
+				return ""
+			}
+			v = node[i]
+		default:
+			return ""
+		}
+	}
+	s, _ := v.(string)
+	return s
+}
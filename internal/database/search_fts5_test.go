@@ -0,0 +1,91 @@
+//go:build sqlite_fts5
+
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchIndex(t *testing.T) {
+	db := newTestDB(t)
+	ctx := t.Context()
+	if !db.SearchEnabled() {
+		t.Fatal("search isn't enabled with the sqlite_fts5 tag")
+	}
+
+	park, err := db.UpsertPark(ctx, map[string]interface{}{
+		"parkCode":    "yose",
+		"name":        "Yosemite",
+		"fullName":    "Yosemite National Park",
+		"description": "Granite cliffs, waterfalls and giant sequoias.",
+		"designation": "National Park",
+		"states":      "CA",
+	}, "yosemite")
+	if err != nil {
+		t.Fatalf("failed to add park: %v", err)
+	}
+	thingsToDo := map[string]interface{}{"data": []interface{}{
+		map[string]interface{}{"id": "vernal", "title": "Hike the Mist Trail", "shortDescription": "<p>Climb granite steps beside <b>Vernal Fall</b>.</p>", "url": "https://www.nps.gov/mist"},
+		map[string]interface{}{"id": "untitled", "shortDescription": "Skipped without a title"},
+	}}
+	if err := db.UpsertParkData(ctx, park.ID, "things_to_do", "park_activities", thingsToDo); err != nil {
+		t.Fatalf("failed to cache things to do: %v", err)
+	}
+
+	search := func(query string, entityTypes ...string) []SearchResult {
+		t.Helper()
+		results, err := db.Search(ctx, query, entityTypes, 10, 0)
+		if err != nil {
+			t.Fatalf("failed to search %q: %v", query, err)
+		}
+		return results
+	}
+
+	// The last word matches as a prefix, and title matches outrank body matches
+	results := search("yosem")
+	if len(results) != 1 || results[0].EntityType != EntityPark || results[0].URL != "/parks/yosemite" {
+		t.Fatalf("got %+v for a prefix of the park name, want the park", results)
+	}
+	if results[0].Highlight != "<mark>Yosemite</mark> National Park" {
+		t.Errorf("got highlight %q", results[0].Highlight)
+	}
+
+	results = search("granite")
+	if len(results) != 2 {
+		t.Fatalf("got %d results for granite, want the park and the thing to do", len(results))
+	}
+	for _, result := range results {
+		if !strings.Contains(result.Snippet, "<mark>granite</mark>") && !strings.Contains(result.Snippet, "<mark>Granite</mark>") {
+			t.Errorf("snippet %q doesn't mark the match", result.Snippet)
+		}
+		if strings.Contains(result.Snippet, "<p>") {
+			t.Errorf("snippet %q wasn't stripped of HTML", result.Snippet)
+		}
+	}
+
+	// Every word must match, porter stemming matches other forms, and operators are literal
+	if results := search("mist hiking"); len(results) != 1 || results[0].EntityID != "vernal" {
+		t.Errorf("got %+v for mist hiking, want the Mist Trail", results)
+	}
+	if results := search("mist sequoias"); len(results) != 0 {
+		t.Errorf("got %+v for words in different documents, want none", results)
+	}
+	if results := search("granite OR nothing"); len(results) != 0 {
+		t.Errorf("got %+v, OR should be matched as a word", results)
+	}
+	if results := search("granite", EntityThingToDo); len(results) != 1 || results[0].EntityID != "vernal" {
+		t.Errorf("got %+v filtered to things to do", results)
+	}
+	if results := search("skipped"); len(results) != 0 {
+		t.Errorf("got %+v, items without a title shouldn't be indexed", results)
+	}
+
+	// Rebuilding from the cache tables indexes the same documents
+	if err := db.RebuildSearchIndex(ctx); err != nil {
+		t.Fatalf("failed to rebuild the index: %v", err)
+	}
+	if results := search("granite"); len(results) != 2 {
+		t.Errorf("got %d results after rebuilding, want 2", len(results))
+	}
+}
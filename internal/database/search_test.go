@@ -0,0 +1,40 @@
+package database
+
+import "testing"
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"  ?! ", ""},
+		{"yosemite", `"yosemite"*`},
+		{"half dome", `"half" "dome"*`},
+		{"Half-Dome hike", `"Half" "Dome" "hike"*`},
+		{"El Capitán", `"El" "Capitán"*`},
+		// FTS5 syntax is quoted away rather than interpreted
+		{`title:bears OR "wolves" NOT elk*`, `"title" "bears" "OR" "wolves" "NOT" "elk"*`},
+		{"campground 42", `"campground" "42"*`},
+	}
+	for _, tt := range tests {
+		if got := buildMatchQuery(tt.query); got != tt.want {
+			t.Errorf("buildMatchQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMarkMatches(t *testing.T) {
+	got := markMatches("Tom & Jerry's " + matchStart + "<Valley>" + matchEnd + " tour")
+	want := "Tom &amp; Jerry&#39;s <mark>&lt;Valley&gt;</mark> tour"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	got := StripHTML("<p>Hike to <b>Vernal&nbsp;Fall</b>.</p>\n<p>Bring&#160;water</p>")
+	if want := "Hike to Vernal Fall . Bring water"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SyncRun records one pass of the background NPS sync
+type SyncRun struct {
+	ID          int        `json:"id"`
+	Kind        string     `json:"kind"`   // 'parks' or 'park_data'
+	Status      string     `json:"status"` // 'running', 'success', 'partial' or 'failed'
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ParksSynced int        `json:"parks_synced"`
+	ItemsSynced int        `json:"items_synced"`
+	APICalls    int        `json:"api_calls"`
+	ErrorCount  int        `json:"error_count"`
+	Errors      string     `json:"errors,omitempty"`
+}
+
+// StartSyncRun records the start of a sync pass and returns its ID
+func (db *DB) StartSyncRun(ctx context.Context, kind string) (int, error) {
+	result, err := db.ExecContext(ctx, "INSERT INTO sync_runs (kind, status, started_at) VALUES (?, 'running', ?)", kind, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to start sync run: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sync run ID: %w", err)
+	}
+	return int(id), nil
+}
+
+// FinishSyncRun records the outcome of a sync pass
+func (db *DB) FinishSyncRun(ctx context.Context, run *SyncRun) error {
+	query := `
+		UPDATE sync_runs SET status = ?, finished_at = ?, parks_synced = ?, items_synced = ?,
+			api_calls = ?, error_count = ?, errors = ?
+		WHERE id = ?
+	`
+	_, err := db.ExecContext(ctx, query, run.Status, time.Now().UTC(), run.ParksSynced, run.ItemsSynced,
+		run.APICalls, run.ErrorCount, run.Errors, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to finish sync run: %w", err)
+	}
+	return nil
+}
+
+// GetRecentSyncRuns retrieves the most recent sync runs, newest first
+func (db *DB) GetRecentSyncRuns(ctx context.Context, limit int) ([]SyncRun, error) {
+	query := `
+		SELECT id, kind, status, started_at, finished_at, parks_synced, items_synced,
+			   api_calls, error_count, errors
+		FROM sync_runs ORDER BY started_at DESC, id DESC LIMIT ?
+	`
+
+	rows, err := db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []SyncRun
+	for rows.Next() {
+		run, err := scanSyncRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sync runs: %w", err)
+	}
+	return runs, nil
+}
+
+// GetLastSyncRun retrieves the most recent sync run of a kind
+func (db *DB) GetLastSyncRun(ctx context.Context, kind string) (*SyncRun, error) {
+	query := `
+		SELECT id, kind, status, started_at, finished_at, parks_synced, items_synced,
+			   api_calls, error_count, errors
+		FROM sync_runs WHERE kind = ? ORDER BY started_at DESC, id DESC LIMIT 1
+	`
+	return scanSyncRun(db.QueryRowContext(ctx, query, kind))
+}
+
+func scanSyncRun(row interface{ Scan(...interface{}) error }) (*SyncRun, error) {
+	var run SyncRun
+	var finishedAt sql.NullTime
+	var errors sql.NullString
+	err := row.Scan(
+		&run.ID, &run.Kind, &run.Status, &run.StartedAt, &finishedAt, &run.ParksSynced,
+		&run.ItemsSynced, &run.APICalls, &run.ErrorCount, &errors,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan sync run: %w", err)
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	run.Errors = errors.String
+	return &run, nil
+}
@@ -0,0 +1,392 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MaxTripDays caps how long a trip can be
+const MaxTripDays = 60
+
+// TripItemTypes are the kinds of things that can be added to a trip
+var TripItemTypes = []string{EntityPark, EntityThingToDo, EntityCampground, EntityEvent}
+
+var (
+	// ErrTripNotFound is returned for trips and trip items that don't exist
+	ErrTripNotFound = errors.New("trip not found")
+	// ErrInvalidTrip wraps errors caused by invalid trip or trip item fields
+	ErrInvalidTrip = errors.New("invalid trip")
+)
+
+// Trip is a user's multi-park itinerary
+type Trip struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Name      string     `json:"name"`
+	StartDate string     `json:"start_date,omitempty"` // YYYY-MM-DD
+	Days      int        `json:"days"`
+	Notes     string     `json:"notes"`
+	ItemCount int        `json:"item_count"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Items     []TripItem `json:"items,omitempty"`
+}
+
+// TripItem is a park, thing to do, campground or event on one day of a trip
+type TripItem struct {
+	ID        int       `json:"id"`
+	TripID    int       `json:"trip_id"`
+	Day       int       `json:"day"`
+	Position  int       `json:"position"`
+	ItemType  string    `json:"item_type"`
+	ItemID    string    `json:"item_id"`
+	ParkCode  string    `json:"park_code"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DayItems returns the trip's items on a day, in order
+func (t *Trip) DayItems(day int) []TripItem {
+	var items []TripItem
+	for _, item := range t.Items {
+		if item.Day == day {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Date returns the calendar date of a day of the trip, if it has a start date
+func (t *Trip) Date(day int) (time.Time, bool) {
+	start, err := time.Parse("2006-01-02", t.StartDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return start.AddDate(0, 0, day-1), true
+}
+
+// ValidateTrip checks a trip's user-editable fields
+func ValidateTrip(trip *Trip) error {
+	if trip.Name == "" {
+		return fmt.Errorf("%w: trip name is required", ErrInvalidTrip)
+	}
+	if trip.StartDate != "" {
+		if _, err := time.Parse("2006-01-02", trip.StartDate); err != nil {
+			return fmt.Errorf("%w: start date must be YYYY-MM-DD", ErrInvalidTrip)
+		}
+	}
+	if trip.Days < 1 || trip.Days > MaxTripDays {
+		return fmt.Errorf("%w: trips must be between 1 and %d days", ErrInvalidTrip, MaxTripDays)
+	}
+	return nil
+}
+
+// CreateTrip saves a new trip for a user
+func (db *DB) CreateTrip(ctx context.Context, trip *Trip) error {
+	if err := ValidateTrip(trip); err != nil {
+		return err
+	}
+
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO trips (user_id, name, start_date, days, notes) VALUES (?, ?, ?, ?, ?)
+	`, trip.UserID, trip.Name, nullString(trip.StartDate), trip.Days, trip.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to create trip: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get trip ID: %w", err)
+	}
+	trip.ID = int(id)
+	trip.CreatedAt = time.Now().UTC()
+	trip.UpdatedAt = trip.CreatedAt
+	return nil
+}
+
+// UpdateTrip saves a trip's name, start date, length and notes. A trip can't be
+// shortened to drop days that still have items.
+func (db *DB) UpdateTrip(ctx context.Context, trip *Trip) error {
+	if err := ValidateTrip(trip); err != nil {
+		return err
+	}
+
+	var lastDay sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT MAX(day) FROM trip_items WHERE trip_id = ?", trip.ID).Scan(&lastDay); err != nil {
+		return fmt.Errorf("failed to check trip items: %w", err)
+	}
+	if int(lastDay.Int64) > trip.Days {
+		return fmt.Errorf("%w: day %d still has items, move or remove them first", ErrInvalidTrip, lastDay.Int64)
+	}
+
+	result, err := db.ExecContext(ctx, `
+		UPDATE trips SET name = ?, start_date = ?, days = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, trip.Name, nullString(trip.StartDate), trip.Days, trip.Notes, trip.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update trip: %w", err)
+	}
+	return requireRow(result)
+}
+
+// DeleteTrip deletes a trip, its items are deleted with it by ON DELETE CASCADE
+func (db *DB) DeleteTrip(ctx context.Context, tripID int) error {
+	result, err := db.ExecContext(ctx, "DELETE FROM trips WHERE id = ?", tripID)
+	if err != nil {
+		return fmt.Errorf("failed to delete trip: %w", err)
+	}
+	return requireRow(result)
+}
+
+// GetTrip retrieves a trip with its items, ordered by day and position
+func (db *DB) GetTrip(ctx context.Context, tripID int) (*Trip, error) {
+	query := `
+		SELECT t.id, t.user_id, t.name, t.start_date, t.days, t.notes, t.created_at, t.updated_at,
+			   (SELECT COUNT(*) FROM trip_items i WHERE i.trip_id = t.id)
+		FROM trips t WHERE t.id = ?
+	`
+	trip, err := scanTrip(db.QueryRowContext(ctx, query, tripID))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, trip_id, day, position, item_type, item_id, park_code, title, url, notes, created_at
+		FROM trip_items WHERE trip_id = ? ORDER BY day, position
+	`, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trip items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item TripItem
+		err := rows.Scan(&item.ID, &item.TripID, &item.Day, &item.Position, &item.ItemType,
+			&item.ItemID, &item.ParkCode, &item.Title, &item.URL, &item.Notes, &item.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trip item: %w", err)
+		}
+		trip.Items = append(trip.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trip items: %w", err)
+	}
+	return trip, nil
+}
+
+// GetUserTrips retrieves a user's trips without their items, soonest first
+func (db *DB) GetUserTrips(ctx context.Context, userID int) ([]Trip, error) {
+	query := `
+		SELECT t.id, t.user_id, t.name, t.start_date, t.days, t.notes, t.created_at, t.updated_at,
+			   (SELECT COUNT(*) FROM trip_items i WHERE i.trip_id = t.id)
+		FROM trips t WHERE t.user_id = ?
+		ORDER BY t.start_date IS NULL, t.start_date, t.created_at DESC
+	`
+
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trips: %w", err)
+	}
+	defer rows.Close()
+
+	trips := []Trip{}
+	for rows.Next() {
+		trip, err := scanTrip(rows)
+		if err != nil {
+			return nil, err
+		}
+		trips = append(trips, *trip)
+	}
+	return trips, rows.Err()
+}
+
+// AddTripItem appends an item to the end of its day, extending the trip if the day is past its end
+func (db *DB) AddTripItem(ctx context.Context, item *TripItem) error {
+	if !slices.Contains(TripItemTypes, item.ItemType) {
+		return fmt.Errorf("%w: unknown item type %q", ErrInvalidTrip, item.ItemType)
+	}
+	if item.ItemID == "" || item.Title == "" {
+		return fmt.Errorf("%w: items need an ID and title", ErrInvalidTrip)
+	}
+	if item.Day < 1 || item.Day > MaxTripDays {
+		return fmt.Errorf("%w: day must be between 1 and %d", ErrInvalidTrip, MaxTripDays)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM trip_items WHERE trip_id = ? AND day = ?", item.TripID, item.Day,
+	).Scan(&item.Position); err != nil {
+		return fmt.Errorf("failed to count trip items: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO trip_items (trip_id, day, position, item_type, item_id, park_code, title, url, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, item.TripID, item.Day, item.Position, item.ItemType, item.ItemID, item.ParkCode, item.Title, item.URL, item.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to add trip item: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get trip item ID: %w", err)
+	}
+	item.ID = int(id)
+	item.CreatedAt = time.Now().UTC()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE trips SET days = MAX(days, ?), updated_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, item.Day, item.TripID)
+	if err != nil {
+		return fmt.Errorf("failed to update trip: %w", err)
+	}
+	return tx.Commit()
+}
+
+// UpdateTripItemNotes saves an item's notes
+func (db *DB) UpdateTripItemNotes(ctx context.Context, tripID, itemID int, notes string) error {
+	result, err := db.ExecContext(ctx, "UPDATE trip_items SET notes = ? WHERE id = ? AND trip_id = ?", notes, itemID, tripID)
+	if err != nil {
+		return fmt.Errorf("failed to update trip item: %w", err)
+	}
+	if err := requireRow(result); err != nil {
+		return err
+	}
+	return db.touchTrip(ctx, tripID)
+}
+
+// MoveTripItem moves an item to a position on a day, shifting the items around it.
+// Positions past the end of the day append the item.
+func (db *DB) MoveTripItem(ctx context.Context, tripID, itemID, day, position int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var days, fromDay, fromPosition int
+	err = tx.QueryRowContext(ctx, `
+		SELECT t.days, i.day, i.position FROM trip_items i JOIN trips t ON t.id = i.trip_id
+		WHERE i.id = ? AND i.trip_id = ?
+	`, itemID, tripID).Scan(&days, &fromDay, &fromPosition)
+	if err == sql.ErrNoRows {
+		return ErrTripNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get trip item: %w", err)
+	}
+	if day < 1 || day > days {
+		return fmt.Errorf("%w: day must be between 1 and %d", ErrInvalidTrip, days)
+	}
+
+	// Close the gap the item leaves behind
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE trip_items SET position = position - 1 WHERE trip_id = ? AND day = ? AND position > ?
+	`, tripID, fromDay, fromPosition); err != nil {
+		return fmt.Errorf("failed to reorder trip items: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM trip_items WHERE trip_id = ? AND day = ? AND id != ?", tripID, day, itemID,
+	).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count trip items: %w", err)
+	}
+	position = max(0, min(position, count))
+
+	// Open a gap at the new position
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE trip_items SET position = position + 1 WHERE trip_id = ? AND day = ? AND position >= ? AND id != ?
+	`, tripID, day, position, itemID); err != nil {
+		return fmt.Errorf("failed to reorder trip items: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE trip_items SET day = ?, position = ? WHERE id = ?", day, position, itemID,
+	); err != nil {
+		return fmt.Errorf("failed to move trip item: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE trips SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", tripID); err != nil {
+		return fmt.Errorf("failed to update trip: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DeleteTripItem removes an item from a trip, closing the gap in its day
+func (db *DB) DeleteTripItem(ctx context.Context, tripID, itemID int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var day, position int
+	err = tx.QueryRowContext(ctx,
+		"SELECT day, position FROM trip_items WHERE id = ? AND trip_id = ?", itemID, tripID,
+	).Scan(&day, &position)
+	if err == sql.ErrNoRows {
+		return ErrTripNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get trip item: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM trip_items WHERE id = ?", itemID); err != nil {
+		return fmt.Errorf("failed to delete trip item: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE trip_items SET position = position - 1 WHERE trip_id = ? AND day = ? AND position > ?
+	`, tripID, day, position); err != nil {
+		return fmt.Errorf("failed to reorder trip items: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE trips SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", tripID); err != nil {
+		return fmt.Errorf("failed to update trip: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (db *DB) touchTrip(ctx context.Context, tripID int) error {
+	if _, err := db.ExecContext(ctx, "UPDATE trips SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", tripID); err != nil {
+		return fmt.Errorf("failed to update trip: %w", err)
+	}
+	return nil
+}
+
+func scanTrip(row interface{ Scan(...interface{}) error }) (*Trip, error) {
+	var trip Trip
+	var startDate sql.NullString
+	err := row.Scan(&trip.ID, &trip.UserID, &trip.Name, &startDate, &trip.Days, &trip.Notes,
+		&trip.CreatedAt, &trip.UpdatedAt, &trip.ItemCount)
+	if err == sql.ErrNoRows {
+		return nil, ErrTripNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan trip: %w", err)
+	}
+	trip.StartDate = startDate.String
+	return &trip, nil
+}
+
+// requireRow returns ErrTripNotFound if a statement changed nothing
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrTripNotFound
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
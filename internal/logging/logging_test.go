@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, "prod", "info")
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(Middleware(logger))
+	r.Get("/api/parks/{parkCode}/overview", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		RecordCacheLookup(ctx, "park_activities", "things_to_do", "hit")
+		RecordCacheLookup(ctx, "park_details", "visitor_centers", "miss")
+		RecordNPSCall(ctx, "visitorcenters", errors.New("status code: 500"))
+		logger.ErrorContext(ctx, "Failed to render overview content", "park", chi.URLParam(r, "parkCode"))
+		http.Error(w, "Failed to render overview content", http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/parks/yose/overview", nil)
+	req.Header.Set(middleware.RequestIDHeader, "test-request")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if got := rec.Header().Get(middleware.RequestIDHeader); got != "test-request" {
+		t.Errorf("X-Request-Id = %q, want test-request", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want the handler's and the summary:\n%s", len(lines), out.String())
+	}
+	var handlerLine, summary map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &handlerLine); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if handlerLine["request_id"] != "test-request" {
+		t.Errorf("handler log has request_id %v, want test-request", handlerLine["request_id"])
+	}
+
+	want := map[string]interface{}{
+		"level":      "ERROR",
+		"msg":        "request",
+		"request_id": "test-request",
+		"route":      "/api/parks/{parkCode}/overview",
+		"status":     float64(500),
+		"cache_hit":  []interface{}{"park_activities/things_to_do"},
+		"cache_miss": []interface{}{"park_details/visitor_centers"},
+		"nps_calls":  []interface{}{"visitorcenters"},
+		"nps_errors": float64(1),
+	}
+	for key, value := range want {
+		if !reflect.DeepEqual(summary[key], value) {
+			t.Errorf("summary %s = %v, want %v", key, summary[key], value)
+		}
+	}
+	if _, ok := summary["cache_stale"]; ok {
+		t.Errorf("summary has cache_stale without any stale lookups")
+	}
+}
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, "dev", "warn")
+	logger.Info("dropped")
+	logger.Warn("kept", "park", "yose")
+	if got := out.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "level=WARN msg=kept park=yose") {
+		t.Errorf("got %q, want only the warning as text", got)
+	}
+
+	if ParseLevel("debug") != slog.LevelDebug || ParseLevel("") != slog.LevelInfo || ParseLevel("loud") != slog.LevelInfo {
+		t.Errorf("ParseLevel doesn't default to info")
+	}
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Trace collects the cache lookups, cache writes and NPS calls made while serving one
+// request, reported in its summary by Middleware. It is safe for concurrent use.
+type Trace struct {
+	mu          sync.Mutex
+	lookups     map[string][]string // "table/data_type" by lookup result, e.g. hit or miss
+	cacheWrites []string
+	npsCalls    []string
+	npsErrors   int
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context that collects a new Trace
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{lookups: map[string][]string{}}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// TraceFrom returns the Trace collected by ctx, or nil
+func TraceFrom(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+// RecordCacheLookup records a lookup of dataType in a park_* cache table, result being hit, miss or stale
+func RecordCacheLookup(ctx context.Context, table, dataType, result string) {
+	if t := TraceFrom(ctx); t != nil {
+		t.mu.Lock()
+		t.lookups[result] = append(t.lookups[result], table+"/"+dataType)
+		t.mu.Unlock()
+	}
+}
+
+// RecordCacheWrite records fresh dataType being stored in a park_* cache table
+func RecordCacheWrite(ctx context.Context, table, dataType string) {
+	if t := TraceFrom(ctx); t != nil {
+		t.mu.Lock()
+		t.cacheWrites = append(t.cacheWrites, table+"/"+dataType)
+		t.mu.Unlock()
+	}
+}
+
+// RecordNPSCall records a call to an NPS API endpoint, e.g. "thingstodo"
+func RecordNPSCall(ctx context.Context, endpoint string, err error) {
+	if t := TraceFrom(ctx); t != nil {
+		t.mu.Lock()
+		t.npsCalls = append(t.npsCalls, endpoint)
+		if err != nil {
+			t.npsErrors++
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Attrs returns the trace as log attributes, leaving out anything that didn't happen
+func (t *Trace) Attrs() []slog.Attr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var attrs []slog.Attr
+	for _, result := range []string{"hit", "stale", "miss"} {
+		if tables := t.lookups[result]; len(tables) > 0 {
+			attrs = append(attrs, slog.Any("cache_"+result, tables))
+		}
+	}
+	if len(t.cacheWrites) > 0 {
+		attrs = append(attrs, slog.Any("cache_writes", t.cacheWrites))
+	}
+	if len(t.npsCalls) > 0 {
+		attrs = append(attrs, slog.Any("nps_calls", t.npsCalls))
+	}
+	if t.npsErrors > 0 {
+		attrs = append(attrs, slog.Int("nps_errors", t.npsErrors))
+	}
+	return attrs
+}
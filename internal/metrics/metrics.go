@@ -0,0 +1,204 @@
+// Package metrics defines the Prometheus metrics exposed on /metrics
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/ztkent/replay"
+)
+
+// Results of a cache lookup
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheStale = "stale"
+)
+
+// Registry holds every metric, along with the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "parks_http_requests_total",
+		Help: "HTTP requests by chi route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "parks_http_request_duration_seconds",
+		Help:    "HTTP request latency by chi route pattern and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	npsRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "parks_nps_requests_total",
+		Help: "NPS API calls by endpoint and result, success or error.",
+	}, []string{"endpoint", "result"})
+	npsDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "parks_nps_request_duration_seconds",
+		Help:    "NPS API call latency by endpoint.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"endpoint"})
+
+	parkCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "parks_park_cache_lookups_total",
+		Help: "Lookups of per-park NPS data in the SQLite cache tables by table, data type and result, hit, miss or stale.",
+	}, []string{"table", "data_type", "result"})
+
+	imageProxyBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "parks_image_proxy_bytes_total",
+		Help: "Bytes downloaded by the image and avatar proxies, by proxy.",
+	}, []string{"proxy"})
+	imageProxyServedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "parks_image_proxy_served_bytes_total",
+		Help: "Bytes served by the image proxy, by source: original, resized or cache.",
+	}, []string{"source"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "parks_db_query_duration_seconds",
+		Help:    "SQLite query latency by the function running the query.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, 1},
+	}, []string{"query"})
+)
+
+// responseCache is the replay cache reported by the parks_response_cache metrics
+var responseCache atomic.Pointer[replay.Cache]
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		npsRequests, npsDuration,
+		parkCacheLookups,
+		imageProxyBytes, imageProxyServedBytes,
+		dbQueryDuration,
+	)
+
+	// The replay cache keeps its own counts, read when scraped
+	replayMetric := func(read func(*replay.CacheMetrics) float64) func() float64 {
+		return func() float64 {
+			cache := responseCache.Load()
+			if cache == nil {
+				return 0
+			}
+			return read(cache.Metrics())
+		}
+	}
+	Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "parks_response_cache_lookups_total",
+			Help:        "Lookups in the replay response cache by result, hit or miss.",
+			ConstLabels: prometheus.Labels{"result": CacheHit},
+		}, replayMetric(func(m *replay.CacheMetrics) float64 { return float64(m.Hits) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "parks_response_cache_lookups_total",
+			Help:        "Lookups in the replay response cache by result, hit or miss.",
+			ConstLabels: prometheus.Labels{"result": CacheMiss},
+		}, replayMetric(func(m *replay.CacheMetrics) float64 { return float64(m.Misses) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "parks_response_cache_evictions_total",
+			Help: "Responses evicted from the replay response cache.",
+		}, replayMetric(func(m *replay.CacheMetrics) float64 { return float64(m.Evictions) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "parks_response_cache_entries",
+			Help: "Responses held in the replay response cache.",
+		}, replayMetric(func(m *replay.CacheMetrics) float64 { return float64(m.CurrentSize) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "parks_response_cache_bytes",
+			Help: "Memory used by responses in the replay response cache.",
+		}, replayMetric(func(m *replay.CacheMetrics) float64 { return float64(m.CurrentMemory) })),
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// SetResponseCache reports cache in the parks_response_cache metrics, replacing any earlier cache
+func SetResponseCache(cache *replay.Cache) {
+	responseCache.Store(cache)
+}
+
+// Middleware counts and times requests by their chi route pattern, e.g. /parks/{slug}, so
+// paths with IDs don't each get their own series
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter records the status code of a response. Unlike chi's WrapResponseWriter it
+// doesn't call WriteHeader before the first Write, so the Content-Type is still sniffed.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.status == 0 {
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// ObserveNPSRequest records an NPS API call to endpoint that started at start
+func ObserveNPSRequest(endpoint string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	npsRequests.WithLabelValues(endpoint, result).Inc()
+	npsDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+}
+
+// ObserveParkCacheLookup records a lookup of dataType in a park_* cache table
+func ObserveParkCacheLookup(table, dataType, result string) {
+	parkCacheLookups.WithLabelValues(table, dataType, result).Inc()
+}
+
+// AddImageProxyBytes records n bytes downloaded by proxy, "image" or "avatar"
+func AddImageProxyBytes(proxy string, n int) {
+	imageProxyBytes.WithLabelValues(proxy).Add(float64(n))
+}
+
+// AddImageProxyServedBytes records n bytes served by the image proxy from source
+func AddImageProxyServedBytes(source string, n int) {
+	imageProxyServedBytes.WithLabelValues(source).Add(float64(n))
+}
+
+// ObserveDBQuery records a query run by the named function that started at start
+func ObserveDBQuery(query string, start time.Time) {
+	dbQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
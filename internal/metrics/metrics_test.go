@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/ztkent/replay"
+)
+
+func TestMiddleware(t *testing.T) {
+	httpRequests.Reset()
+	httpDuration.Reset()
+	cache := replay.NewCache(replay.WithLogger(log.New(io.Discard, "", 0)))
+	SetResponseCache(cache)
+	t.Cleanup(func() { SetResponseCache(nil) })
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/parks/{slug}", cache.MiddlewareFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<h1>" + chi.URLParam(r, "slug") + "</h1>"))
+	}))
+	r.Get("/api/trips/{tripID}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Trip not found", http.StatusNotFound)
+	})
+	r.Get("/metrics", Handler().ServeHTTP)
+
+	for _, path := range []string{"/parks/yosemite", "/parks/yosemite", "/parks/zion", "/api/trips/7", "/missing"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if path == "/parks/zion" && rec.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+			t.Errorf("Content-Type of %s is %q, want it sniffed", path, rec.Header().Get("Content-Type"))
+		}
+		// replay stores responses in the background, wait for the first one
+		for deadline := time.Now().Add(time.Second); cache.Metrics().CurrentSize == 0 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	counts := map[[3]string]float64{
+		{"/parks/{slug}", "GET", "200"}:       3,
+		{"/api/trips/{tripID}", "GET", "404"}: 1,
+		{"unmatched", "GET", "404"}:           1,
+	}
+	for labels, want := range counts {
+		if got := testutil.ToFloat64(httpRequests.WithLabelValues(labels[:]...)); got != want {
+			t.Errorf("parks_http_requests_total%v = %v, want %v", labels, got, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, line := range []string{
+		`parks_response_cache_lookups_total{result="hit"} 1`,
+		`parks_response_cache_lookups_total{result="miss"} 2`,
+		`parks_http_request_duration_seconds_count{method="GET",route="/parks/{slug}"} 3`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("/metrics is missing %q", line)
+		}
+	}
+}
+
+func TestObserveNPSRequest(t *testing.T) {
+	npsRequests.Reset()
+	ObserveNPSRequest("parks", time.Now(), nil)
+	ObserveNPSRequest("parks", time.Now(), io.ErrUnexpectedEOF)
+	ObserveNPSRequest("parks", time.Now(), nil)
+	if got := testutil.ToFloat64(npsRequests.WithLabelValues("parks", "success")); got != 2 {
+		t.Errorf("successful calls = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(npsRequests.WithLabelValues("parks", "error")); got != 1 {
+		t.Errorf("failed calls = %v, want 1", got)
+	}
+}
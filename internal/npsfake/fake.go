@@ -0,0 +1,551 @@
+// Package npsfake is an offline nps.NpsApi backed by recorded JSON fixtures, so the server and
+// its handlers can be tested and demoed without an NPS API key or network access.
+//
+// Each NPS endpoint has one fixture file named after its path, e.g. parks.json or
+// multimedia_galleries.json, holding {"data": [...]}. The fake filters those items the way the
+// live API does for park codes, state codes, IDs and q, then pages them with start and limit.
+// Dates are not filtered, so recorded events stay visible after they pass.
+package npsfake
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ztkent/go-nps"
+)
+
+//go:embed fixtures/*.json
+var embeddedFixtures embed.FS
+
+const defaultLimit = 50
+
+// Fixtures returns the fixtures recorded into this package
+func Fixtures() fs.FS {
+	fixtures, err := fs.Sub(embeddedFixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return fixtures
+}
+
+// Client serves NPS API calls from fixture files
+type Client struct {
+	fixtures fs.FS
+
+	mu         sync.Mutex
+	items      map[string][]map[string]interface{}
+	parkStates map[string][]string
+	calls      map[string]int
+	failures   map[string]error
+	holds      map[string]chan struct{}
+}
+
+var _ nps.NpsApi = (*Client)(nil)
+
+// New creates a fake NPS API serving the fixtures in fsys
+func New(fixtures fs.FS) *Client {
+	return &Client{
+		fixtures: fixtures,
+		items:    make(map[string][]map[string]interface{}),
+		calls:    make(map[string]int),
+		failures: make(map[string]error),
+		holds:    make(map[string]chan struct{}),
+	}
+}
+
+// Fail makes every later call to an endpoint return err, or "*" for every endpoint.
+// A nil err clears the failure.
+func (c *Client) Fail(endpoint string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.failures, endpoint)
+		return
+	}
+	c.failures[endpoint] = err
+}
+
+// failure returns the error injected for an endpoint with Fail. Callers hold c.mu.
+func (c *Client) failure(endpoint string) error {
+	if err, ok := c.failures[endpoint]; ok {
+		return err
+	}
+	return c.failures["*"]
+}
+
+// Hold makes later calls to an endpoint wait until release is called, so tests can overlap
+// them. Held calls are counted as soon as they're made.
+func (c *Client) Hold(endpoint string) (release func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hold := make(chan struct{})
+	c.holds[endpoint] = hold
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.holds[endpoint] == hold {
+				delete(c.holds, endpoint)
+			}
+			close(hold)
+		})
+	}
+}
+
+// call counts a call to an endpoint, waits out any Hold on it, and returns the failure injected
+// for it with Fail
+func (c *Client) call(endpoint string) error {
+	c.mu.Lock()
+	c.calls[endpoint]++
+	hold := c.holds[endpoint]
+	c.mu.Unlock()
+	if hold != nil {
+		<-hold
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.failure(endpoint)
+}
+
+// Calls returns how many times an endpoint has been called, e.g. Calls("parks")
+func (c *Client) Calls(endpoint string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[endpoint]
+}
+
+// query is the filtering a list endpoint supports
+type query struct {
+	IDs        []string
+	ParkCodes  []string
+	StateCodes []string
+	Match      map[string][]string // other item fields, e.g. event types
+	Q          string
+	Start      int
+	Limit      int
+}
+
+// list answers a list endpoint, decoding the filtered page into out
+func (c *Client) list(endpoint string, q query, out interface{}) error {
+	if err := c.call(endpoint); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items, err := c.load(endpoint)
+	if err != nil {
+		return err
+	}
+	var matched []map[string]interface{}
+	for _, item := range items {
+		if c.matches(item, q) {
+			matched = append(matched, item)
+		}
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	start := min(max(q.Start, 0), len(matched))
+	end := min(start+limit, len(matched))
+	page := matched[start:end]
+	if page == nil {
+		page = []map[string]interface{}{}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"total":      strconv.Itoa(len(matched)),
+		"limit":      strconv.Itoa(limit),
+		"start":      strconv.Itoa(start),
+		"pagesize":   strconv.Itoa(limit),
+		"pagenumber": strconv.Itoa(start/limit + 1),
+		"data":       page,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s fixture page: %w", endpoint, err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s fixture: %w", endpoint, err)
+	}
+	return nil
+}
+
+// document answers an endpoint that returns a single document rather than a list
+func (c *Client) document(name string, out interface{}) error {
+	if err := c.call(name); err != nil {
+		return err
+	}
+
+	data, err := fs.ReadFile(c.fixtures, name+".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s fixture: %w", name, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s fixture: %w", name, err)
+	}
+	return nil
+}
+
+// load reads an endpoint's fixture items, a missing fixture has no items. Callers hold c.mu.
+func (c *Client) load(endpoint string) ([]map[string]interface{}, error) {
+	if items, ok := c.items[endpoint]; ok {
+		return items, nil
+	}
+
+	data, err := fs.ReadFile(c.fixtures, endpoint+".json")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s fixture: %w", endpoint, err)
+	}
+	var fixture struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to decode %s fixture: %w", endpoint, err)
+		}
+	}
+	c.items[endpoint] = fixture.Data
+	return fixture.Data, nil
+}
+
+// matches reports whether an item passes every filter in q. Callers hold c.mu.
+func (c *Client) matches(item map[string]interface{}, q query) bool {
+	if len(q.IDs) > 0 && !overlaps(q.IDs, fieldStrings(item, "id", "eventid")) {
+		return false
+	}
+	parkCodes := itemParkCodes(item)
+	if len(q.ParkCodes) > 0 && !overlaps(q.ParkCodes, parkCodes) {
+		return false
+	}
+	if len(q.StateCodes) > 0 {
+		states := itemStates(item)
+		// Events and alerts only name their park, look its states up in the parks fixture
+		if len(states) == 0 {
+			for _, code := range parkCodes {
+				states = append(states, c.statesOf(code)...)
+			}
+		}
+		if !overlaps(q.StateCodes, states) {
+			return false
+		}
+	}
+	for field, values := range q.Match {
+		if len(values) > 0 && !overlaps(values, fieldStrings(item, field)) {
+			return false
+		}
+	}
+	if q.Q != "" {
+		text, _ := json.Marshal(item)
+		lower := strings.ToLower(string(text))
+		for _, word := range strings.Fields(strings.ToLower(q.Q)) {
+			if !strings.Contains(lower, word) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// statesOf returns the states of a park in the parks fixture. Callers hold c.mu.
+func (c *Client) statesOf(parkCode string) []string {
+	if c.parkStates == nil {
+		c.parkStates = make(map[string][]string)
+		parks, _ := c.load("parks")
+		for _, park := range parks {
+			for _, code := range fieldStrings(park, "parkCode") {
+				c.parkStates[strings.ToLower(code)] = itemStates(park)
+			}
+		}
+	}
+	return c.parkStates[strings.ToLower(parkCode)]
+}
+
+// itemParkCodes finds the parks an item belongs to, NPS endpoints name them in different fields
+func itemParkCodes(item map[string]interface{}) []string {
+	codes := fieldStrings(item, "parkCode", "sitecode")
+	for _, park := range nestedObjects(item, "relatedParks", "park") {
+		codes = append(codes, fieldStrings(park, "parkCode")...)
+	}
+	return codes
+}
+
+// itemStates finds the states an item is in
+func itemStates(item map[string]interface{}) []string {
+	states := fieldStrings(item, "states")
+	for _, park := range nestedObjects(item, "relatedParks", "park") {
+		states = append(states, fieldStrings(park, "states")...)
+	}
+	for _, address := range nestedObjects(item, "addresses") {
+		states = append(states, fieldStrings(address, "stateCode")...)
+	}
+	return states
+}
+
+// fieldStrings collects string values from fields, splitting comma separated lists
+func fieldStrings(item map[string]interface{}, fields ...string) []string {
+	var values []string
+	add := func(v interface{}) {
+		if s, ok := v.(string); ok {
+			for _, part := range strings.Split(s, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					values = append(values, part)
+				}
+			}
+		}
+	}
+	for _, field := range fields {
+		switch v := item[field].(type) {
+		case []interface{}:
+			for _, elem := range v {
+				add(elem)
+			}
+		default:
+			add(v)
+		}
+	}
+	return values
+}
+
+// nestedObjects collects the objects held in fields, either directly or in arrays
+func nestedObjects(item map[string]interface{}, fields ...string) []map[string]interface{} {
+	var objects []map[string]interface{}
+	for _, field := range fields {
+		switch v := item[field].(type) {
+		case map[string]interface{}:
+			objects = append(objects, v)
+		case []interface{}:
+			for _, elem := range v {
+				if obj, ok := elem.(map[string]interface{}); ok {
+					objects = append(objects, obj)
+				}
+			}
+		}
+	}
+	return objects
+}
+
+// overlaps reports whether any want value is in have, ignoring case
+func overlaps(want, have []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(w, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// one turns an optional single value into a filter list
+func one(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func (c *Client) GetActivities(id, q string, limit, start int, sort string) (*nps.ActivityResponse, error) {
+	var resp nps.ActivityResponse
+	err := c.list("activities", query{IDs: one(id), Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetActivityParks(id []string, q string, limit, start int, sort string) (*nps.ActivityParkResponse, error) {
+	var resp nps.ActivityParkResponse
+	err := c.list("activities_parks", query{IDs: id, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetAlerts(parkCode, stateCode []string, q string, limit, start int) (*nps.AlertResponse, error) {
+	var resp nps.AlertResponse
+	err := c.list("alerts", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetAmenities(id []string, q string, limit, start int) (*nps.AmenityResponse, error) {
+	var resp nps.AmenityResponse
+	err := c.list("amenities", query{IDs: id, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetAmenitiesParksPlaces(parkCode, id []string, q string, limit, start int, sort string) (*nps.AmenityParkPlaceResponse, error) {
+	var resp nps.AmenityParkPlaceResponse
+	err := c.document("amenities_parksplaces", &resp)
+	return &resp, err
+}
+
+func (c *Client) GetAmenitiesParksVisitorCenters(parkCode, id, q string, limit, start int, sort []string) (*nps.AmenityParkVisitorCenterResponse, error) {
+	var resp nps.AmenityParkVisitorCenterResponse
+	err := c.document("amenities_parksvisitorcenters", &resp)
+	return &resp, err
+}
+
+func (c *Client) GetArticles(parkCode, stateCode []string, q string, limit, start int) (*nps.ArticleData, error) {
+	var resp nps.ArticleData
+	err := c.list("articles", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetCampgrounds(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.CampgroundData, error) {
+	var resp nps.CampgroundData
+	err := c.list("campgrounds", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetEvents(parkCode, stateCode, organization, subject, portal, tagsAll, tagsOne, tagsNone []string, dateStart, dateEnd string, eventType []string, id, q string, pageSize, pageNumber int, expandRecurring bool) (*nps.EventResponse, error) {
+	if pageSize <= 0 {
+		pageSize = defaultLimit
+	}
+	var resp nps.EventResponse
+	err := c.list("events", query{
+		IDs:        one(id),
+		ParkCodes:  parkCode,
+		StateCodes: stateCode,
+		Match:      map[string][]string{"types": eventType, "organizationname": organization, "subjectname": subject},
+		Q:          q,
+		Start:      max(pageNumber-1, 0) * pageSize,
+		Limit:      pageSize,
+	}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetFeesPasses(parkCode, stateCode []string, q string, start, limit int, sort []string) (*nps.FeePassResponse, error) {
+	var resp nps.FeePassResponse
+	err := c.list("feespasses", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetLessonPlans(parkCode, stateCode []string, q string, start, limit int, sort []string) (*nps.LessonPlanResponse, error) {
+	var resp nps.LessonPlanResponse
+	err := c.list("lessonplans", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetParkBoundaries(sitecode string) (*nps.MapdataParkboundaryResponse, error) {
+	var resp nps.MapdataParkboundaryResponse
+	err := c.document("mapdata_parkboundaries_"+sitecode, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetMultimediaAudio(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaAudioResponse, error) {
+	var resp nps.MultimediaAudioResponse
+	err := c.list("multimedia_audio", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetMultimediaGalleries(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaGalleriesResponse, error) {
+	var resp nps.MultimediaGalleriesResponse
+	err := c.list("multimedia_galleries", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetMultimediaGalleriesAssets(id, galleryId string, parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaGalleriesAssetsResponse, error) {
+	var resp nps.MultimediaGalleriesAssetsResponse
+	err := c.list("multimedia_galleries_assets", query{
+		IDs:        one(id),
+		ParkCodes:  parkCode,
+		StateCodes: stateCode,
+		Match:      map[string][]string{galleryIDField: one(galleryId)},
+		Q:          q,
+		Limit:      limit,
+		Start:      start,
+	}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetMultimediaVideos(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaVideosResponse, error) {
+	var resp nps.MultimediaVideosResponse
+	err := c.list("multimedia_videos", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetNewsReleases(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.NewsReleaseResponse, error) {
+	var resp nps.NewsReleaseResponse
+	err := c.list("newsreleases", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetParkinglots(parkCode, stateCode []string, q string, start, limit int) (*nps.ParkinglotResponse, error) {
+	var resp nps.ParkinglotResponse
+	err := c.list("parkinglots", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetParks(parkCode, stateCode []string, start, limit int, q string, sort []string) (*nps.ParkResponse, error) {
+	var resp nps.ParkResponse
+	err := c.list("parks", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetPassportStampLocations(parkCode, stateCode []string, q string, limit, start int) (*nps.PassportStampLocationResponse, error) {
+	var resp nps.PassportStampLocationResponse
+	err := c.list("passportstamplocations", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetPeople(parkCode, stateCode []string, q string, limit, start int) (*nps.PersonResponse, error) {
+	var resp nps.PersonResponse
+	err := c.list("people", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetPlaces(parkCode, stateCode []string, q string, limit, start int) (*nps.PlaceResponse, error) {
+	var resp nps.PlaceResponse
+	err := c.list("places", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetRoadEvents(parkCode, eventType string) (*nps.RoadEventResponse, error) {
+	var resp nps.RoadEventResponse
+	err := c.document("roadevents", &resp)
+	return &resp, err
+}
+
+func (c *Client) GetThingsToDo(id, parkCode, stateCode, q string, limit, start int, sort []string) (*nps.ThingsToDoResponse, error) {
+	var resp nps.ThingsToDoResponse
+	err := c.list("thingstodo", query{IDs: one(id), ParkCodes: one(parkCode), StateCodes: one(stateCode), Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetTopics(id, q string, limit, start int, sort string) (*nps.TopicResponse, error) {
+	var resp nps.TopicResponse
+	err := c.list("topics", query{IDs: one(id), Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetTopicParks(id []string, q string, limit, start int, sort string) (*nps.TopicParkResponse, error) {
+	var resp nps.TopicParkResponse
+	err := c.list("topics_parks", query{IDs: id, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetTours(id, parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.TourResponse, error) {
+	var resp nps.TourResponse
+	err := c.list("tours", query{IDs: id, ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetVisitorCenters(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.VisitorCenterResponse, error) {
+	var resp nps.VisitorCenterResponse
+	err := c.list("visitorcenters", query{ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetWebcams(id string, parkCode, stateCode []string, q string, limit, start int) (*nps.WebcamResponse, error) {
+	var resp nps.WebcamResponse
+	err := c.list("webcams", query{IDs: one(id), ParkCodes: parkCode, StateCodes: stateCode, Q: q, Limit: limit, Start: start}, &resp)
+	return &resp, err
+}
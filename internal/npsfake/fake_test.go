@@ -0,0 +1,263 @@
+package npsfake
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFixturesDecode(t *testing.T) {
+	c := New(Fixtures())
+
+	parks, err := c.GetParks(nil, nil, 0, 500, "", nil)
+	if err != nil || len(parks.Data) != 3 {
+		t.Fatalf("GetParks: got %d parks, err %v", len(parks.Data), err)
+	}
+	events, err := c.GetEvents(nil, nil, nil, nil, nil, nil, nil, nil, "", "", nil, "", "", 50, 1, true)
+	if err != nil || len(events.Data) != 3 {
+		t.Fatalf("GetEvents: got %d events, err %v", len(events.Data), err)
+	}
+	if _, err := c.GetThingsToDo("", "", "", "", 50, 0, nil); err != nil {
+		t.Errorf("GetThingsToDo: %v", err)
+	}
+	if _, err := c.GetCampgrounds(nil, nil, "", 50, 0, nil); err != nil {
+		t.Errorf("GetCampgrounds: %v", err)
+	}
+	if _, err := c.GetNewsReleases(nil, nil, "", 50, 0, nil); err != nil {
+		t.Errorf("GetNewsReleases: %v", err)
+	}
+	if _, err := c.GetArticles(nil, nil, "", 50, 0); err != nil {
+		t.Errorf("GetArticles: %v", err)
+	}
+	if _, err := c.GetAlerts(nil, nil, "", 50, 0); err != nil {
+		t.Errorf("GetAlerts: %v", err)
+	}
+	if _, err := c.GetMultimediaGalleries(nil, nil, "", 0, 50); err != nil {
+		t.Errorf("GetMultimediaGalleries: %v", err)
+	}
+	if _, err := c.GetMultimediaGalleriesAssets("", "", nil, nil, "", 0, 50); err != nil {
+		t.Errorf("GetMultimediaGalleriesAssets: %v", err)
+	}
+	if _, err := c.GetMultimediaVideos(nil, nil, "", 0, 50); err != nil {
+		t.Errorf("GetMultimediaVideos: %v", err)
+	}
+	if _, err := c.GetMultimediaAudio(nil, nil, "", 0, 50); err != nil {
+		t.Errorf("GetMultimediaAudio: %v", err)
+	}
+	if _, err := c.GetWebcams("", nil, nil, "", 50, 0); err != nil {
+		t.Errorf("GetWebcams: %v", err)
+	}
+	if _, err := c.GetVisitorCenters(nil, nil, "", 50, 0, nil); err != nil {
+		t.Errorf("GetVisitorCenters: %v", err)
+	}
+	if _, err := c.GetFeesPasses(nil, nil, "", 0, 50, nil); err != nil {
+		t.Errorf("GetFeesPasses: %v", err)
+	}
+	if _, err := c.GetParkinglots(nil, nil, "", 0, 50); err != nil {
+		t.Errorf("GetParkinglots: %v", err)
+	}
+	if _, err := c.GetTours(nil, nil, nil, "", 50, 0, nil); err != nil {
+		t.Errorf("GetTours: %v", err)
+	}
+	if _, err := c.GetActivities("", "", 50, 0, ""); err != nil {
+		t.Errorf("GetActivities: %v", err)
+	}
+	if _, err := c.GetAmenities(nil, "", 50, 0); err != nil {
+		t.Errorf("GetAmenities: %v", err)
+	}
+
+	// Endpoints without fixtures answer with nothing rather than an error
+	people, err := c.GetPeople(nil, nil, "", 50, 0)
+	if err != nil || len(people.Data) != 0 || people.Total != "0" {
+		t.Errorf("GetPeople: got %+v, err %v", people, err)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	c := New(Fixtures())
+
+	tests := []struct {
+		name string
+		call func() (int, error)
+		want int
+	}{
+		{"parks by state", func() (int, error) {
+			r, err := c.GetParks(nil, []string{"ca"}, 0, 50, "", nil)
+			return len(r.Data), err
+		}, 1},
+		{"parks by code", func() (int, error) {
+			r, err := c.GetParks([]string{"grca", "acad"}, nil, 0, 50, "", nil)
+			return len(r.Data), err
+		}, 2},
+		{"events by site code", func() (int, error) {
+			r, err := c.GetEvents([]string{"yose"}, nil, nil, nil, nil, nil, nil, nil, "", "", nil, "", "", 10, 0, false)
+			return len(r.Data), err
+		}, 2},
+		{"events by state via the parks fixture", func() (int, error) {
+			r, err := c.GetEvents(nil, []string{"AZ"}, nil, nil, nil, nil, nil, nil, "", "", nil, "", "", 10, 0, false)
+			return len(r.Data), err
+		}, 1},
+		{"events by type", func() (int, error) {
+			r, err := c.GetEvents(nil, nil, nil, nil, nil, nil, nil, nil, "", "", []string{"Talk"}, "", "", 10, 0, false)
+			return len(r.Data), err
+		}, 2},
+		{"event by id", func() (int, error) {
+			r, err := c.GetEvents(nil, nil, nil, nil, nil, nil, nil, nil, "", "", nil, "4C2C3E30-7D8F-4091-ACBD-2E3F4A5B6C77", "", 1, 0, true)
+			return len(r.Data), err
+		}, 1},
+		{"things to do by related park", func() (int, error) {
+			r, err := c.GetThingsToDo("", "acad", "", "", 10, 0, nil)
+			return len(r.Data), err
+		}, 1},
+		{"campgrounds by text", func() (int, error) {
+			r, err := c.GetCampgrounds(nil, nil, "south rim", 10, 0, nil)
+			return len(r.Data), err
+		}, 1},
+		{"tours by nested park", func() (int, error) {
+			r, err := c.GetTours(nil, []string{"yose"}, nil, "", 10, 0, nil)
+			return len(r.Data), err
+		}, 1},
+		{"gallery assets by gallery", func() (int, error) {
+			r, err := c.GetMultimediaGalleriesAssets("", "9B8A7C6D-5E4F-4A3B-2C1D-0E9F8A7B6C5D", []string{"yose"}, nil, "", 0, 500)
+			return len(r.Data), err
+		}, 2},
+		{"gallery assets for another gallery", func() (int, error) {
+			r, err := c.GetMultimediaGalleriesAssets("", "missing", []string{"yose"}, nil, "", 0, 500)
+			return len(r.Data), err
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d items, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaging(t *testing.T) {
+	c := New(Fixtures())
+
+	campgrounds, err := c.GetCampgrounds(nil, nil, "", 2, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(campgrounds.Data) != 1 || campgrounds.Total != "3" || campgrounds.Start != "2" {
+		t.Errorf("campgrounds page: got %d items, total %s, start %s", len(campgrounds.Data), campgrounds.Total, campgrounds.Start)
+	}
+
+	// Event pages are numbered from 1, like the live API
+	events, err := c.GetEvents(nil, nil, nil, nil, nil, nil, nil, nil, "", "", nil, "", "", 2, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Data) != 1 || events.PageNumber != "2" {
+		t.Errorf("events page 2: got %d items, page %s", len(events.Data), events.PageNumber)
+	}
+
+	if c.Calls("events") != 1 || c.Calls("campgrounds") != 1 {
+		t.Errorf("got %d events and %d campgrounds calls, want 1 each", c.Calls("events"), c.Calls("campgrounds"))
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	source := New(Fixtures())
+	rec, err := NewRecorder(source, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := rec.GetParks(nil, nil, 0, 500, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Recording the same parks again replaces them rather than duplicating them
+	if _, err := rec.GetParks([]string{"yose"}, nil, 0, 500, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	const galleryID = "9B8A7C6D-5E4F-4A3B-2C1D-0E9F8A7B6C5D"
+	if _, err := rec.GetMultimediaGalleriesAssets("", galleryID, []string{"yose"}, nil, "", 0, 500); err != nil {
+		t.Fatal(err)
+	}
+
+	replay := New(os.DirFS(dir))
+	got, err := replay.GetParks(nil, nil, 0, 500, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed parks differ from recorded parks")
+	}
+
+	assets, err := replay.GetMultimediaGalleriesAssets("", galleryID, []string{"yose"}, nil, "", 0, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets.Data) != 2 {
+		t.Errorf("replayed %d gallery assets, want 2", len(assets.Data))
+	}
+
+	// Nothing was recorded for endpoints that weren't called
+	if _, err := os.Stat(dir + "/events.json"); !os.IsNotExist(err) {
+		t.Errorf("events fixture should not exist, stat err %v", err)
+	}
+}
+
+func TestFail(t *testing.T) {
+	c := New(Fixtures())
+	unavailable := errors.New("nps api unavailable")
+
+	c.Fail("campgrounds", unavailable)
+	if _, err := c.GetCampgrounds(nil, nil, "", 10, 0, nil); !errors.Is(err, unavailable) {
+		t.Errorf("GetCampgrounds: got err %v, want %v", err, unavailable)
+	}
+	if _, err := c.GetParks(nil, nil, 0, 10, "", nil); err != nil {
+		t.Errorf("GetParks should not fail: %v", err)
+	}
+
+	c.Fail("*", unavailable)
+	if _, err := c.GetParks(nil, nil, 0, 10, "", nil); !errors.Is(err, unavailable) {
+		t.Errorf("GetParks: got err %v, want %v", err, unavailable)
+	}
+
+	c.Fail("*", nil)
+	c.Fail("campgrounds", nil)
+	if _, err := c.GetCampgrounds(nil, nil, "", 10, 0, nil); err != nil {
+		t.Errorf("GetCampgrounds after clearing the failure: %v", err)
+	}
+}
+
+func TestHold(t *testing.T) {
+	c := New(Fixtures())
+	release := c.Hold("campgrounds")
+
+	done := make(chan error)
+	go func() {
+		_, err := c.GetCampgrounds(nil, nil, "", 10, 0, nil)
+		done <- err
+	}()
+	for c.Calls("campgrounds") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("held call returned before release: %v", err)
+	default:
+	}
+	if _, err := c.GetParks(nil, nil, 0, 10, "", nil); err != nil {
+		t.Errorf("GetParks should not be held: %v", err)
+	}
+
+	release()
+	if err := <-done; err != nil {
+		t.Errorf("released call: %v", err)
+	}
+	release() // Releasing again is harmless
+}
@@ -0,0 +1,317 @@
+package npsfake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ztkent/go-nps"
+)
+
+// galleryIDField tags recorded gallery assets with their gallery, the NPS response doesn't say
+const galleryIDField = "galleryId"
+
+// Recorder passes calls through to a live NPS API and merges every successful response into
+// fixture files, so a session against the real API can be replayed offline with New
+type Recorder struct {
+	api nps.NpsApi
+	dir string
+	mu  sync.Mutex
+}
+
+var _ nps.NpsApi = (*Recorder)(nil)
+
+// NewRecorder records the responses of api into fixture files in dir
+func NewRecorder(api nps.NpsApi, dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	return &Recorder{api: api, dir: dir}, nil
+}
+
+// recordList merges the items of a list response into the endpoint's fixture, replacing items
+// that were recorded before
+func (r *Recorder) recordList(endpoint string, resp interface{}, err error, tags map[string]string) {
+	if err != nil {
+		return
+	}
+	if err := r.mergeFixture(endpoint, resp, tags); err != nil {
+		log.Printf("Failed to record %s fixture: %v", endpoint, err)
+	}
+}
+
+func (r *Recorder) mergeFixture(endpoint string, resp interface{}, tags map[string]string) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	var recorded struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := filepath.Join(r.dir, endpoint+".json")
+	var fixture struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read fixture: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(existing, &fixture); err != nil {
+			return fmt.Errorf("failed to decode fixture: %w", err)
+		}
+	}
+
+	index := make(map[string]int, len(fixture.Data))
+	for i, item := range fixture.Data {
+		index[itemKey(item)] = i
+	}
+	for _, item := range recorded.Data {
+		for field, value := range tags {
+			item[field] = value
+		}
+		key := itemKey(item)
+		if i, ok := index[key]; ok {
+			fixture.Data[i] = item
+			continue
+		}
+		index[key] = len(fixture.Data)
+		fixture.Data = append(fixture.Data, item)
+	}
+	return writeFixture(path, fixture)
+}
+
+// recordDocument saves a single document response as its own fixture
+func (r *Recorder) recordDocument(name string, resp interface{}, err error) {
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := writeFixture(filepath.Join(r.dir, name+".json"), resp); err != nil {
+		log.Printf("Failed to record %s fixture: %v", name, err)
+	}
+}
+
+// itemKey identifies a recorded item, by ID where the endpoint has one
+func itemKey(item map[string]interface{}) string {
+	for _, field := range []string{"id", "eventid", "parkCode"} {
+		if values := fieldStrings(item, field); len(values) > 0 {
+			key := field + ":" + values[0]
+			if tag, ok := item[galleryIDField].(string); ok {
+				key += "/" + tag
+			}
+			return key
+		}
+	}
+	data, _ := json.Marshal(item)
+	return string(data)
+}
+
+// writeFixture writes v as indented JSON, through a temporary file so a failed write never
+// leaves a truncated fixture
+func writeFixture(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace fixture: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) GetActivities(id, q string, limit, start int, sort string) (*nps.ActivityResponse, error) {
+	resp, err := r.api.GetActivities(id, q, limit, start, sort)
+	r.recordList("activities", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetActivityParks(id []string, q string, limit, start int, sort string) (*nps.ActivityParkResponse, error) {
+	resp, err := r.api.GetActivityParks(id, q, limit, start, sort)
+	r.recordList("activities_parks", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetAlerts(parkCode, stateCode []string, q string, limit, start int) (*nps.AlertResponse, error) {
+	resp, err := r.api.GetAlerts(parkCode, stateCode, q, limit, start)
+	r.recordList("alerts", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetAmenities(id []string, q string, limit, start int) (*nps.AmenityResponse, error) {
+	resp, err := r.api.GetAmenities(id, q, limit, start)
+	r.recordList("amenities", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetAmenitiesParksPlaces(parkCode, id []string, q string, limit, start int, sort string) (*nps.AmenityParkPlaceResponse, error) {
+	resp, err := r.api.GetAmenitiesParksPlaces(parkCode, id, q, limit, start, sort)
+	r.recordDocument("amenities_parksplaces", resp, err)
+	return resp, err
+}
+
+func (r *Recorder) GetAmenitiesParksVisitorCenters(parkCode, id, q string, limit, start int, sort []string) (*nps.AmenityParkVisitorCenterResponse, error) {
+	resp, err := r.api.GetAmenitiesParksVisitorCenters(parkCode, id, q, limit, start, sort)
+	r.recordDocument("amenities_parksvisitorcenters", resp, err)
+	return resp, err
+}
+
+func (r *Recorder) GetArticles(parkCode, stateCode []string, q string, limit, start int) (*nps.ArticleData, error) {
+	resp, err := r.api.GetArticles(parkCode, stateCode, q, limit, start)
+	r.recordList("articles", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetCampgrounds(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.CampgroundData, error) {
+	resp, err := r.api.GetCampgrounds(parkCode, stateCode, q, limit, start, sort)
+	r.recordList("campgrounds", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetEvents(parkCode, stateCode, organization, subject, portal, tagsAll, tagsOne, tagsNone []string, dateStart, dateEnd string, eventType []string, id, q string, pageSize, pageNumber int, expandRecurring bool) (*nps.EventResponse, error) {
+	resp, err := r.api.GetEvents(parkCode, stateCode, organization, subject, portal, tagsAll, tagsOne, tagsNone, dateStart, dateEnd, eventType, id, q, pageSize, pageNumber, expandRecurring)
+	r.recordList("events", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetFeesPasses(parkCode, stateCode []string, q string, start, limit int, sort []string) (*nps.FeePassResponse, error) {
+	resp, err := r.api.GetFeesPasses(parkCode, stateCode, q, start, limit, sort)
+	r.recordList("feespasses", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetLessonPlans(parkCode, stateCode []string, q string, start, limit int, sort []string) (*nps.LessonPlanResponse, error) {
+	resp, err := r.api.GetLessonPlans(parkCode, stateCode, q, start, limit, sort)
+	r.recordList("lessonplans", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetParkBoundaries(sitecode string) (*nps.MapdataParkboundaryResponse, error) {
+	resp, err := r.api.GetParkBoundaries(sitecode)
+	r.recordDocument("mapdata_parkboundaries_"+sitecode, resp, err)
+	return resp, err
+}
+
+func (r *Recorder) GetMultimediaAudio(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaAudioResponse, error) {
+	resp, err := r.api.GetMultimediaAudio(parkCode, stateCode, q, start, limit)
+	r.recordList("multimedia_audio", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetMultimediaGalleries(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaGalleriesResponse, error) {
+	resp, err := r.api.GetMultimediaGalleries(parkCode, stateCode, q, start, limit)
+	r.recordList("multimedia_galleries", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetMultimediaGalleriesAssets(id, galleryId string, parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaGalleriesAssetsResponse, error) {
+	resp, err := r.api.GetMultimediaGalleriesAssets(id, galleryId, parkCode, stateCode, q, start, limit)
+	var tags map[string]string
+	if galleryId != "" {
+		tags = map[string]string{galleryIDField: galleryId}
+	}
+	r.recordList("multimedia_galleries_assets", resp, err, tags)
+	return resp, err
+}
+
+func (r *Recorder) GetMultimediaVideos(parkCode, stateCode []string, q string, start, limit int) (*nps.MultimediaVideosResponse, error) {
+	resp, err := r.api.GetMultimediaVideos(parkCode, stateCode, q, start, limit)
+	r.recordList("multimedia_videos", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetNewsReleases(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.NewsReleaseResponse, error) {
+	resp, err := r.api.GetNewsReleases(parkCode, stateCode, q, limit, start, sort)
+	r.recordList("newsreleases", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetParkinglots(parkCode, stateCode []string, q string, start, limit int) (*nps.ParkinglotResponse, error) {
+	resp, err := r.api.GetParkinglots(parkCode, stateCode, q, start, limit)
+	r.recordList("parkinglots", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetParks(parkCode, stateCode []string, start, limit int, q string, sort []string) (*nps.ParkResponse, error) {
+	resp, err := r.api.GetParks(parkCode, stateCode, start, limit, q, sort)
+	r.recordList("parks", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetPassportStampLocations(parkCode, stateCode []string, q string, limit, start int) (*nps.PassportStampLocationResponse, error) {
+	resp, err := r.api.GetPassportStampLocations(parkCode, stateCode, q, limit, start)
+	r.recordList("passportstamplocations", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetPeople(parkCode, stateCode []string, q string, limit, start int) (*nps.PersonResponse, error) {
+	resp, err := r.api.GetPeople(parkCode, stateCode, q, limit, start)
+	r.recordList("people", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetPlaces(parkCode, stateCode []string, q string, limit, start int) (*nps.PlaceResponse, error) {
+	resp, err := r.api.GetPlaces(parkCode, stateCode, q, limit, start)
+	r.recordList("places", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetRoadEvents(parkCode, eventType string) (*nps.RoadEventResponse, error) {
+	resp, err := r.api.GetRoadEvents(parkCode, eventType)
+	r.recordDocument("roadevents", resp, err)
+	return resp, err
+}
+
+func (r *Recorder) GetThingsToDo(id, parkCode, stateCode, q string, limit, start int, sort []string) (*nps.ThingsToDoResponse, error) {
+	resp, err := r.api.GetThingsToDo(id, parkCode, stateCode, q, limit, start, sort)
+	r.recordList("thingstodo", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetTopics(id, q string, limit, start int, sort string) (*nps.TopicResponse, error) {
+	resp, err := r.api.GetTopics(id, q, limit, start, sort)
+	r.recordList("topics", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetTopicParks(id []string, q string, limit, start int, sort string) (*nps.TopicParkResponse, error) {
+	resp, err := r.api.GetTopicParks(id, q, limit, start, sort)
+	r.recordList("topics_parks", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetTours(id, parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.TourResponse, error) {
+	resp, err := r.api.GetTours(id, parkCode, stateCode, q, limit, start, sort)
+	r.recordList("tours", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetVisitorCenters(parkCode, stateCode []string, q string, limit, start int, sort []string) (*nps.VisitorCenterResponse, error) {
+	resp, err := r.api.GetVisitorCenters(parkCode, stateCode, q, limit, start, sort)
+	r.recordList("visitorcenters", resp, err, nil)
+	return resp, err
+}
+
+func (r *Recorder) GetWebcams(id string, parkCode, stateCode []string, q string, limit, start int) (*nps.WebcamResponse, error) {
+	resp, err := r.api.GetWebcams(id, parkCode, stateCode, q, limit, start)
+	r.recordList("webcams", resp, err, nil)
+	return resp, err
+}
@@ -0,0 +1,101 @@
+// Package tlsreload serves a TLS certificate and key loaded from files, reloading them when either
+// file changes so certificates can be rotated without restarting the server.
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Certificate is a certificate and key pair kept in sync with the files they were loaded from
+type Certificate struct {
+	certPath string
+	keyPath  string
+	logger   *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	certMod time.Time // Modification times of the files the current pair was loaded from
+	keyMod  time.Time
+}
+
+// Load reads a PEM certificate and key, failing if either can't be read or they don't match
+func Load(certPath, keyPath string, logger *slog.Logger) (*Certificate, error) {
+	if certPath == "" || keyPath == "" {
+		return nil, errors.New("both a certificate and a key path are required")
+	}
+	c := &Certificate{certPath: certPath, keyPath: keyPath, logger: logger}
+	if _, err := c.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config.GetCertificate
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// Watch checks the files every interval until ctx is done, reloading the pair when either changes.
+// A pair that fails to load, like a certificate written before its key, is logged and the current
+// certificate kept until the next check.
+func (c *Certificate) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.reloadIfChanged(); err != nil {
+				c.logger.Error("Failed to reload TLS certificate, keeping the current one", "cert", c.certPath, "key", c.keyPath, "error", err)
+			}
+		}
+	}
+}
+
+// reloadIfChanged loads the pair if either file changed since it was last loaded, reporting whether it did
+func (c *Certificate) reloadIfChanged() (bool, error) {
+	certMod, err := modTime(c.certPath)
+	if err != nil {
+		return false, err
+	}
+	keyMod, err := modTime(c.keyPath)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.RLock()
+	unchanged := c.cert != nil && certMod.Equal(c.certMod) && keyMod.Equal(c.keyMod)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cert, c.certMod, c.keyMod = &cert, certMod, keyMod
+	c.mu.Unlock()
+	c.logger.Info("Loaded TLS certificate", "cert", c.certPath, "subject", cert.Leaf.Subject.String(), "expires", cert.Leaf.NotAfter)
+	return true, nil
+}
+
+func modTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,108 @@
+package tlsreload
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePair writes a self-signed certificate for name and its key, dated mod
+func writePair(t *testing.T, certPath, keyPath, name string, mod time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keyPath != "" {
+		writeFile(t, keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), mod)
+	}
+	writeFile(t, certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), mod)
+}
+
+func writeFile(t *testing.T, path string, data []byte, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func subject(t *testing.T, c *Certificate) string {
+	t.Helper()
+	cert, err := c.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	now := time.Now()
+	writePair(t, certPath, keyPath, "first", now)
+
+	c, err := Load(certPath, keyPath, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if got := subject(t, c); got != "first" {
+		t.Errorf("got %q, want first", got)
+	}
+	if reloaded, err := c.reloadIfChanged(); reloaded || err != nil {
+		t.Errorf("reloaded unchanged files: %v, %v", reloaded, err)
+	}
+
+	writePair(t, certPath, keyPath, "second", now.Add(time.Minute))
+	if reloaded, err := c.reloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("didn't reload changed files: %v, %v", reloaded, err)
+	}
+	if got := subject(t, c); got != "second" {
+		t.Errorf("got %q after reloading, want second", got)
+	}
+
+	// A certificate written without its new key doesn't match, so the current pair is kept
+	writePair(t, certPath, "", "third", now.Add(2*time.Minute))
+	if _, err := c.reloadIfChanged(); err == nil {
+		t.Error("loaded a certificate that doesn't match its key")
+	}
+	if got := subject(t, c); got != "second" {
+		t.Errorf("got %q after a failed reload, want second", got)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load("", "", slog.New(slog.DiscardHandler)); err == nil {
+		t.Error("loaded without paths")
+	}
+	dir := t.TempDir()
+	if _, err := Load(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), slog.New(slog.DiscardHandler)); err == nil {
+		t.Error("loaded missing files")
+	}
+}
@@ -1,40 +1,65 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
-	_ "embed"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	_ "embed"
-
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ztkent/parks-explorer/internal/config"
 	"github.com/ztkent/parks-explorer/internal/dashboard"
+	"github.com/ztkent/parks-explorer/internal/logging"
+	"github.com/ztkent/parks-explorer/internal/metrics"
+	"github.com/ztkent/parks-explorer/internal/tlsreload"
 	"github.com/ztkent/replay"
 )
 
-//go:embed certs/parks_cert.pem
-var certPEM []byte
-
-//go:embed certs/parks_key.pem
-var keyPEM []byte
+const (
+	// shutdownTimeout is how long in-flight requests get to finish after SIGTERM
+	shutdownTimeout = 30 * time.Second
+	// certCheckInterval is how often CERT_PATH and CERT_KEY_PATH are checked for a new certificate
+	certCheckInterval = time.Minute
+)
 
 func main() {
-	// Set default database path if not provided
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "./data/dashboard.db"
+	// Read settings from flags, the environment and CONFIG_FILE, refusing to start if any are invalid
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
 	}
 
-	dashManager := dashboard.NewDashboard(os.Getenv("NPS_API_KEY"), dbPath)
+	// Log JSON in production and text in dev, at LOG_LEVEL
+	logger := logging.New(os.Stdout, cfg.Env, cfg.LogLevel)
+	slog.SetDefault(logger)
+
+	// Stop serving on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore the default handling once shutdown starts, so a second signal exits immediately
+		<-ctx.Done()
+		stop()
+	}()
+
+	dashManager := dashboard.NewDashboard(cfg, logger)
 	// Initialize router and middleware
 	r := chi.NewRouter()
-	// Log request and recover from panics
-	r.Use(middleware.Logger)
+	// Tag each request with an ID, log a summary of it and recover from panics
+	r.Use(middleware.RequestID)
+	r.Use(logging.Middleware(logger))
 	r.Use(middleware.Recoverer)
 
 	// Define routes
@@ -45,37 +70,99 @@ func main() {
 		replay.WithTTL(6*time.Hour),
 		replay.WithMaxTTL(24*time.Hour),
 		replay.WithCacheFilters([]string{"URL", "Method"}),
-		replay.WithLogger(log.New(os.Stdout, "replay: ", log.LstdFlags)),
+		replay.WithLogger(slog.NewLogLogger(logger.With("component", "replay").Handler(), slog.LevelDebug)),
 	))
 
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8086" // Default port
+	server := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: r,
+		// The write timeout leaves room for a cold park tab or calendar feed waiting on the NPS API
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      2 * time.Minute,
+		IdleTimeout:       2 * time.Minute,
+		ErrorLog:          slog.NewLogLogger(logger.With("component", "http").Handler(), slog.LevelWarn),
 	}
-
-	fmt.Println("Starting server on port", port)
-	if os.Getenv("ENV") == "dev" {
-		// Development mode - serve HTTP only
-		log.Fatal(http.ListenAndServe(":"+port, r))
-	} else {
-		// Production mode - serve HTTPS with embedded certificates
-		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if !cfg.IsDev() {
+		// Production mode - serve HTTPS, picking up renewed certificates without a restart
+		cert, err := tlsreload.Load(cfg.CertPath, cfg.CertKeyPath, logger)
 		if err != nil {
-			log.Fatal("Failed to load embedded certificates:", err)
+			logger.Error("Failed to load TLS certificate from CERT_PATH and CERT_KEY_PATH", "error", err)
+			os.Exit(1)
 		}
-		tlsConfig := &tls.Config{
-			Certificates: []tls.Certificate{cert},
+		go cert.Watch(ctx, certCheckInterval)
+		server.TLSConfig = &tls.Config{
+			GetCertificate: cert.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
 		}
-		server := &http.Server{
-			Addr:      ":" + port,
-			Handler:   r,
-			TLSConfig: tlsConfig,
+	}
+
+	// Prometheus metrics get a listener of their own, so they aren't public along with the site
+	if cfg.MetricsAddr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler())
+		metricsServer := &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: 10 * time.Second,
+			ErrorLog:          slog.NewLogLogger(logger.With("component", "metrics").Handler(), slog.LevelWarn),
+		}
+		go func() {
+			if err := serve(ctx, metricsServer, logger); err != nil {
+				logger.Error("Metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	if err := serve(ctx, server, logger); err != nil {
+		logger.Error("Server stopped", "error", err)
+		dashManager.Close()
+		os.Exit(1)
+	}
+	// Stop the background sync and close the database once in-flight requests are done
+	if err := dashManager.Close(); err != nil {
+		logger.Error("Failed to close dashboard", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Server stopped")
+}
+
+// serve runs the server until it fails or ctx is done, then lets in-flight requests finish.
+// It serves HTTPS when the server has a TLS config, and HTTP otherwise.
+func serve(ctx context.Context, server *http.Server, logger *slog.Logger) error {
+	errs := make(chan error, 1)
+	go func() {
+		logger.Info("Starting server", "addr", server.Addr, "tls", server.TLSConfig != nil)
+		if server.TLSConfig != nil {
+			errs <- server.ListenAndServeTLS("", "")
+		} else {
+			errs <- server.ListenAndServe()
 		}
-		log.Fatal(server.ListenAndServeTLS("", ""))
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down, waiting for in-flight requests", "timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
 	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 func DefineRoutes(r *chi.Mux, dashManager *dashboard.Dashboard, cache *replay.Cache) {
+	// Count and time requests by route, and report the response cache
+	r.Use(metrics.Middleware)
+	metrics.SetResponseCache(cache)
+
 	// Apply visitor tracking middleware
 	r.Use(dashManager.TagVistorsMiddleware)
 
@@ -86,6 +173,17 @@ func DefineRoutes(r *chi.Mux, dashManager *dashboard.Dashboard, cache *replay.Ca
 	r.Get("/camping", cache.MiddlewareFunc(dashManager.CampingPageHandler))
 	r.Get("/news", cache.MiddlewareFunc(dashManager.NewsPageHandler))
 	r.Get("/parks/{slug}", cache.MiddlewareFunc(dashManager.ParkPageHandler))
+	r.Get("/my-parks", cache.MiddlewareFunc(dashManager.MyParksPageHandler))
+
+	// News feeds set their own Content-Type, which replay drops on a cache miss
+	r.Get("/feeds/news.rss", dashManager.NewsRSSHandler)
+	r.Get("/feeds/news.atom", dashManager.NewsAtomHandler)
+	r.Get("/parks/{slug}/feed.atom", dashManager.ParkNewsAtomHandler)
+
+	// Trip pages are per user, so never cached
+	r.Get("/trips", dashManager.TripsPageHandler)
+	r.Get("/trips/{tripID}", dashManager.TripPageHandler)
+	r.Get("/trips/{tripID}/print", dashManager.TripPrintHandler)
 
 	// Serve specific files from static directory at top level
 	r.Get("/robots.txt", dashManager.TopLevelStaticFileHandler("robots.txt"))
@@ -106,14 +204,52 @@ func DefineRoutes(r *chi.Mux, dashManager *dashboard.Dashboard, cache *replay.Ca
 		// Analytics routes
 		r.Get("/analytics/config", dashManager.AnalyticsConfigHandler)
 
-		// Image proxy route for secure image serving
-		r.Get("/image-proxy", cache.MiddlewareFunc(dashManager.ImageProxyHandler))
+		// Favorites routes, per user so never cached
+		r.Get("/favorites/parks", dashManager.FavoriteParksHandler)
+		r.Group(func(r chi.Router) {
+			r.Use(dashManager.AuthMiddleware)
+			r.Get("/favorites", dashManager.FavoritesHandler)
+			r.Put("/favorites/{parkCode}", dashManager.AddFavoriteHandler)
+			r.Delete("/favorites/{parkCode}", dashManager.RemoveFavoriteHandler)
+		})
+
+		// Trip planner routes
+		r.Group(func(r chi.Router) {
+			r.Use(dashManager.AuthMiddleware)
+			r.Get("/trips", dashManager.TripsHandler)
+			r.Post("/trips", dashManager.CreateTripHandler)
+			r.Get("/trips/{tripID}", dashManager.TripHandler)
+			r.Patch("/trips/{tripID}", dashManager.UpdateTripHandler)
+			r.Delete("/trips/{tripID}", dashManager.DeleteTripHandler)
+			r.Post("/trips/{tripID}/items", dashManager.AddTripItemHandler)
+			r.Patch("/trips/{tripID}/items/{itemID}", dashManager.UpdateTripItemHandler)
+			r.Delete("/trips/{tripID}/items/{itemID}", dashManager.DeleteTripItemHandler)
+		})
+
+		// Admin routes
+		r.Group(func(r chi.Router) {
+			r.Use(dashManager.AdminMiddleware)
+			r.Get("/admin/sync-runs", dashManager.SyncRunsHandler)
+			r.Get("/admin/schema", dashManager.SchemaHandler)
+			r.Get("/admin/status", dashManager.StatusHandler)
+		})
+
+		// Image proxy route for secure image serving. Kept out of replay, which would hold its lock
+		// through the fetch and resize and drop the caching headers on a miss. Resized images are
+		// cached on disk, and browsers cache every image through Cache-Control.
+		r.Get("/image-proxy", dashManager.ImageProxyHandler)
 
 		// HTMX routes
 		r.Get("/auth-status", dashManager.AuthStatusHandler)
 		r.Get("/parks", cache.MiddlewareFunc(dashManager.ParksHandler))
 		r.Get("/parks/featured", cache.MiddlewareFunc(dashManager.FeaturedParksHandler))
 		r.Get("/parks/search", cache.MiddlewareFunc(dashManager.ParkSearchHandler))
+		// JSON, which replay would serve as text/plain on a cache miss, read from SQLite anyway
+		r.Get("/parks/nearby", dashManager.NearbyParksHandler)
+
+		// Full-text search across parks, things to do, events and news, JSON read from SQLite so
+		// kept out of replay, which would serve it as text/plain on a cache miss
+		r.Get("/search", dashManager.SearchHandler)
 
 		// Things To Do routes
 		r.Get("/things-to-do/search", cache.MiddlewareFunc(dashManager.ThingsToDoSearchHandler))
@@ -121,6 +257,10 @@ func DefineRoutes(r *chi.Mux, dashManager *dashboard.Dashboard, cache *replay.Ca
 		// Events routes
 		r.Get("/events/search", cache.MiddlewareFunc(dashManager.EventsSearchHandler))
 		r.Get("/events/{eventID}/details", cache.MiddlewareFunc(dashManager.EventDetailsHandler))
+		// Calendars set Content-Type and Content-Disposition, which replay drops on a cache miss,
+		// so they're left to HTTP caches through Cache-Control instead
+		r.Get("/events/{eventID}/event.ics", dashManager.EventICSHandler)
+		r.Get("/events/calendar.ics", dashManager.EventsCalendarHandler)
 
 		// Camping routes
 		r.Get("/camping/search", cache.MiddlewareFunc(dashManager.CampingSearchHandler))
@@ -135,7 +275,30 @@ func DefineRoutes(r *chi.Mux, dashManager *dashboard.Dashboard, cache *replay.Ca
 		r.Get("/parks/{parkCode}/news", cache.MiddlewareFunc(dashManager.ParkNewsHandler))
 		r.Get("/parks/{parkCode}/details", cache.MiddlewareFunc(dashManager.ParkDetailsHandler))
 
+		// Versioned JSON API
+		r.Route("/v1", func(r chi.Router) {
+			r.Use(dashManager.ETagMiddleware)
+			r.NotFound(dashManager.APINotFoundHandler)
+			r.MethodNotAllowed(dashManager.APIMethodNotAllowedHandler)
+			r.Get("/parks", dashManager.APIParksHandler)
+			r.Get("/parks/{parkCode}", dashManager.APIParkHandler)
+			r.Get("/parks/{parkCode}/{tab}", dashManager.APIParkTabHandler)
+			r.Get("/things-to-do", dashManager.APIThingsToDoHandler)
+			r.Get("/events", dashManager.APIEventsHandler)
+			r.Get("/events/{eventID}", dashManager.APIEventHandler)
+			r.Get("/campgrounds", dashManager.APICampgroundsHandler)
+			r.Get("/news", dashManager.APINewsHandler)
+		})
+
 		// Template routes
 		r.Get("/templates/{template}", dashManager.TemplateHandler)
 	})
+
+	// Liveness and readiness probes, never cached
+	r.Get("/healthz", dashManager.HealthzHandler)
+	r.Get("/readyz", dashManager.ReadyzHandler)
+
+	// API documentation, generated from the routes above
+	r.Get("/api/openapi.json", dashManager.OpenAPIHandler(r))
+	r.Get("/api/docs", dashManager.APIDocsPageHandler)
 }
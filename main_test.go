@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ztkent/parks-explorer/internal/dashboard"
+	"github.com/ztkent/replay"
+)
+
+func testRouter() *chi.Mux {
+	r := chi.NewRouter()
+	DefineRoutes(r, &dashboard.Dashboard{}, replay.NewCache())
+	return r
+}
+
+func TestRoutesDocumented(t *testing.T) {
+	r := testRouter()
+
+	missing, err := dashboard.UndocumentedRoutes(r)
+	if err != nil {
+		t.Fatalf("failed to walk routes: %v", err)
+	}
+	for _, route := range missing {
+		t.Errorf("route %s is not documented in routeDocs", route)
+	}
+
+	stale, err := dashboard.UnregisteredRouteDocs(r)
+	if err != nil {
+		t.Fatalf("failed to walk routes: %v", err)
+	}
+	for _, route := range stale {
+		t.Errorf("routeDocs documents %s, which is not registered", route)
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	w := httptest.NewRecorder()
+	testRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json: got status %d", w.Code)
+	}
+
+	var spec struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if spec.OpenAPI == "" {
+		t.Error("spec has no openapi version")
+	}
+	for _, path := range []string{"/api/v1/parks", "/api/v1/news", "/api/events/search", "/static/{path}"} {
+		if _, ok := spec.Paths[path]["get"]; !ok {
+			t.Errorf("spec is missing GET %s", path)
+		}
+	}
+	for _, schema := range []string{"ApiPark", "UnifiedNewsItem", "Trip", "SearchResult"} {
+		if _, ok := spec.Components.Schemas[schema]; !ok {
+			t.Errorf("spec is missing schema %s", schema)
+		}
+	}
+}
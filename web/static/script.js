@@ -128,12 +128,12 @@ document.addEventListener('htmx:afterSwap', (event) => {
         const searchInput = document.querySelector('.hero-search-input');
         
         // If search input is empty (showing featured parks), reset and show trigger
-        if (searchInput && searchInput.value.trim() === '' && trigger && hasMoreParks) {
+        if (searchInput && searchInput.value.trim() === '' && !isNearMeActive() && trigger && hasMoreParks) {
             currentOffset = 12;
             hasMoreParks = true;
             trigger.style.display = 'flex';
             setupInfiniteScrollTrigger();
-        } else if (searchInput && searchInput.value.trim() !== '' && trigger) {
+        } else if (searchInput && (searchInput.value.trim() !== '' || isNearMeActive()) && trigger) {
             // If there's a search query, hide the infinite scroll trigger
             trigger.style.display = 'none';
         }
@@ -256,12 +256,12 @@ document.addEventListener('htmx:afterSwap', (event) => {
         const searchInput = document.querySelector('.hero-search-input');
         
         // If search input is empty (showing featured parks), reset and show trigger
-        if (searchInput && searchInput.value.trim() === '' && trigger && hasMoreParks) {
+        if (searchInput && searchInput.value.trim() === '' && !isNearMeActive() && trigger && hasMoreParks) {
             currentOffset = 12;
             hasMoreParks = true;
             trigger.style.display = 'flex';
             setupInfiniteScrollTrigger();
-        } else if (searchInput && searchInput.value.trim() !== '' && trigger) {
+        } else if (searchInput && (searchInput.value.trim() !== '' || isNearMeActive()) && trigger) {
             // If there's a search query, hide the infinite scroll trigger
             trigger.style.display = 'none';
         }
@@ -304,7 +304,7 @@ function openGallerySlideshow(galleryId) {
         const previewImages = galleryCard.querySelectorAll('.gallery-preview-image');
         previewImages.forEach(img => {
             galleryImages.push({
-                url: img.src,
+                url: img.dataset.fullSrc || img.src,
                 alt: img.alt,
                 title: img.title || '',
                 description: '',
@@ -460,3 +460,232 @@ function applyTheme(theme) {
         metaThemeColor.setAttribute('content', theme === 'dark' ? '#1a1a1a' : '#2d5016');
     }
 }
+
+// "Near me" sorts park search results by distance from the visitor's location
+function isNearMeActive() {
+    const lat = document.getElementById('searchLat');
+    return !!(lat && lat.value);
+}
+
+document.addEventListener('click', (event) => {
+    const button = event.target.closest('#nearMeButton');
+    if (!button) return;
+
+    const latInput = document.getElementById('searchLat');
+    const lngInput = document.getElementById('searchLng');
+    const searchInput = document.querySelector('.hero-search-input');
+    const runSearch = () => htmx.trigger(searchInput, 'search');
+
+    // Toggle off, back to plain search or featured parks
+    if (isNearMeActive()) {
+        latInput.value = '';
+        lngInput.value = '';
+        button.setAttribute('aria-pressed', 'false');
+        runSearch();
+        return;
+    }
+
+    if (!navigator.geolocation) {
+        alert('Location is not available in this browser.');
+        return;
+    }
+
+    button.disabled = true;
+    navigator.geolocation.getCurrentPosition((position) => {
+        // Rounded to ~1km, enough for road trip planning and friendlier to the response cache
+        latInput.value = position.coords.latitude.toFixed(2);
+        lngInput.value = position.coords.longitude.toFixed(2);
+        button.disabled = false;
+        button.setAttribute('aria-pressed', 'true');
+        runSearch();
+    }, () => {
+        button.disabled = false;
+        alert('Unable to get your location.');
+    }, { maximumAge: 10 * 60 * 1000, timeout: 10000 });
+});
+
+// Favorites: cards are cached and shared, so toggles render hidden and are filled in here
+// for signed-in visitors. favoriteParkCodes is null when signed out.
+let favoriteParkCodes = null;
+
+function loadFavorites() {
+    return fetch('/api/favorites', { credentials: 'same-origin' })
+        .then(response => response.ok ? response.json() : null)
+        .then(data => {
+            favoriteParkCodes = data ? new Set(data.park_codes) : null;
+            applyFavoriteState(document);
+            applyTripButtons(document);
+        })
+        .catch(() => {
+            favoriteParkCodes = null;
+        });
+}
+
+function applyFavoriteState(root) {
+    root.querySelectorAll('.favorite-toggle').forEach(button => {
+        if (!favoriteParkCodes) {
+            button.hidden = true;
+            return;
+        }
+        const favorite = favoriteParkCodes.has(button.dataset.parkCode);
+        button.hidden = false;
+        button.textContent = favorite ? '♥' : '♡';
+        button.title = favorite ? 'Remove from My Parks' : 'Save to My Parks';
+        button.setAttribute('aria-pressed', favorite ? 'true' : 'false');
+    });
+}
+
+document.addEventListener('DOMContentLoaded', loadFavorites);
+document.body.addEventListener('authChange', loadFavorites);
+document.addEventListener('htmx:afterSwap', (event) => {
+    applyFavoriteState(event.target);
+    applyTripButtons(event.target);
+});
+
+document.addEventListener('click', (event) => {
+    const button = event.target.closest('.favorite-toggle');
+    if (!button || !favoriteParkCodes) return;
+
+    // The toggle sits on top of the card link
+    event.preventDefault();
+    event.stopPropagation();
+
+    const parkCode = button.dataset.parkCode;
+    const favorite = favoriteParkCodes.has(parkCode);
+    button.disabled = true;
+    fetch('/api/favorites/' + encodeURIComponent(parkCode), {
+        method: favorite ? 'DELETE' : 'PUT',
+        credentials: 'same-origin'
+    })
+        .then(response => {
+            if (!response.ok) throw new Error('Failed to update favorite');
+            if (favorite) {
+                favoriteParkCodes.delete(parkCode);
+                // Unsaved parks leave the My Parks page
+                const card = button.closest('#favoriteParksGrid .park-card');
+                if (card) card.remove();
+            } else {
+                favoriteParkCodes.add(parkCode);
+            }
+            applyFavoriteState(document);
+        })
+        .catch(error => console.error(error))
+        .finally(() => {
+            button.disabled = false;
+        });
+});
+
+// Trip planner: "+ Add to trip" buttons on search results and park pages, shown to signed-in
+// visitors (favoriteParkCodes is only loaded for them)
+function applyTripButtons(root) {
+    root.querySelectorAll('.add-to-trip').forEach(button => {
+        button.hidden = !favoriteParkCodes;
+    });
+}
+
+function tripDialog() {
+    let dialog = document.getElementById('addToTripDialog');
+    if (dialog) return dialog;
+
+    dialog = document.createElement('dialog');
+    dialog.id = 'addToTripDialog';
+    dialog.className = 'trip-dialog';
+    dialog.innerHTML = `
+        <form method="dialog" class="trip-form">
+            <h3>Add to trip</h3>
+            <p class="trip-dialog-title"></p>
+            <label>Trip <select name="trip" class="trip-input"></select></label>
+            <label class="trip-dialog-new" hidden>New trip name <input type="text" name="name" class="trip-input" maxlength="100"></label>
+            <label>Day <input type="number" name="day" class="trip-input trip-days-input" min="1" value="1"></label>
+            <textarea name="notes" class="trip-input" placeholder="Notes (optional)"></textarea>
+            <div class="trip-actions">
+                <button value="cancel" class="secondary-btn">Cancel</button>
+                <button value="add" class="primary-btn">Add</button>
+            </div>
+        </form>`;
+    document.body.appendChild(dialog);
+
+    dialog.querySelector('select[name="trip"]').addEventListener('change', (event) => {
+        dialog.querySelector('.trip-dialog-new').hidden = event.target.value !== 'new';
+        dialog.updateDefaultDay();
+    });
+    return dialog;
+}
+
+// tripDayForDate returns the day of a trip a date falls on, or 1 if it can't be worked out
+function tripDayForDate(trip, date) {
+    if (!trip || !trip.start_date || !date) return 1;
+    const day = Math.round((new Date(date.slice(0, 10)) - new Date(trip.start_date)) / 86400000) + 1;
+    return day >= 1 && day <= trip.days ? day : 1;
+}
+
+function postJSON(url, body) {
+    return fetch(url, {
+        method: 'POST',
+        credentials: 'same-origin',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body)
+    }).then(response => {
+        if (!response.ok) return response.text().then(text => { throw new Error(text); });
+        return response.json();
+    });
+}
+
+document.addEventListener('click', (event) => {
+    const button = event.target.closest('.add-to-trip');
+    if (!button) return;
+    event.preventDefault();
+    event.stopPropagation();
+
+    const dialog = tripDialog();
+    const form = dialog.querySelector('form');
+    const select = form.querySelector('select[name="trip"]');
+
+    fetch('/api/trips', { credentials: 'same-origin' })
+        .then(response => {
+            if (!response.ok) throw new Error('Sign in to plan trips');
+            return response.json();
+        })
+        .then(data => {
+            const trips = data.trips || [];
+            select.innerHTML = '';
+            trips.forEach(trip => select.add(new Option(trip.name, trip.id)));
+            select.add(new Option('+ New trip...', 'new'));
+            select.value = trips.length ? String(trips[0].id) : 'new';
+
+            dialog.updateDefaultDay = () => {
+                const trip = trips.find(t => String(t.id) === select.value);
+                form.day.value = tripDayForDate(trip, button.dataset.date);
+            };
+            dialog.updateDefaultDay();
+            form.querySelector('.trip-dialog-new').hidden = select.value !== 'new';
+            form.notes.value = '';
+            form.name.value = '';
+            dialog.querySelector('.trip-dialog-title').textContent = button.dataset.title;
+
+            dialog.onclose = () => {
+                if (dialog.returnValue !== 'add') return;
+
+                const day = parseInt(form.day.value, 10) || 1;
+                const trip = select.value === 'new'
+                    ? postJSON('/api/trips', { name: form.name.value || button.dataset.title, days: day })
+                    : Promise.resolve({ id: select.value });
+                trip
+                    .then(trip => postJSON('/api/trips/' + trip.id + '/items', {
+                        item_type: button.dataset.itemType,
+                        item_id: button.dataset.itemId,
+                        park_code: button.dataset.parkCode,
+                        title: button.dataset.title,
+                        url: button.dataset.url,
+                        day: day,
+                        notes: form.notes.value
+                    }))
+                    .then(() => {
+                        button.textContent = '✓ Added to trip';
+                    })
+                    .catch(error => alert(error.message));
+            };
+            dialog.showModal();
+        })
+        .catch(error => alert(error.message));
+});
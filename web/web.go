@@ -0,0 +1,16 @@
+// Package web holds the HTML templates and static assets served by the dashboard,
+// embedded so the server runs as a single binary from any working directory.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed templates static
+var files embed.FS
+
+// Files returns the embedded web directory, with templates/ and static/ at its root
+func Files() fs.FS {
+	return files
+}